@@ -0,0 +1,166 @@
+// Package auth проверяет API-ключи запросов. Ключ администратора дает права
+// на удаление постов, блокировку авторов и статистику; ключ автора
+// определяет, от чьего имени запрос, — по нему открываются черновики и
+// публикация постов автора. Запросы без ключа обслуживаются как анонимные.
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// HeaderAPIKey — заголовок с API-ключом; также принимается
+// Authorization: Bearer <ключ>.
+const HeaderAPIKey = "X-API-Key"
+
+var (
+	// ErrUnauthorized — операция требует действительного API-ключа.
+	ErrUnauthorized = errors.New("операция доступна только с API-ключом администратора")
+	// ErrDisabled — API-ключи не настроены, административные операции отключены.
+	ErrDisabled = errors.New("административные операции отключены: API-ключи не настроены (API_KEYS)")
+	// ErrNoAuthor — операция требует ключа автора.
+	ErrNoAuthor = errors.New("операция доступна только автору: нужен ключ автора (AUTHOR_KEYS)")
+)
+
+// Keys — ключи доступа: Admin дают права администратора, Authors
+// сопоставляют ключ автора с его именем.
+type Keys struct {
+	Admin   []string
+	Authors map[string]string
+}
+
+// ParseAuthorKeys разбирает ключи авторов в виде автор=ключ и возвращает
+// их как ключ → автор.
+func ParseAuthorKeys(entries []string) (map[string]string, error) {
+	authors := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		author, key, ok := strings.Cut(entry, "=")
+		author, key = strings.TrimSpace(author), strings.TrimSpace(key)
+		if !ok || author == "" || key == "" {
+			return nil, fmt.Errorf("ключ автора %q: ожидается автор=ключ", entry)
+		}
+		if _, dup := authors[key]; dup {
+			return nil, fmt.Errorf("ключ автора %q указан дважды", author)
+		}
+		authors[key] = author
+	}
+	return authors, nil
+}
+
+type contextKey struct{}
+
+// state — результат проверки ключа в контексте запроса.
+type state struct {
+	enabled bool
+	admin   bool
+	author  string
+}
+
+// Middleware проверяет ключ запроса по keys. Запрос с неверным ключом
+// отклоняется с 401, без ключа — проходит как анонимный. Пустой keys.Admin
+// отключает административные операции.
+func Middleware(keys Keys, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		st := state{enabled: len(keys.Admin) > 0}
+		if key := requestKey(r); key != "" {
+			author, isAuthor := authorOf(keys.Authors, key)
+			switch {
+			case valid(keys.Admin, key):
+				st.admin = true
+			case isAuthor:
+				st.author = author
+			default:
+				w.Header().Set("WWW-Authenticate", `Bearer realm="ozon_test"`)
+				http.Error(w, "недействительный API-ключ", http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, st)))
+	})
+}
+
+// WithAdmin возвращает контекст с правами администратора, например для
+// внутренних вызовов и тестов.
+func WithAdmin(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, state{enabled: true, admin: true})
+}
+
+// WithAuthor возвращает контекст запроса от имени автора, например для
+// внутренних вызовов и тестов.
+func WithAuthor(ctx context.Context, author string) context.Context {
+	st, _ := ctx.Value(contextKey{}).(state)
+	st.author = author
+	return context.WithValue(ctx, contextKey{}, st)
+}
+
+// Author возвращает автора, чей ключ предъявлен в запросе.
+func Author(ctx context.Context) (string, bool) {
+	st, _ := ctx.Value(contextKey{}).(state)
+	return st.author, st.author != ""
+}
+
+// RequireAuthor возвращает автора запроса или ErrNoAuthor.
+func RequireAuthor(ctx context.Context) (string, error) {
+	author, ok := Author(ctx)
+	if !ok {
+		return "", ErrNoAuthor
+	}
+	return author, nil
+}
+
+// IsAdmin сообщает, предъявлен ли в запросе действительный ключ.
+func IsAdmin(ctx context.Context) bool {
+	st, _ := ctx.Value(contextKey{}).(state)
+	return st.admin
+}
+
+// Require возвращает nil для администратора, ErrDisabled — если ключи
+// не настроены, иначе ErrUnauthorized.
+func Require(ctx context.Context) error {
+	st, ok := ctx.Value(contextKey{}).(state)
+	switch {
+	case st.admin:
+		return nil
+	case ok && !st.enabled:
+		return ErrDisabled
+	default:
+		return ErrUnauthorized
+	}
+}
+
+func requestKey(r *http.Request) string {
+	if key := r.Header.Get(HeaderAPIKey); key != "" {
+		return key
+	}
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+// valid сравнивает ключ со всеми настроенными за постоянное время.
+func valid(keys []string, key string) bool {
+	match := 0
+	for _, k := range keys {
+		match |= subtle.ConstantTimeCompare([]byte(k), []byte(key))
+	}
+	return match == 1
+}
+
+// authorOf ищет автора по ключу, сравнивая со всеми ключами авторов за
+// постоянное время.
+func authorOf(authors map[string]string, key string) (string, bool) {
+	var author string
+	match := 0
+	for k, name := range authors {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			author, match = name, 1
+		}
+	}
+	return author, match == 1
+}
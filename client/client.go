@@ -0,0 +1,268 @@
+// Package client — типизированный Go-клиент GraphQL API. Методы запросов,
+// мутаций и подписок сгенерированы по graph/schema.graphql (generated.go)
+// и возвращают типы graph/model, поэтому не расходятся со схемой.
+//
+//	c := client.New("http://localhost:8080/query", client.WithAPIKey(key))
+//	post, err := c.CreatePost(ctx, "Заголовок", "Текст", "Автор", true)
+//	for comment, err := range c.AllComments(ctx, post.ID, 100) { ... }
+//
+// Запросы (query) при сетевых ошибках и ответах 429/502/503/504
+// повторяются с экспоненциальной паузой; мутации не повторяются.
+// Подписки работают по websocket (протокол graphql-transport-ws).
+package client
+
+//go:generate go run ../cmd/clientgen -schema ../graph/schema.graphql -o generated.go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultPageSize — размер страницы итераторов All*, если не задан.
+const DefaultPageSize = 100
+
+// Client — клиент GraphQL API. Безопасен для одновременного использования.
+type Client struct {
+	endpoint string
+	http     *http.Client
+	header   http.Header
+	attempts int
+	backoff  time.Duration
+}
+
+// Option настраивает клиента.
+type Option func(*Client)
+
+// WithAPIKey передает API-ключ администратора в заголовке X-API-Key.
+func WithAPIKey(key string) Option {
+	return WithHeader("X-API-Key", key)
+}
+
+// WithBearerToken передает ключ в заголовке Authorization: Bearer.
+func WithBearerToken(token string) Option {
+	return WithHeader("Authorization", "Bearer "+token)
+}
+
+// WithHeader добавляет заголовок ко всем запросам и к websocket-подключениям.
+func WithHeader(key, value string) Option {
+	return func(c *Client) { c.header.Set(key, value) }
+}
+
+// WithHTTPClient задает HTTP-клиент, например с таймаутом или TLS.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.http = hc }
+}
+
+// WithRetry задает число попыток запроса и начальную паузу между ними;
+// attempts = 1 отключает повторы.
+func WithRetry(attempts int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.attempts = max(attempts, 1)
+		c.backoff = backoff
+	}
+}
+
+// New создает клиента для адреса GraphQL, например http://host:8080/query.
+func New(endpoint string, opts ...Option) *Client {
+	c := &Client{
+		endpoint: endpoint,
+		http:     http.DefaultClient,
+		header:   make(http.Header),
+		attempts: 3,
+		backoff:  100 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Error — ошибка GraphQL из ответа сервера.
+type Error struct {
+	Message    string         `json:"message"`
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if code := e.Code(); code != "" {
+		return fmt.Sprintf("%s (%s)", e.Message, code)
+	}
+	return e.Message
+}
+
+// Code возвращает extensions.code, например UNAUTHENTICATED или CURSOR_EXPIRED.
+func (e *Error) Code() string {
+	code, _ := e.Extensions["code"].(string)
+	return code
+}
+
+// Errors — ошибки GraphQL одного ответа.
+type Errors []*Error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// ErrorCode возвращает код первой ошибки GraphQL в err или пустую строку.
+func ErrorCode(err error) string {
+	var list Errors
+	if errors.As(err, &list) && len(list) > 0 {
+		return list[0].Code()
+	}
+	var single *Error
+	if errors.As(err, &single) {
+		return single.Code()
+	}
+	return ""
+}
+
+// HTTPError — ответ сервера без тела GraphQL, например 401 от проверки ключа.
+type HTTPError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("сервер ответил %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Body)
+}
+
+// query выполняет запрос с повторами при временных ошибках.
+func (c *Client) query(ctx context.Context, document string, vars map[string]any, out any) error {
+	var err error
+	for attempt := 0; attempt < c.attempts; attempt++ {
+		if attempt > 0 {
+			pause := c.backoff << (attempt - 1)
+			select {
+			case <-ctx.Done():
+				return errors.Join(err, ctx.Err())
+			case <-time.After(pause):
+			}
+		}
+		if err = c.do(ctx, document, vars, out); !retryable(ctx, err) {
+			return err
+		}
+	}
+	return err
+}
+
+// mutate выполняет мутацию один раз: повтор мог бы выполнить ее дважды.
+func (c *Client) mutate(ctx context.Context, document string, vars map[string]any, out any) error {
+	return c.do(ctx, document, vars, out)
+}
+
+// retryable сообщает, стоит ли повторить запрос.
+func retryable(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	// Сетевые ошибки http.Client; ошибки GraphQL и разбора не повторяются
+	var netErr *url.Error
+	return errors.As(err, &netErr)
+}
+
+// do отправляет один запрос и разбирает data в out.
+func (c *Client) do(ctx context.Context, document string, vars map[string]any, out any) error {
+	body, err := json.Marshal(map[string]any{"query": document, "variables": vars})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors Errors          `json:"errors"`
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		// Не ответ GraphQL: отказ проверки ключа, балансировщик, не тот адрес
+		return &HTTPError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(raw))}
+	}
+	if len(result.Errors) > 0 {
+		return result.Errors
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return &HTTPError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(raw))}
+	}
+	if isNull(result.Data) {
+		return errors.New("ответ без data")
+	}
+	return json.Unmarshal(result.Data, out)
+}
+
+// paginate перебирает страницы limit/offset, пока не придет неполная.
+// Список отсортирован от новых к старым, поэтому новые элементы сдвигают
+// страницы; key (может быть nil) отсеивает повторы.
+func paginate[T any](pageSize int, fetch func(limit, offset int) ([]T, error), key func(T) string) iter.Seq2[T, error] {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	return func(yield func(T, error) bool) {
+		seen := make(map[string]bool)
+		for offset := 0; ; offset += pageSize {
+			page, err := fetch(pageSize, offset)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, v := range page {
+				if key != nil {
+					k := key(v)
+					if seen[k] {
+						continue
+					}
+					seen[k] = true
+				}
+				if !yield(v, nil) {
+					return
+				}
+			}
+			if len(page) < pageSize {
+				return
+			}
+		}
+	}
+}
+
+func isNull(data json.RawMessage) bool {
+	return len(data) == 0 || string(data) == "null"
+}
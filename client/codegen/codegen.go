@@ -0,0 +1,352 @@
+// Package codegen генерирует типизированный клиент (пакет client) по схеме
+// GraphQL: метод на каждое поле Query, Mutation и Subscription и итераторы
+// для запросов с пагинацией limit/offset. Типы берутся из graph/model,
+// поэтому клиент и сервер используют одни и те же структуры; собственные
+// скаляры схемы должны быть объявлены в graph/model под тем же именем.
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+
+	"github.com/99designs/gqlgen/codegen/templates"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Generate возвращает исходник generated.go пакета client для схемы.
+func Generate(name, schema string) ([]byte, error) {
+	s, err := gqlparser.LoadSchema(&ast.Source{Name: name, Input: schema})
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{schema: s, unions: map[string]bool{}, imports: map[string]bool{"context": true}}
+	roots := []struct {
+		kind string
+		def  *ast.Definition
+	}{
+		{"query", s.Query},
+		{"mutation", s.Mutation},
+		{"subscription", s.Subscription},
+	}
+	for _, root := range roots {
+		if root.def == nil {
+			continue
+		}
+		for _, f := range root.def.Fields {
+			if strings.HasPrefix(f.Name, "__") {
+				continue
+			}
+			if err := g.operation(root.kind, f); err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", root.def.Name, f.Name, err)
+			}
+		}
+	}
+	g.decoders()
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by cmd/clientgen from %s, DO NOT EDIT.\n\npackage client\n\nimport (\n", name)
+	for _, pkg := range []string{"context", "encoding/json", "fmt", "iter", "time"} {
+		if g.imports[pkg] {
+			fmt.Fprintf(&out, "%q\n", pkg)
+		}
+	}
+	fmt.Fprintf(&out, "\n%q\n)\n", "ozon_test/graph/model")
+	out.Write(g.buf.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("форматирование: %w", err)
+	}
+	return src, nil
+}
+
+type generator struct {
+	schema *ast.Schema
+	buf    bytes.Buffer
+	// unions — union-типы, для которых нужен декодер по __typename.
+	unions map[string]bool
+	// imports — пакеты, которые использует сгенерированный код.
+	imports map[string]bool
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// operation пишет документ запроса и метод клиента для корневого поля.
+func (g *generator) operation(kind string, f *ast.FieldDefinition) error {
+	method := templates.ToGo(f.Name)
+	document := kind + method + "Document"
+
+	var varDefs, argUses, vars []string
+	params := []string{"ctx context.Context"}
+	for _, a := range f.Arguments {
+		param := templates.ToGoPrivate(a.Name)
+		varDefs = append(varDefs, "$"+a.Name+": "+a.Type.String())
+		argUses = append(argUses, a.Name+": $"+a.Name)
+		params = append(params, param+" "+g.goType(a.Type))
+		vars = append(vars, fmt.Sprintf("%q: %s", a.Name, param))
+	}
+
+	query := kind + " " + method
+	if len(varDefs) > 0 {
+		query += "(" + strings.Join(varDefs, ", ") + ")"
+	}
+	query += " { " + f.Name
+	if len(argUses) > 0 {
+		query += "(" + strings.Join(argUses, ", ") + ")"
+	}
+	if sel := g.selection(f.Type.Name(), map[string]bool{}); sel != "" {
+		query += " " + sel
+	}
+	query += " }"
+
+	varsExpr := "nil"
+	if len(vars) > 0 {
+		varsExpr = "map[string]any{" + strings.Join(vars, ", ") + "}"
+	}
+
+	result := g.goType(f.Type)
+	union := g.isUnion(f.Type.Name())
+	if union {
+		if f.Type.Elem != nil {
+			return fmt.Errorf("списки union-типов не поддерживаются")
+		}
+		g.unions[f.Type.Name()] = true
+	}
+
+	g.printf("\nconst %s = %q\n\n", document, query)
+	g.printf("%s", comment(method, f.Description, defaultDescription(kind, f.Name)))
+
+	switch kind {
+	case "subscription":
+		g.imports["encoding/json"] = true
+		g.printf("func (c *Client) %s(%s) (*Subscription[%s], error) {\n", method, strings.Join(params, ", "), result)
+		g.printf("return subscribe(ctx, c, %s, %s, func(data json.RawMessage) (%s, error) {\n", document, varsExpr, result)
+		g.decodeResult(f.Name, result, union, "json.Unmarshal(data, &resp)")
+		g.printf("})\n}\n")
+
+	default:
+		call := "query"
+		if kind == "mutation" {
+			call = "mutate"
+		}
+		g.printf("func (c *Client) %s(%s) (%s, error) {\n", method, strings.Join(params, ", "), result)
+		g.decodeResult(f.Name, result, union, fmt.Sprintf("c.%s(ctx, %s, %s, &resp)", call, document, varsExpr))
+		g.printf("}\n")
+
+		if kind == "query" {
+			g.iterator(method, f, params, result)
+		}
+	}
+	return nil
+}
+
+// decodeResult пишет разбор поля name: load заполняет resp, union-тип
+// затем декодируется по __typename.
+func (g *generator) decodeResult(name, result string, union bool, load string) {
+	if union {
+		g.imports["encoding/json"] = true
+		g.printf("var resp struct {\nResult json.RawMessage `json:%q`\n}\n", name)
+		g.printf("if err := %s; err != nil {\nreturn nil, err\n}\n", load)
+		g.printf("return decode%s(resp.Result)\n", strings.TrimPrefix(result, "model."))
+		return
+	}
+	g.printf("var resp struct {\nResult %s `json:%q`\n}\n", result, name)
+	g.printf("err := %s\nreturn resp.Result, err\n", load)
+}
+
+// iterator пишет All<Метод> для запроса со списком и аргументами
+// limit: Int! и offset: Int!.
+func (g *generator) iterator(method string, f *ast.FieldDefinition, params []string, result string) {
+	limit, offset := f.Arguments.ForName("limit"), f.Arguments.ForName("offset")
+	if f.Type.Elem == nil || limit == nil || offset == nil ||
+		limit.Type.String() != "Int!" || offset.Type.String() != "Int!" {
+		return
+	}
+
+	var outer, inner []string
+	for i, a := range f.Arguments {
+		param := templates.ToGoPrivate(a.Name)
+		switch a.Name {
+		case "limit", "offset":
+			inner = append(inner, a.Name)
+		default:
+			outer = append(outer, params[i+1])
+			inner = append(inner, param)
+		}
+	}
+	elem := strings.TrimPrefix(result, "[]")
+	g.imports["iter"] = true
+
+	key := "nil"
+	if def := g.schema.Types[f.Type.Name()]; def.Kind == ast.Object && def.Fields.ForName("id") != nil {
+		key = fmt.Sprintf("func(v %s) string { return v.ID }", elem)
+	}
+
+	g.printf("\n// All%s перебирает все элементы %s страницами по pageSize (0 — DefaultPageSize).\n", method, f.Name)
+	g.printf("func (c *Client) All%s(%s) iter.Seq2[%s, error] {\n",
+		method, strings.Join(append([]string{"ctx context.Context"}, append(outer, "pageSize int")...), ", "), elem)
+	g.printf("return paginate(pageSize, func(limit, offset int) (%s, error) {\nreturn c.%s(ctx, %s)\n}, %s)\n}\n",
+		result, method, strings.Join(inner, ", "), key)
+}
+
+// decoders пишет функции разбора union-типов по __typename.
+func (g *generator) decoders() {
+	// Порядок как в схеме, чтобы вывод был стабильным
+	for _, name := range g.typeOrder() {
+		if !g.unions[name] {
+			continue
+		}
+		g.imports["encoding/json"], g.imports["fmt"] = true, true
+		def := g.schema.Types[name]
+		typ := templates.ToGo(name)
+		g.printf("\n// decode%s выбирает вариант %s по __typename.\n", typ, name)
+		g.printf("func decode%s(data json.RawMessage) (model.%s, error) {\n", typ, typ)
+		g.printf("if isNull(data) {\nreturn nil, nil\n}\n")
+		g.printf("var probe struct {\nTypename string `json:\"__typename\"`\n}\n")
+		g.printf("if err := json.Unmarshal(data, &probe); err != nil {\nreturn nil, err\n}\n")
+		g.printf("switch probe.Typename {\n")
+		for _, member := range def.Types {
+			g.printf("case %q:\nv := &model.%s{}\nerr := json.Unmarshal(data, v)\nreturn v, err\n", member, templates.ToGo(member))
+		}
+		g.printf("}\nreturn nil, fmt.Errorf(\"неизвестный вариант %s: %%q\", probe.Typename)\n}\n", name)
+	}
+}
+
+// typeOrder возвращает имена типов в порядке объявления в схеме.
+func (g *generator) typeOrder() []string {
+	type positioned struct {
+		name string
+		pos  int
+	}
+	var list []positioned
+	for name, def := range g.schema.Types {
+		if def.Position != nil && def.Position.Src != nil && !def.BuiltIn {
+			list = append(list, positioned{name, def.Position.Start})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].pos < list[j].pos })
+	names := make([]string, len(list))
+	for i, p := range list {
+		names[i] = p.name
+	}
+	return names
+}
+
+// selection строит набор полей типа: все поля без обязательных
+// аргументов, вложенные объекты целиком; циклы по типам обрываются.
+func (g *generator) selection(name string, path map[string]bool) string {
+	def := g.schema.Types[name]
+	switch def.Kind {
+	case ast.Object:
+		path[name] = true
+		defer delete(path, name)
+
+		var parts []string
+		for _, f := range def.Fields {
+			if strings.HasPrefix(f.Name, "__") || requiresArgs(f) {
+				continue
+			}
+			child := g.schema.Types[f.Type.Name()]
+			switch child.Kind {
+			case ast.Object, ast.Union:
+				if path[child.Name] {
+					continue
+				}
+				parts = append(parts, f.Name+" "+g.selection(child.Name, path))
+			default:
+				parts = append(parts, f.Name)
+			}
+		}
+		return "{ " + strings.Join(parts, " ") + " }"
+
+	case ast.Union:
+		parts := []string{"__typename"}
+		for _, member := range def.Types {
+			parts = append(parts, "... on "+member+" "+g.selection(member, path))
+		}
+		return "{ " + strings.Join(parts, " ") + " }"
+	}
+	return ""
+}
+
+func requiresArgs(f *ast.FieldDefinition) bool {
+	for _, a := range f.Arguments {
+		if a.Type.NonNull && a.DefaultValue == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *generator) isUnion(name string) bool {
+	def := g.schema.Types[name]
+	return def != nil && (def.Kind == ast.Union || def.Kind == ast.Interface)
+}
+
+// goType возвращает Go-тип для типа GraphQL так же, как его строит gqlgen
+// в graph/model: объекты — указатели, необязательные скаляры — указатели,
+// union — интерфейс.
+func (g *generator) goType(t *ast.Type) string {
+	if t.Elem != nil {
+		return "[]" + g.goType(t.Elem)
+	}
+	def := g.schema.Types[t.NamedType]
+	switch def.Kind {
+	case ast.Union, ast.Interface:
+		return "model." + templates.ToGo(def.Name)
+	case ast.Object, ast.InputObject:
+		return "*model." + templates.ToGo(def.Name)
+	}
+
+	var base string
+	switch t.NamedType {
+	case "ID", "String":
+		base = "string"
+	case "Int":
+		base = "int"
+	case "Float":
+		base = "float64"
+	case "Boolean":
+		base = "bool"
+	case "Time":
+		base = "time.Time"
+		g.imports["time"] = true
+	default:
+		base = "model." + templates.ToGo(t.NamedType)
+	}
+	if t.NonNull {
+		return base
+	}
+	return "*" + base
+}
+
+func defaultDescription(kind, field string) string {
+	switch kind {
+	case "mutation":
+		return "выполняет мутацию " + field + "."
+	case "subscription":
+		return "подписывается на " + field + " по websocket."
+	}
+	return "выполняет запрос " + field + "."
+}
+
+// comment оформляет описание поля из схемы как doc-комментарий метода.
+func comment(method, description, fallback string) string {
+	description = strings.TrimSpace(description)
+	if description == "" {
+		return "// " + method + " " + fallback + "\n"
+	}
+	var b strings.Builder
+	b.WriteString("// " + method + " " + fallback + "\n//\n")
+	for _, line := range strings.Split(description, "\n") {
+		b.WriteString(strings.TrimRight("// "+strings.TrimSpace(line), " ") + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,326 @@
+// Code generated by cmd/clientgen from schema.graphql, DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"time"
+
+	"ozon_test/graph/model"
+)
+
+const queryPostsDocument = "query Posts { posts { id title content author commentsAllowed createdAt status publishAt comments { id postId parentId author content createdAt cursor } } }"
+
+// Posts выполняет запрос posts.
+//
+// Автору, предъявившему свой ключ (AUTHOR_KEYS), видны и его черновики, запланированные и архивные посты.
+func (c *Client) Posts(ctx context.Context) ([]*model.Post, error) {
+	var resp struct {
+		Result []*model.Post `json:"posts"`
+	}
+	err := c.query(ctx, queryPostsDocument, nil, &resp)
+	return resp.Result, err
+}
+
+const queryPostDocument = "query Post($id: ID!) { post(id: $id) { id title content author commentsAllowed createdAt status publishAt comments { id postId parentId author content createdAt cursor } } }"
+
+// Post выполняет запрос post.
+func (c *Client) Post(ctx context.Context, id string) (*model.Post, error) {
+	var resp struct {
+		Result *model.Post `json:"post"`
+	}
+	err := c.query(ctx, queryPostDocument, map[string]any{"id": id}, &resp)
+	return resp.Result, err
+}
+
+const queryCommentsDocument = "query Comments($postID: ID!, $limit: Int!, $offset: Int!) { comments(postID: $postID, limit: $limit, offset: $offset) { id postId parentId author content createdAt cursor } }"
+
+// Comments выполняет запрос comments.
+func (c *Client) Comments(ctx context.Context, postID string, limit int, offset int) ([]*model.Comment, error) {
+	var resp struct {
+		Result []*model.Comment `json:"comments"`
+	}
+	err := c.query(ctx, queryCommentsDocument, map[string]any{"postID": postID, "limit": limit, "offset": offset}, &resp)
+	return resp.Result, err
+}
+
+// AllComments перебирает все элементы comments страницами по pageSize (0 — DefaultPageSize).
+func (c *Client) AllComments(ctx context.Context, postID string, pageSize int) iter.Seq2[*model.Comment, error] {
+	return paginate(pageSize, func(limit, offset int) ([]*model.Comment, error) {
+		return c.Comments(ctx, postID, limit, offset)
+	}, func(v *model.Comment) string { return v.ID })
+}
+
+const queryModerationLogDocument = "query ModerationLog($limit: Int!, $offset: Int!) { moderationLog(limit: $limit, offset: $offset) { id targetId postId kind author action decisions { filter action reason } createdAt } }"
+
+// ModerationLog выполняет запрос moderationLog.
+//
+// Требует API-ключа.
+func (c *Client) ModerationLog(ctx context.Context, limit int, offset int) ([]*model.ModerationRecord, error) {
+	var resp struct {
+		Result []*model.ModerationRecord `json:"moderationLog"`
+	}
+	err := c.query(ctx, queryModerationLogDocument, map[string]any{"limit": limit, "offset": offset}, &resp)
+	return resp.Result, err
+}
+
+// AllModerationLog перебирает все элементы moderationLog страницами по pageSize (0 — DefaultPageSize).
+func (c *Client) AllModerationLog(ctx context.Context, pageSize int) iter.Seq2[*model.ModerationRecord, error] {
+	return paginate(pageSize, func(limit, offset int) ([]*model.ModerationRecord, error) {
+		return c.ModerationLog(ctx, limit, offset)
+	}, func(v *model.ModerationRecord) string { return v.ID })
+}
+
+const queryStatsDocument = "query Stats { stats { posts lockedPosts comments bannedUsers subscriptions } }"
+
+// Stats выполняет запрос stats.
+//
+// Требует API-ключа.
+func (c *Client) Stats(ctx context.Context) (*model.Stats, error) {
+	var resp struct {
+		Result *model.Stats `json:"stats"`
+	}
+	err := c.query(ctx, queryStatsDocument, nil, &resp)
+	return resp.Result, err
+}
+
+const queryBannedUsersDocument = "query BannedUsers { bannedUsers { author reason bannedAt } }"
+
+// BannedUsers выполняет запрос bannedUsers.
+//
+// Требует API-ключа.
+func (c *Client) BannedUsers(ctx context.Context) ([]*model.BannedUser, error) {
+	var resp struct {
+		Result []*model.BannedUser `json:"bannedUsers"`
+	}
+	err := c.query(ctx, queryBannedUsersDocument, nil, &resp)
+	return resp.Result, err
+}
+
+const mutationCreatePostDocument = "mutation CreatePost($title: String!, $content: String!, $author: String!, $commentsAllowed: Boolean!) { createPost(title: $title, content: $content, author: $author, commentsAllowed: $commentsAllowed) { id title content author commentsAllowed createdAt status publishAt comments { id postId parentId author content createdAt cursor } } }"
+
+// CreatePost выполняет мутацию createPost.
+func (c *Client) CreatePost(ctx context.Context, title string, content string, author string, commentsAllowed bool) (*model.Post, error) {
+	var resp struct {
+		Result *model.Post `json:"createPost"`
+	}
+	err := c.mutate(ctx, mutationCreatePostDocument, map[string]any{"title": title, "content": content, "author": author, "commentsAllowed": commentsAllowed}, &resp)
+	return resp.Result, err
+}
+
+const mutationAddCommentDocument = "mutation AddComment($postId: ID!, $parentId: ID, $author: String!, $content: String!) { addComment(postId: $postId, parentId: $parentId, author: $author, content: $content) { id postId parentId author content createdAt cursor } }"
+
+// AddComment выполняет мутацию addComment.
+func (c *Client) AddComment(ctx context.Context, postID string, parentID *string, author string, content string) (*model.Comment, error) {
+	var resp struct {
+		Result *model.Comment `json:"addComment"`
+	}
+	err := c.mutate(ctx, mutationAddCommentDocument, map[string]any{"postId": postID, "parentId": parentID, "author": author, "content": content}, &resp)
+	return resp.Result, err
+}
+
+const mutationUpdateCommentDocument = "mutation UpdateComment($id: ID!, $content: String!) { updateComment(id: $id, content: $content) { id postId parentId author content createdAt cursor } }"
+
+// UpdateComment выполняет мутацию updateComment.
+//
+// Меняет текст комментария. Требует API-ключа.
+func (c *Client) UpdateComment(ctx context.Context, id string, content string) (*model.Comment, error) {
+	var resp struct {
+		Result *model.Comment `json:"updateComment"`
+	}
+	err := c.mutate(ctx, mutationUpdateCommentDocument, map[string]any{"id": id, "content": content}, &resp)
+	return resp.Result, err
+}
+
+const mutationDeleteCommentDocument = "mutation DeleteComment($id: ID!) { deleteComment(id: $id) }"
+
+// DeleteComment выполняет мутацию deleteComment.
+//
+// Удаляет комментарий вместе с ответами. Требует API-ключа.
+func (c *Client) DeleteComment(ctx context.Context, id string) (bool, error) {
+	var resp struct {
+		Result bool `json:"deleteComment"`
+	}
+	err := c.mutate(ctx, mutationDeleteCommentDocument, map[string]any{"id": id}, &resp)
+	return resp.Result, err
+}
+
+const mutationSetCommentsAllowedDocument = "mutation SetCommentsAllowed($postId: ID!, $allowed: Boolean!) { setCommentsAllowed(postId: $postId, allowed: $allowed) { id title content author commentsAllowed createdAt status publishAt comments { id postId parentId author content createdAt cursor } } }"
+
+// SetCommentsAllowed выполняет мутацию setCommentsAllowed.
+//
+// Включает или отключает комментарии к посту. Требует API-ключа.
+func (c *Client) SetCommentsAllowed(ctx context.Context, postID string, allowed bool) (*model.Post, error) {
+	var resp struct {
+		Result *model.Post `json:"setCommentsAllowed"`
+	}
+	err := c.mutate(ctx, mutationSetCommentsAllowedDocument, map[string]any{"postId": postID, "allowed": allowed}, &resp)
+	return resp.Result, err
+}
+
+const mutationSavePostDraftDocument = "mutation SavePostDraft($id: ID, $title: String!, $content: String!, $commentsAllowed: Boolean!) { savePostDraft(id: $id, title: $title, content: $content, commentsAllowed: $commentsAllowed) { id title content author commentsAllowed createdAt status publishAt comments { id postId parentId author content createdAt cursor } } }"
+
+// SavePostDraft выполняет мутацию savePostDraft.
+//
+// Создает черновик (без id) или сохраняет черновик автора. Запланированный пост после правки снова становится черновиком. Требует ключа автора.
+func (c *Client) SavePostDraft(ctx context.Context, id *string, title string, content string, commentsAllowed bool) (*model.Post, error) {
+	var resp struct {
+		Result *model.Post `json:"savePostDraft"`
+	}
+	err := c.mutate(ctx, mutationSavePostDraftDocument, map[string]any{"id": id, "title": title, "content": content, "commentsAllowed": commentsAllowed}, &resp)
+	return resp.Result, err
+}
+
+const mutationPublishPostDocument = "mutation PublishPost($id: ID!) { publishPost(id: $id) { id title content author commentsAllowed createdAt status publishAt comments { id postId parentId author content createdAt cursor } } }"
+
+// PublishPost выполняет мутацию publishPost.
+//
+// Публикует черновик или запланированный пост автора сразу. Требует ключа автора.
+func (c *Client) PublishPost(ctx context.Context, id string) (*model.Post, error) {
+	var resp struct {
+		Result *model.Post `json:"publishPost"`
+	}
+	err := c.mutate(ctx, mutationPublishPostDocument, map[string]any{"id": id}, &resp)
+	return resp.Result, err
+}
+
+const mutationSchedulePostDocument = "mutation SchedulePost($id: ID!, $publishAt: Time!) { schedulePost(id: $id, publishAt: $publishAt) { id title content author commentsAllowed createdAt status publishAt comments { id postId parentId author content createdAt cursor } } }"
+
+// SchedulePost выполняет мутацию schedulePost.
+//
+// Планирует публикацию черновика автора на publishAt; время должно быть в будущем. Требует ключа автора.
+func (c *Client) SchedulePost(ctx context.Context, id string, publishAt time.Time) (*model.Post, error) {
+	var resp struct {
+		Result *model.Post `json:"schedulePost"`
+	}
+	err := c.mutate(ctx, mutationSchedulePostDocument, map[string]any{"id": id, "publishAt": publishAt}, &resp)
+	return resp.Result, err
+}
+
+const mutationArchivePostDocument = "mutation ArchivePost($id: ID!) { archivePost(id: $id) { id title content author commentsAllowed createdAt status publishAt comments { id postId parentId author content createdAt cursor } } }"
+
+// ArchivePost выполняет мутацию archivePost.
+//
+// Переносит опубликованный пост автора в архив: он пропадает из posts, комментарии закрываются. Требует ключа автора.
+func (c *Client) ArchivePost(ctx context.Context, id string) (*model.Post, error) {
+	var resp struct {
+		Result *model.Post `json:"archivePost"`
+	}
+	err := c.mutate(ctx, mutationArchivePostDocument, map[string]any{"id": id}, &resp)
+	return resp.Result, err
+}
+
+const mutationDeletePostDocument = "mutation DeletePost($id: ID!) { deletePost(id: $id) }"
+
+// DeletePost выполняет мутацию deletePost.
+//
+// Удаляет пост вместе с комментариями. Требует API-ключа.
+func (c *Client) DeletePost(ctx context.Context, id string) (bool, error) {
+	var resp struct {
+		Result bool `json:"deletePost"`
+	}
+	err := c.mutate(ctx, mutationDeletePostDocument, map[string]any{"id": id}, &resp)
+	return resp.Result, err
+}
+
+const mutationBanUserDocument = "mutation BanUser($author: String!, $reason: String) { banUser(author: $author, reason: $reason) { author reason bannedAt } }"
+
+// BanUser выполняет мутацию banUser.
+//
+// Блокирует автора. Требует API-ключа.
+func (c *Client) BanUser(ctx context.Context, author string, reason *string) (*model.BannedUser, error) {
+	var resp struct {
+		Result *model.BannedUser `json:"banUser"`
+	}
+	err := c.mutate(ctx, mutationBanUserDocument, map[string]any{"author": author, "reason": reason}, &resp)
+	return resp.Result, err
+}
+
+const mutationUnbanUserDocument = "mutation UnbanUser($author: String!) { unbanUser(author: $author) }"
+
+// UnbanUser выполняет мутацию unbanUser.
+//
+// Снимает блокировку; false — автор не был заблокирован. Требует API-ключа.
+func (c *Client) UnbanUser(ctx context.Context, author string) (bool, error) {
+	var resp struct {
+		Result bool `json:"unbanUser"`
+	}
+	err := c.mutate(ctx, mutationUnbanUserDocument, map[string]any{"author": author}, &resp)
+	return resp.Result, err
+}
+
+const subscriptionCommentAddedDocument = "subscription CommentAdded($postId: ID!, $since: Cursor) { commentAdded(postId: $postId, since: $since) { id postId parentId author content createdAt cursor } }"
+
+// CommentAdded подписывается на commentAdded по websocket.
+func (c *Client) CommentAdded(ctx context.Context, postID string, since *model.Cursor) (*Subscription[*model.Comment], error) {
+	return subscribe(ctx, c, subscriptionCommentAddedDocument, map[string]any{"postId": postID, "since": since}, func(data json.RawMessage) (*model.Comment, error) {
+		var resp struct {
+			Result *model.Comment `json:"commentAdded"`
+		}
+		err := json.Unmarshal(data, &resp)
+		return resp.Result, err
+	})
+}
+
+const subscriptionPostEventsDocument = "subscription PostEvents($postId: ID!, $since: Cursor) { postEvents(postId: $postId, since: $since) { __typename ... on CommentAddedEvent { cursor comment { id postId parentId author content createdAt cursor } } ... on CommentUpdatedEvent { cursor comment { id postId parentId author content createdAt cursor } } ... on CommentDeletedEvent { cursor postId commentId } ... on PostCommentsToggledEvent { cursor postId commentsAllowed } } }"
+
+// PostEvents подписывается на postEvents по websocket.
+func (c *Client) PostEvents(ctx context.Context, postID string, since *model.Cursor) (*Subscription[model.PostEvent], error) {
+	return subscribe(ctx, c, subscriptionPostEventsDocument, map[string]any{"postId": postID, "since": since}, func(data json.RawMessage) (model.PostEvent, error) {
+		var resp struct {
+			Result json.RawMessage `json:"postEvents"`
+		}
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return nil, err
+		}
+		return decodePostEvent(resp.Result)
+	})
+}
+
+const subscriptionPostCreatedDocument = "subscription PostCreated($author: String) { postCreated(author: $author) { id title content author commentsAllowed createdAt status publishAt comments { id postId parentId author content createdAt cursor } } }"
+
+// PostCreated подписывается на postCreated по websocket.
+func (c *Client) PostCreated(ctx context.Context, author *string) (*Subscription[*model.Post], error) {
+	return subscribe(ctx, c, subscriptionPostCreatedDocument, map[string]any{"author": author}, func(data json.RawMessage) (*model.Post, error) {
+		var resp struct {
+			Result *model.Post `json:"postCreated"`
+		}
+		err := json.Unmarshal(data, &resp)
+		return resp.Result, err
+	})
+}
+
+// decodePostEvent выбирает вариант PostEvent по __typename.
+func decodePostEvent(data json.RawMessage) (model.PostEvent, error) {
+	if isNull(data) {
+		return nil, nil
+	}
+	var probe struct {
+		Typename string `json:"__typename"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, err
+	}
+	switch probe.Typename {
+	case "CommentAddedEvent":
+		v := &model.CommentAddedEvent{}
+		err := json.Unmarshal(data, v)
+		return v, err
+	case "CommentUpdatedEvent":
+		v := &model.CommentUpdatedEvent{}
+		err := json.Unmarshal(data, v)
+		return v, err
+	case "CommentDeletedEvent":
+		v := &model.CommentDeletedEvent{}
+		err := json.Unmarshal(data, v)
+		return v, err
+	case "PostCommentsToggledEvent":
+		v := &model.PostCommentsToggledEvent{}
+		err := json.Unmarshal(data, v)
+		return v, err
+	}
+	return nil, fmt.Errorf("неизвестный вариант PostEvent: %q", probe.Typename)
+}
@@ -0,0 +1,232 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Подпротокол websocket и типы его сообщений.
+const (
+	wsProtocol = "graphql-transport-ws"
+
+	msgConnectionInit = "connection_init"
+	msgConnectionAck  = "connection_ack"
+	msgSubscribe      = "subscribe"
+	msgNext           = "next"
+	msgError          = "error"
+	msgComplete       = "complete"
+	msgPing           = "ping"
+	msgPong           = "pong"
+)
+
+// ackTimeout — сколько ждать connection_ack после подключения.
+const ackTimeout = 10 * time.Second
+
+// ErrSubscriptionClosed — подписка закрыта через Close или отменой ctx.
+var ErrSubscriptionClosed = errors.New("подписка закрыта")
+
+// Subscription — активная подписка. События приходят в Events, канал
+// закрывается, когда сервер завершает подписку, при ошибке или после Close;
+// причину возвращает Err.
+type Subscription[T any] struct {
+	events chan T
+	conn   *websocket.Conn
+	cancel context.CancelFunc
+
+	writeMu sync.Mutex
+	done    chan struct{}
+	err     error
+}
+
+// Events возвращает канал событий.
+func (s *Subscription[T]) Events() <-chan T { return s.events }
+
+// Err возвращает причину завершения после закрытия Events: nil — сервер
+// завершил подписку, ErrSubscriptionClosed — ее закрыл клиент.
+func (s *Subscription[T]) Err() error {
+	<-s.done
+	return s.err
+}
+
+// Close отписывается и закрывает соединение.
+func (s *Subscription[T]) Close() error {
+	s.cancel()
+	<-s.done
+	return nil
+}
+
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// subscribe подключается по websocket и запускает подписку; decode
+// разбирает data каждого события.
+func subscribe[T any](ctx context.Context, c *Client, document string, vars map[string]any, decode func(json.RawMessage) (T, error)) (*Subscription[T], error) {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(map[string]any{"query": document, "variables": vars})
+	if err == nil {
+		err = conn.WriteJSON(wsMessage{ID: "1", Type: msgSubscribe, Payload: payload})
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s := &Subscription[T]{
+		events: make(chan T, 16),
+		conn:   conn,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go s.read(ctx, decode)
+	go func() {
+		<-ctx.Done()
+		// Сервер завершает подписку по complete; закрытие соединения
+		// прерывает чтение, если сервер не ответит
+		s.write(wsMessage{ID: "1", Type: msgComplete})
+		conn.Close()
+	}()
+	return s, nil
+}
+
+// dial открывает websocket-соединение и ждет connection_ack. Заголовки
+// клиента (API-ключ) передаются при подключении и в connection_init.
+func (c *Client) dial(ctx context.Context) (*websocket.Conn, error) {
+	endpoint := c.endpoint
+	switch {
+	case strings.HasPrefix(endpoint, "https://"):
+		endpoint = "wss://" + strings.TrimPrefix(endpoint, "https://")
+	case strings.HasPrefix(endpoint, "http://"):
+		endpoint = "ws://" + strings.TrimPrefix(endpoint, "http://")
+	}
+
+	dialer := websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: ackTimeout,
+		Subprotocols:     []string{wsProtocol},
+	}
+	conn, resp, err := dialer.DialContext(ctx, endpoint, c.header.Clone())
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("подключение websocket: %w (%s)", err, resp.Status)
+		}
+		return nil, fmt.Errorf("подключение websocket: %w", err)
+	}
+
+	initPayload := make(map[string]string, len(c.header))
+	for key := range c.header {
+		initPayload[key] = c.header.Get(key)
+	}
+	payload, _ := json.Marshal(initPayload)
+	if err := conn.WriteJSON(wsMessage{Type: msgConnectionInit, Payload: payload}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(ackTimeout))
+	for {
+		var msg wsMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("ожидание connection_ack: %w", err)
+		}
+		switch msg.Type {
+		case msgConnectionAck:
+			_ = conn.SetReadDeadline(time.Time{})
+			return conn, nil
+		case msgPing:
+			if err := conn.WriteJSON(wsMessage{Type: msgPong}); err != nil {
+				conn.Close()
+				return nil, err
+			}
+		default:
+			conn.Close()
+			return nil, fmt.Errorf("ожидался connection_ack, получено %q", msg.Type)
+		}
+	}
+}
+
+// read принимает сообщения сервера до завершения подписки.
+func (s *Subscription[T]) read(ctx context.Context, decode func(json.RawMessage) (T, error)) {
+	defer close(s.done)
+	defer close(s.events)
+	defer s.cancel()
+
+	for {
+		var msg wsMessage
+		if err := s.conn.ReadJSON(&msg); err != nil {
+			if ctx.Err() != nil {
+				s.err = ErrSubscriptionClosed
+			} else {
+				s.err = fmt.Errorf("чтение websocket: %w", err)
+			}
+			return
+		}
+
+		switch msg.Type {
+		case msgNext:
+			var result struct {
+				Data   json.RawMessage `json:"data"`
+				Errors Errors          `json:"errors"`
+			}
+			if err := json.Unmarshal(msg.Payload, &result); err != nil {
+				s.err = err
+				return
+			}
+			if len(result.Errors) > 0 {
+				s.err = result.Errors
+				return
+			}
+			event, err := decode(result.Data)
+			if err != nil {
+				s.err = err
+				return
+			}
+			select {
+			case s.events <- event:
+			case <-ctx.Done():
+				s.err = ErrSubscriptionClosed
+				return
+			}
+
+		case msgError:
+			var errs Errors
+			if err := json.Unmarshal(msg.Payload, &errs); err != nil || len(errs) == 0 {
+				s.err = fmt.Errorf("ошибка подписки: %s", msg.Payload)
+			} else {
+				s.err = errs
+			}
+			return
+
+		case msgComplete:
+			return
+
+		case msgPing:
+			s.write(wsMessage{Type: msgPong})
+		}
+	}
+}
+
+// write отправляет сообщение; gorilla/websocket не допускает
+// одновременной записи из нескольких горутин.
+func (s *Subscription[T]) write(msg wsMessage) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_ = s.conn.SetWriteDeadline(time.Now().Add(time.Second))
+	_ = s.conn.WriteJSON(msg)
+}
@@ -0,0 +1,37 @@
+// Команда clientgen генерирует client/generated.go по graph/schema.graphql.
+// Запускается через go generate в пакете client:
+//
+//	go generate ./client
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"ozon_test/client/codegen"
+)
+
+func main() {
+	schema := flag.String("schema", "graph/schema.graphql", "файл схемы GraphQL")
+	output := flag.String("o", "client/generated.go", "файл для сгенерированного кода")
+	flag.Parse()
+
+	src, err := os.ReadFile(*schema)
+	if err != nil {
+		fail(err)
+	}
+	code, err := codegen.Generate(filepath.Base(*schema), string(src))
+	if err != nil {
+		fail(err)
+	}
+	if err := os.WriteFile(*output, code, 0o644); err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "clientgen:", err)
+	os.Exit(1)
+}
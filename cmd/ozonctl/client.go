@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// client выполняет GraphQL-запросы к серверу по HTTP.
+type client struct {
+	addr   string
+	apiKey string
+	http   *http.Client
+}
+
+func newClient(addr, apiKey string, timeout time.Duration) *client {
+	return &client{addr: addr, apiKey: apiKey, http: &http.Client{Timeout: timeout}}
+}
+
+// apiError — ошибка из поля errors ответа GraphQL.
+type apiError struct {
+	Message    string `json:"message"`
+	Extensions struct {
+		Code      string `json:"code"`
+		RequestID string `json:"requestId"`
+	} `json:"extensions"`
+}
+
+func (e apiError) Error() string {
+	if e.Extensions.Code != "" {
+		return fmt.Sprintf("%s (%s)", e.Message, e.Extensions.Code)
+	}
+	return e.Message
+}
+
+// do выполняет запрос и декодирует data в out. Ошибки GraphQL
+// возвращаются первой из них; остальные дописываются к сообщению.
+func (c *client) do(ctx context.Context, query string, vars map[string]any, out any) error {
+	body, err := json.Marshal(map[string]any{"query": query, "variables": vars})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.addr, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("запрос к %s: %w", c.addr, err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 64<<20))
+	if err != nil {
+		return fmt.Errorf("чтение ответа: %w", err)
+	}
+
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []apiError      `json:"errors"`
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		// Ошибка не из GraphQL: неверный ключ, прокси, не тот адрес
+		return fmt.Errorf("сервер ответил %s: %s", resp.Status, strings.TrimSpace(string(raw)))
+	}
+	if len(result.Errors) > 0 {
+		err := result.Errors[0]
+		if len(result.Errors) > 1 {
+			return fmt.Errorf("%w (и еще ошибок: %d)", err, len(result.Errors)-1)
+		}
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(result.Data, out)
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"ozon_test/graph/model"
+)
+
+const postFields = `id title content author commentsAllowed createdAt`
+
+const commentFields = `id postId parentId author content createdAt`
+
+func postsList(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("posts list", flag.ContinueOnError)
+	author := fs.String("author", "", "только посты автора")
+	locked := fs.Bool("locked", false, "только посты, закрытые для комментариев")
+	if _, err := a.parse(fs, args, 0, ""); err != nil {
+		return err
+	}
+
+	var data struct{ Posts []*model.Post }
+	if err := a.client.do(ctx, `query { posts { `+postFields+` } }`, nil, &data); err != nil {
+		return err
+	}
+	posts := data.Posts[:0]
+	for _, p := range data.Posts {
+		if (*author == "" || p.Author == *author) && (!*locked || !p.CommentsAllowed) {
+			posts = append(posts, p)
+		}
+	}
+
+	if a.json() {
+		return printJSON(a.stdout, posts)
+	}
+	rows := make([][]string, 0, len(posts))
+	for _, p := range posts {
+		rows = append(rows, []string{p.ID, cell(p.Title), p.Author, yesNo(p.CommentsAllowed), localTime(p.CreatedAt)})
+	}
+	return printTable(a.stdout, []string{"ID", "TITLE", "AUTHOR", "COMMENTS", "CREATED"}, rows)
+}
+
+func postsShow(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("posts show", flag.ContinueOnError)
+	rest, err := a.parse(fs, args, 1, "ID")
+	if err != nil {
+		return err
+	}
+
+	var data struct{ Post *model.Post }
+	err = a.client.do(ctx, `query($id: ID!) { post(id: $id) { `+postFields+` comments { `+commentFields+` } } }`,
+		map[string]any{"id": rest[0]}, &data)
+	if err != nil {
+		return err
+	}
+
+	if a.json() {
+		return printJSON(a.stdout, data.Post)
+	}
+	p := data.Post
+	fmt.Fprintf(a.stdout, "ID:        %s\n", p.ID)
+	fmt.Fprintf(a.stdout, "Заголовок: %s\n", p.Title)
+	fmt.Fprintf(a.stdout, "Автор:     %s\n", p.Author)
+	fmt.Fprintf(a.stdout, "Создан:    %s\n", localTime(p.CreatedAt))
+	fmt.Fprintf(a.stdout, "Комментарии разрешены: %s\n\n", yesNo(p.CommentsAllowed))
+	fmt.Fprintln(a.stdout, p.Content)
+	if len(p.Comments) == 0 {
+		return nil
+	}
+	fmt.Fprintf(a.stdout, "\nПоследние комментарии (%d):\n", len(p.Comments))
+	return printComments(a, p.Comments)
+}
+
+func postsLock(ctx context.Context, a *app, args []string) error {
+	return setCommentsAllowed(ctx, a, "posts lock", args, false)
+}
+
+func postsUnlock(ctx context.Context, a *app, args []string) error {
+	return setCommentsAllowed(ctx, a, "posts unlock", args, true)
+}
+
+func setCommentsAllowed(ctx context.Context, a *app, name string, args []string, allowed bool) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	rest, err := a.parse(fs, args, 1, "ID")
+	if err != nil {
+		return err
+	}
+
+	var data struct{ SetCommentsAllowed *model.Post }
+	err = a.client.do(ctx, `mutation($id: ID!, $allowed: Boolean!) { setCommentsAllowed(postId: $id, allowed: $allowed) { `+postFields+` } }`,
+		map[string]any{"id": rest[0], "allowed": allowed}, &data)
+	if err != nil {
+		return err
+	}
+
+	if a.json() {
+		return printJSON(a.stdout, data.SetCommentsAllowed)
+	}
+	if allowed {
+		fmt.Fprintf(a.stdout, "Пост %s открыт для комментариев\n", rest[0])
+	} else {
+		fmt.Fprintf(a.stdout, "Пост %s закрыт для комментариев\n", rest[0])
+	}
+	return nil
+}
+
+func postsDelete(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("posts delete", flag.ContinueOnError)
+	yes := fs.Bool("y", false, "не спрашивать подтверждение")
+	rest, err := a.parse(fs, args, 1, "ID")
+	if err != nil {
+		return err
+	}
+	if err := a.confirm(*yes, fmt.Sprintf("Удалить пост %s вместе с комментариями?", rest[0])); err != nil {
+		return err
+	}
+
+	var data struct{ DeletePost bool }
+	if err := a.client.do(ctx, `mutation($id: ID!) { deletePost(id: $id) }`, map[string]any{"id": rest[0]}, &data); err != nil {
+		return err
+	}
+	if a.json() {
+		return printJSON(a.stdout, map[string]any{"deleted": data.DeletePost})
+	}
+	fmt.Fprintf(a.stdout, "Пост %s удален\n", rest[0])
+	return nil
+}
+
+func commentsList(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("comments list", flag.ContinueOnError)
+	postID := fs.String("post", "", "ID поста (обязательно)")
+	tree := fs.Bool("tree", false, "показать ветки ответов; по умолчанию все комментарии")
+	limit := fs.Int("limit", 50, "сколько комментариев, 0 — все")
+	offset := fs.Int("offset", 0, "сколько новых комментариев пропустить")
+	if _, err := a.parse(fs, args, 0, ""); err != nil {
+		return err
+	}
+	if *postID == "" {
+		fs.Usage()
+		return errUsage
+	}
+	// Ветки строятся по всем комментариям, иначе часть ответов потеряет родителей
+	if *tree && !isSet(fs, "limit") {
+		*limit = 0
+	}
+
+	var data struct{ Comments []*model.Comment }
+	err := a.client.do(ctx, `query($post: ID!, $limit: Int!, $offset: Int!) { comments(postID: $post, limit: $limit, offset: $offset) { `+commentFields+` } }`,
+		map[string]any{"post": *postID, "limit": *limit, "offset": *offset}, &data)
+	if err != nil {
+		return err
+	}
+
+	if *tree {
+		roots := buildTree(data.Comments)
+		if a.json() {
+			return printJSON(a.stdout, roots)
+		}
+		printTree(a.stdout, roots)
+		return nil
+	}
+	if a.json() {
+		return printJSON(a.stdout, data.Comments)
+	}
+	return printComments(a, data.Comments)
+}
+
+func commentsDelete(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("comments delete", flag.ContinueOnError)
+	yes := fs.Bool("y", false, "не спрашивать подтверждение")
+	rest, err := a.parse(fs, args, 1, "ID")
+	if err != nil {
+		return err
+	}
+	if err := a.confirm(*yes, fmt.Sprintf("Удалить комментарий %s вместе с ответами?", rest[0])); err != nil {
+		return err
+	}
+
+	var data struct{ DeleteComment bool }
+	if err := a.client.do(ctx, `mutation($id: ID!) { deleteComment(id: $id) }`, map[string]any{"id": rest[0]}, &data); err != nil {
+		return err
+	}
+	if a.json() {
+		return printJSON(a.stdout, map[string]any{"deleted": data.DeleteComment})
+	}
+	fmt.Fprintf(a.stdout, "Комментарий %s удален\n", rest[0])
+	return nil
+}
+
+func usersList(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("users list", flag.ContinueOnError)
+	if _, err := a.parse(fs, args, 0, ""); err != nil {
+		return err
+	}
+
+	var data struct{ BannedUsers []*model.BannedUser }
+	if err := a.client.do(ctx, `query { bannedUsers { author reason bannedAt } }`, nil, &data); err != nil {
+		return err
+	}
+	if a.json() {
+		return printJSON(a.stdout, data.BannedUsers)
+	}
+	rows := make([][]string, 0, len(data.BannedUsers))
+	for _, u := range data.BannedUsers {
+		rows = append(rows, []string{u.Author, cell(u.Reason), localTime(u.BannedAt)})
+	}
+	return printTable(a.stdout, []string{"AUTHOR", "REASON", "BANNED"}, rows)
+}
+
+func usersBan(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("users ban", flag.ContinueOnError)
+	reason := fs.String("reason", "", "причина блокировки")
+	rest, err := a.parse(fs, args, 1, "АВТОР")
+	if err != nil {
+		return err
+	}
+
+	vars := map[string]any{"author": rest[0]}
+	if *reason != "" {
+		vars["reason"] = *reason
+	}
+	var data struct{ BanUser *model.BannedUser }
+	if err := a.client.do(ctx, `mutation($author: String!, $reason: String) { banUser(author: $author, reason: $reason) { author reason bannedAt } }`, vars, &data); err != nil {
+		return err
+	}
+	if a.json() {
+		return printJSON(a.stdout, data.BanUser)
+	}
+	fmt.Fprintf(a.stdout, "Автор %s заблокирован\n", data.BanUser.Author)
+	return nil
+}
+
+func usersUnban(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("users unban", flag.ContinueOnError)
+	rest, err := a.parse(fs, args, 1, "АВТОР")
+	if err != nil {
+		return err
+	}
+
+	var data struct{ UnbanUser bool }
+	if err := a.client.do(ctx, `mutation($author: String!) { unbanUser(author: $author) }`, map[string]any{"author": rest[0]}, &data); err != nil {
+		return err
+	}
+	if a.json() {
+		return printJSON(a.stdout, map[string]any{"unbanned": data.UnbanUser})
+	}
+	if data.UnbanUser {
+		fmt.Fprintf(a.stdout, "Блокировка автора %s снята\n", rest[0])
+	} else {
+		fmt.Fprintf(a.stdout, "Автор %s не был заблокирован\n", rest[0])
+	}
+	return nil
+}
+
+func stats(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	if _, err := a.parse(fs, args, 0, ""); err != nil {
+		return err
+	}
+
+	var data struct{ Stats *model.Stats }
+	if err := a.client.do(ctx, `query { stats { posts lockedPosts comments bannedUsers subscriptions } }`, nil, &data); err != nil {
+		return err
+	}
+	if a.json() {
+		return printJSON(a.stdout, data.Stats)
+	}
+	s := data.Stats
+	return printTable(a.stdout, []string{"METRIC", "VALUE"}, [][]string{
+		{"posts", strconv.Itoa(s.Posts)},
+		{"locked posts", strconv.Itoa(s.LockedPosts)},
+		{"comments", strconv.Itoa(s.Comments)},
+		{"banned users", strconv.Itoa(s.BannedUsers)},
+		{"subscriptions", strconv.Itoa(s.Subscriptions)},
+	})
+}
+
+// printComments выводит комментарии таблицей.
+func printComments(a *app, comments []*model.Comment) error {
+	rows := make([][]string, 0, len(comments))
+	for _, c := range comments {
+		parent := "-"
+		if c.ParentID != nil {
+			parent = *c.ParentID
+		}
+		rows = append(rows, []string{c.ID, parent, c.Author, cell(c.Content), localTime(c.CreatedAt)})
+	}
+	return printTable(a.stdout, []string{"ID", "PARENT", "AUTHOR", "CONTENT", "CREATED"}, rows)
+}
+
+// isSet сообщает, указан ли флаг явно.
+func isSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) { set = set || f.Name == name })
+	return set
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// completion выводит скрипт автодополнения групп и команд.
+//
+//	source <(ozonctl completion bash)
+//	ozonctl completion zsh > "${fpath[1]}/_ozonctl"
+//	ozonctl completion fish > ~/.config/fish/completions/ozonctl.fish
+func completion(w io.Writer, args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "использование: ozonctl completion bash|zsh|fish")
+		return 2
+	}
+
+	groups, subcommands := completionTree()
+	switch args[0] {
+	case "bash":
+		writeBash(w, groups, subcommands)
+	case "zsh":
+		// zsh подключает bash-скрипт через bashcompinit
+		fmt.Fprintln(w, "#compdef ozonctl")
+		fmt.Fprintln(w, "autoload -U +X bashcompinit && bashcompinit")
+		writeBash(w, groups, subcommands)
+	case "fish":
+		writeFish(w, groups, subcommands)
+	default:
+		fmt.Fprintf(os.Stderr, "неизвестная оболочка %q: bash, zsh или fish\n", args[0])
+		return 2
+	}
+	return 0
+}
+
+// completionTree возвращает группы в порядке commands и команды каждой группы.
+func completionTree() ([]string, map[string][]string) {
+	var groups []string
+	subcommands := make(map[string][]string)
+	for _, c := range commands {
+		if _, seen := subcommands[c.group]; !seen {
+			groups = append(groups, c.group)
+			subcommands[c.group] = nil
+		}
+		if c.name != "" {
+			subcommands[c.group] = append(subcommands[c.group], c.name)
+		}
+	}
+	subcommands["completion"] = []string{"bash", "zsh", "fish"}
+	return groups, subcommands
+}
+
+func writeBash(w io.Writer, groups []string, subcommands map[string][]string) {
+	fmt.Fprintln(w, `_ozonctl() {
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    if [[ "$cur" == -* ]]; then
+        COMPREPLY=($(compgen -W "-addr -api-key -o -timeout" -- "$cur"))
+        return
+    fi
+    if [[ ${COMP_WORDS[COMP_CWORD-1]} == "-o" ]]; then
+        COMPREPLY=($(compgen -W "table json" -- "$cur"))
+        return
+    fi
+    if [[ $COMP_CWORD -eq 1 ]]; then`)
+	fmt.Fprintf(w, "        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(groups, " "))
+	fmt.Fprintln(w, `        return
+    fi
+    if [[ $COMP_CWORD -eq 2 ]]; then
+        case "${COMP_WORDS[1]}" in`)
+	for _, g := range groups {
+		if len(subcommands[g]) > 0 {
+			fmt.Fprintf(w, "            %s) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", g, strings.Join(subcommands[g], " "))
+		}
+	}
+	fmt.Fprintln(w, `        esac
+    fi
+}
+complete -F _ozonctl ozonctl`)
+}
+
+func writeFish(w io.Writer, groups []string, subcommands map[string][]string) {
+	fmt.Fprintln(w, "complete -c ozonctl -f")
+	fmt.Fprintln(w, "complete -c ozonctl -o addr -r -d 'адрес GraphQL API'")
+	fmt.Fprintln(w, "complete -c ozonctl -o api-key -r -d 'API-ключ администратора'")
+	fmt.Fprintln(w, "complete -c ozonctl -o o -x -a 'table json' -d 'формат вывода'")
+	fmt.Fprintln(w, "complete -c ozonctl -o timeout -x -d 'таймаут запроса'")
+	fmt.Fprintf(w, "complete -c ozonctl -n '__fish_use_subcommand' -a '%s'\n", strings.Join(groups, " "))
+	for _, g := range groups {
+		if len(subcommands[g]) > 0 {
+			fmt.Fprintf(w, "complete -c ozonctl -n '__fish_seen_subcommand_from %s; and not __fish_seen_subcommand_from %s' -a '%s'\n",
+				g, strings.Join(subcommands[g], " "), strings.Join(subcommands[g], " "))
+		}
+	}
+}
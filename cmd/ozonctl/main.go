@@ -0,0 +1,220 @@
+// Команда ozonctl — консольный клиент администратора: просмотр и правка
+// постов и комментариев, блокировка авторов и статистика через GraphQL API.
+//
+//	ozonctl [флаги] <группа> <команда> [флаги] [аргументы]
+//
+// Адрес API и ключ берутся из флагов -addr и -api-key или переменных
+// OZONCTL_ADDR и OZONCTL_API_KEY; формат вывода — -o table|json.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const defaultAddr = "http://localhost:8080/query"
+
+// errUsage — неверные аргументы; подсказка уже выведена.
+var errUsage = errors.New("неверные аргументы")
+
+// options — общие флаги всех команд.
+type options struct {
+	addr    string
+	apiKey  string
+	output  string
+	timeout time.Duration
+}
+
+// register добавляет общие флаги в fs; текущие значения становятся
+// значениями по умолчанию, поэтому флаги можно указать и до, и после команды.
+func (o *options) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.addr, "addr", o.addr, "адрес GraphQL API (OZONCTL_ADDR)")
+	fs.StringVar(&o.apiKey, "api-key", o.apiKey, "API-ключ администратора (OZONCTL_API_KEY)")
+	fs.StringVar(&o.output, "o", o.output, "формат вывода: table или json (OZONCTL_OUTPUT)")
+	fs.DurationVar(&o.timeout, "timeout", o.timeout, "таймаут запроса")
+}
+
+// app — состояние запуска команды.
+type app struct {
+	opts   options
+	client *client
+	stdin  io.Reader
+	stdout io.Writer
+}
+
+// command — подкоманда вида «группа команда».
+type command struct {
+	group, name string
+	args        string
+	summary     string
+	run         func(ctx context.Context, a *app, args []string) error
+}
+
+// commands — все подкоманды; по ним же строятся справка и автодополнение.
+var commands = []command{
+	{"posts", "list", "[-author АВТОР] [-locked]", "список постов", postsList},
+	{"posts", "show", "ID", "пост и последние комментарии", postsShow},
+	{"posts", "lock", "ID", "закрыть пост для комментариев", postsLock},
+	{"posts", "unlock", "ID", "открыть пост для комментариев", postsUnlock},
+	{"posts", "delete", "[-y] ID", "удалить пост вместе с комментариями", postsDelete},
+	{"comments", "list", "-post ID [-tree] [-limit N] [-offset N]", "комментарии поста", commentsList},
+	{"comments", "delete", "[-y] ID", "удалить комментарий вместе с ответами", commentsDelete},
+	{"users", "list", "", "заблокированные авторы", usersList},
+	{"users", "ban", "[-reason ПРИЧИНА] АВТОР", "заблокировать автора", usersBan},
+	{"users", "unban", "АВТОР", "снять блокировку", usersUnban},
+	{"stats", "", "", "сводка по постам, комментариям и подпискам", stats},
+	{"completion", "", "bash|zsh|fish", "скрипт автодополнения для оболочки", nil},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	opts := options{
+		addr:    envOr("OZONCTL_ADDR", defaultAddr),
+		apiKey:  os.Getenv("OZONCTL_API_KEY"),
+		output:  envOr("OZONCTL_OUTPUT", outputTable),
+		timeout: 30 * time.Second,
+	}
+
+	fs := flag.NewFlagSet("ozonctl", flag.ContinueOnError)
+	opts.register(fs)
+	fs.Usage = func() { usage(fs.Output()) }
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	cmd, rest, ok := findCommand(fs.Args())
+	if !ok {
+		usage(os.Stderr)
+		return 2
+	}
+	if cmd.group == "completion" {
+		return completion(os.Stdout, rest)
+	}
+
+	a := &app{opts: opts, stdin: os.Stdin, stdout: os.Stdout}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	err := cmd.run(ctx, a, rest)
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errUsage):
+		return 2
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	default:
+		fmt.Fprintln(os.Stderr, "ozonctl:", err)
+		return 1
+	}
+}
+
+// findCommand ищет подкоманду по первым аргументам.
+func findCommand(args []string) (command, []string, bool) {
+	if len(args) == 0 {
+		return command{}, nil, false
+	}
+	for _, c := range commands {
+		if c.group != args[0] {
+			continue
+		}
+		if c.name == "" {
+			return c, args[1:], true
+		}
+		if len(args) > 1 && c.name == args[1] {
+			return c, args[2:], true
+		}
+	}
+	return command{}, nil, false
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "использование: ozonctl [флаги] <группа> <команда> [флаги] [аргументы]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "команды:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-54s %s\n", strings.Join(strings.Fields(c.group+" "+c.name+" "+c.args), " "), c.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "флаги:")
+	fs := flag.NewFlagSet("ozonctl", flag.ContinueOnError)
+	fs.SetOutput(w)
+	(&options{addr: defaultAddr, output: outputTable, timeout: 30 * time.Second}).register(fs)
+	fs.PrintDefaults()
+}
+
+// parse разбирает флаги команды вместе с общими; флаги можно смешивать
+// с позиционными аргументами. Проверяет число позиционных аргументов
+// и подключает клиента API.
+func (a *app) parse(fs *flag.FlagSet, args []string, positional int, names string) ([]string, error) {
+	a.opts.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "использование: ozonctl %s [флаги] %s\n", fs.Name(), names)
+		fs.PrintDefaults()
+	}
+
+	var rest []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, errUsage
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		rest = append(rest, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if len(rest) != positional {
+		fs.Usage()
+		return nil, errUsage
+	}
+	if a.opts.output != outputTable && a.opts.output != outputJSON {
+		fmt.Fprintf(fs.Output(), "неизвестный формат вывода %q: table или json\n", a.opts.output)
+		return nil, errUsage
+	}
+
+	a.client = newClient(a.opts.addr, a.opts.apiKey, a.opts.timeout)
+	return rest, nil
+}
+
+// json сообщает, выбран ли вывод в JSON.
+func (a *app) json() bool { return a.opts.output == outputJSON }
+
+// confirm спрашивает подтверждение; yes пропускает вопрос.
+func (a *app) confirm(yes bool, question string) error {
+	if yes {
+		return nil
+	}
+	fmt.Fprintf(a.stdout, "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(a.stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes", "д", "да":
+		return nil
+	}
+	return errors.New("отменено")
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
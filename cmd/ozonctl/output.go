@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+
+	"ozon_test/graph/model"
+)
+
+// Форматы вывода.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// maxCell — сколько символов текста показывается в ячейке таблицы.
+const maxCell = 60
+
+// printJSON выводит v с отступами.
+func printJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// printTable выводит строки с выравниванием колонок.
+func printTable(w io.Writer, header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// cell приводит текст к одной строке и обрезает до maxCell символов.
+func cell(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) <= maxCell {
+		return s
+	}
+	return string([]rune(s)[:maxCell-1]) + "…"
+}
+
+// localTime показывает время в местном поясе с точностью до секунды.
+func localTime(t time.Time) string {
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// commentNode — комментарий с ответами для вывода дерева.
+type commentNode struct {
+	*model.Comment
+	Replies []*commentNode `json:"replies,omitempty"`
+}
+
+// buildTree собирает комментарии в дерево; ветки упорядочены от старых
+// к новым. Ответы, родитель которых не попал в выборку, становятся корнями.
+func buildTree(comments []*model.Comment) []*commentNode {
+	nodes := make(map[string]*commentNode, len(comments))
+	for _, c := range comments {
+		nodes[c.ID] = &commentNode{Comment: c}
+	}
+
+	var roots []*commentNode
+	for _, c := range comments {
+		node := nodes[c.ID]
+		if c.ParentID != nil {
+			if parent, ok := nodes[*c.ParentID]; ok {
+				parent.Replies = append(parent.Replies, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	var sortNodes func([]*commentNode)
+	sortNodes = func(list []*commentNode) {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+		for _, n := range list {
+			sortNodes(n.Replies)
+		}
+	}
+	sortNodes(roots)
+	return roots
+}
+
+// printTree выводит дерево комментариев с псевдографикой.
+func printTree(w io.Writer, roots []*commentNode) {
+	var walk func(list []*commentNode, prefix string)
+	walk = func(list []*commentNode, prefix string) {
+		for i, n := range list {
+			branch, next := "├── ", "│   "
+			if i == len(list)-1 {
+				branch, next = "└── ", "    "
+			}
+			fmt.Fprintf(w, "%s%s%s: %s  [%s, %s]\n", prefix, branch, n.Author, cell(n.Content), n.ID, localTime(n.CreatedAt))
+			walk(n.Replies, prefix+next)
+		}
+	}
+	walk(roots, "")
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"ozon_test/config"
+	"ozon_test/logging"
+	"ozon_test/migrate"
+	"ozon_test/storage"
+	"ozon_test/transfer"
+)
+
+// loadCommandConfig загружает конфигурацию вместе с флагами команды
+// и настраивает логи; завершает процесс, если конфигурация некорректна.
+func loadCommandConfig(fs *flag.FlagSet, args []string) *config.Config {
+	cfg, err := config.LoadFlags(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if _, err := logging.Setup(cfg, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	return cfg
+}
+
+// logProgress пишет прогресс выгрузки или загрузки в лог.
+func logProgress(msg string) func(transfer.Progress) {
+	return func(p transfer.Progress) {
+		slog.Info(msg,
+			slog.Int("posts", p.Posts), slog.Int("comments", p.Comments),
+			slog.Int("created", p.Created), slog.Int("updated", p.Updated), slog.Int("unchanged", p.Unchanged))
+	}
+}
+
+// exportCommand обрабатывает `export`: выгружает посты и комментарии
+// хранилища из конфигурации в NDJSON.
+func exportCommand(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	output := fs.String("o", "-", "файл для выгрузки, - — stdout")
+	cfg := loadCommandConfig(fs, args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	s, err := storage.Open(ctx, cfg.Storage)
+	if err != nil {
+		slog.Error("Ошибка открытия хранилища", slog.Any("error", err))
+		return 1
+	}
+	defer s.Close()
+
+	w := os.Stdout
+	if *output != "-" {
+		if w, err = os.Create(*output); err != nil {
+			slog.Error("Ошибка создания файла выгрузки", slog.Any("error", err))
+			return 1
+		}
+	}
+
+	_, err = transfer.Export(ctx, s, w, logProgress("Выгрузка"))
+	if w != os.Stdout {
+		err = errors.Join(err, w.Close())
+	}
+	if err != nil {
+		slog.Error("Ошибка выгрузки", slog.Any("error", err))
+		return 1
+	}
+	return 0
+}
+
+// importCommand обрабатывает `import`: загружает NDJSON в хранилище
+// из конфигурации.
+func importCommand(args []string) int {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	input := fs.String("i", "-", "файл для загрузки, - — stdin")
+	dryRun := fs.Bool("dry-run", false, "только проверить ввод и ссылки, ничего не записывая")
+	batch := fs.Int("batch", transfer.DefaultBatchSize, "записей в одной транзакции")
+	cfg := loadCommandConfig(fs, args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	s, err := storage.Open(ctx, cfg.Storage)
+	if err != nil {
+		slog.Error("Ошибка открытия хранилища", slog.Any("error", err))
+		return 1
+	}
+	defer s.Close()
+
+	var r io.Reader = os.Stdin
+	if *input != "-" {
+		f, err := os.Open(*input)
+		if err != nil {
+			slog.Error("Ошибка открытия файла загрузки", slog.Any("error", err))
+			return 1
+		}
+		defer f.Close()
+		r = f
+	}
+
+	_, err = transfer.Import(ctx, s, r, transfer.Options{
+		BatchSize: *batch,
+		DryRun:    *dryRun,
+		Progress:  logProgress("Загрузка"),
+	})
+	if err != nil {
+		slog.Error("Ошибка загрузки", slog.Any("error", err))
+		return 1
+	}
+	return 0
+}
+
+// storageFromSpec строит настройки хранилища по строке вида
+// postgres://…, sqlite:ПУТЬ или memory:КАТАЛОГ; остальные параметры
+// (пул, таймауты) берутся из base.
+func storageFromSpec(base config.StorageConfig, spec string) (config.StorageConfig, error) {
+	cfg := base
+	kind, value, _ := strings.Cut(spec, ":")
+	switch {
+	case kind == "postgres" || kind == "postgresql":
+		cfg.Type, cfg.DSN, cfg.ReplicaDSNs = "postgres", spec, nil
+	case kind == "sqlite" && value != "":
+		cfg.Type, cfg.SQLitePath = "sqlite", value
+	case kind == "memory" && value != "":
+		cfg.Type, cfg.MemoryDataDir = "memory", value
+	default:
+		return cfg, fmt.Errorf("некорректное хранилище %q: ожидается postgres://…, sqlite:ПУТЬ или memory:КАТАЛОГ", spec)
+	}
+	return cfg, nil
+}
+
+// migrateDataCommand обрабатывает `migrate-data`: переносит данные между
+// хранилищами с догонянием по ленте изменений и проверкой.
+func migrateDataCommand(args []string) int {
+	fs := flag.NewFlagSet("migrate-data", flag.ContinueOnError)
+	fromSpec := fs.String("from", "", "источник: postgres://…, sqlite:ПУТЬ или memory:КАТАЛОГ")
+	toSpec := fs.String("to", "", "приемник в том же формате")
+	batch := fs.Int("batch", transfer.DefaultBatchSize, "записей в транзакции и изменений в выборке")
+	follow := fs.Bool("follow", false, "после копирования следить за лентой изменений до SIGINT/SIGTERM")
+	consumer := fs.String("consumer", migrate.DefaultConsumer, "имя потребителя ленты изменений источника")
+	cfg := loadCommandConfig(fs, args)
+
+	fromCfg, err := storageFromSpec(cfg.Storage, *fromSpec)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "-from:", err)
+		return 2
+	}
+	toCfg, err := storageFromSpec(cfg.Storage, *toSpec)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "-to:", err)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	from, err := storage.Open(ctx, fromCfg)
+	if err != nil {
+		slog.Error("Ошибка открытия источника", slog.Any("error", err))
+		return 1
+	}
+	defer from.Close()
+	to, err := storage.Open(ctx, toCfg)
+	if err != nil {
+		slog.Error("Ошибка открытия приемника", slog.Any("error", err))
+		return 1
+	}
+	defer to.Close()
+
+	report, err := migrate.Run(ctx, from, to, migrate.Options{BatchSize: *batch, Follow: *follow, Consumer: *consumer})
+	slog.Info("Итог переноса",
+		slog.Int("posts", report.Copied.Posts), slog.Int("comments", report.Copied.Comments),
+		slog.Int("changes", report.Changes), slog.Int64("seq", report.LastSeq),
+		slog.Any("repaired", report.Repaired),
+		slog.Group("verify",
+			slog.Int("sourcePosts", report.Verify.SourcePosts), slog.Int("targetPosts", report.Verify.TargetPosts),
+			slog.Int("sourceComments", report.Verify.SourceComments), slog.Int("targetComments", report.Verify.TargetComments),
+			slog.Any("mismatched", report.Verify.Mismatched)))
+	if err != nil {
+		slog.Error("Ошибка переноса", slog.Any("error", err))
+		return 1
+	}
+	return 0
+}
@@ -1,24 +1,280 @@
-package config
-
-import "os"
-
-type Config struct {
-	StorageType string
-	DSN         string
-}
-
-func LoadConfig() *Config {
-	config := &Config{
-		StorageType: getEnvOrDefault("STORAGE_TYPE", "memory"),
-		DSN:         os.Getenv("POSTGRES_DSN"),
-	}
-	return config
-}
-
-func getEnvOrDefault(key, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
-	}
-	return value
-}
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Config — полная конфигурация сервиса. Значения берутся по возрастанию
+// приоритета: значения по умолчанию, файл (YAML или TOML), переменные
+// окружения (тег env) и флаги командной строки (имя флага — путь через точку,
+// например -storage.type). Поля с тегом secret маскируются в `config print`.
+type Config struct {
+	Server    ServerConfig    `yaml:"server" toml:"server"`
+	Storage   StorageConfig   `yaml:"storage" toml:"storage"`
+	Limits    LimitsConfig    `yaml:"limits" toml:"limits"`
+	Auth      AuthConfig      `yaml:"auth" toml:"auth"`
+	Log       LogConfig       `yaml:"log" toml:"log"`
+	Filter    FilterConfig    `yaml:"filter" toml:"filter"`
+	Events    EventsConfig    `yaml:"events" toml:"events"`
+	Scheduler SchedulerConfig `yaml:"scheduler" toml:"scheduler"`
+	Tracing   TracingConfig   `yaml:"tracing" toml:"tracing"`
+}
+
+// ServerConfig — HTTP-сервер и его таймауты.
+type ServerConfig struct {
+	Addr              string        `yaml:"addr" toml:"addr" env:"ADDR" desc:"адрес HTTP-сервера"`
+	GRPCAddr          string        `yaml:"grpcAddr" toml:"grpcAddr" env:"GRPC_ADDR" desc:"адрес gRPC-сервера; пусто — отключен"`
+	ReadHeaderTimeout time.Duration `yaml:"readHeaderTimeout" toml:"readHeaderTimeout" env:"READ_HEADER_TIMEOUT" desc:"таймаут чтения заголовков запроса"`
+	ReadTimeout       time.Duration `yaml:"readTimeout" toml:"readTimeout" env:"READ_TIMEOUT" desc:"таймаут чтения запроса целиком"`
+	IdleTimeout       time.Duration `yaml:"idleTimeout" toml:"idleTimeout" env:"IDLE_TIMEOUT" desc:"время жизни простаивающего keep-alive соединения"`
+	// Время на завершение активных запросов при остановке сервера
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout" toml:"shutdownTimeout" env:"SHUTDOWN_TIMEOUT" desc:"время на завершение запросов при остановке"`
+	// Пауза перед остановкой, пока /readyz уже отвечает 503
+	ShutdownDelay time.Duration `yaml:"shutdownDelay" toml:"shutdownDelay" env:"SHUTDOWN_DELAY" desc:"пауза перед остановкой с 503 в /readyz"`
+	// Таймаут проверки зависимостей в /readyz
+	ReadinessTimeout time.Duration `yaml:"readinessTimeout" toml:"readinessTimeout" env:"READINESS_TIMEOUT" desc:"таймаут проверок /readyz"`
+	// Интервал пингов SSE-подписок и websocket keep-alive
+	SSEHeartbeat       time.Duration `yaml:"sseHeartbeat" toml:"sseHeartbeat" env:"SSE_HEARTBEAT" desc:"интервал пингов SSE"`
+	WebsocketKeepAlive time.Duration `yaml:"websocketKeepAlive" toml:"websocketKeepAlive" env:"WEBSOCKET_KEEPALIVE" desc:"интервал keep-alive websocket"`
+}
+
+// StorageConfig — выбор хранилища и параметры подключения.
+type StorageConfig struct {
+	Type string `yaml:"type" toml:"type" env:"STORAGE_TYPE" desc:"тип хранилища: memory, postgres или sqlite"`
+	DSN  string `yaml:"dsn" toml:"dsn" env:"POSTGRES_DSN" secret:"dsn" desc:"строка подключения к PostgreSQL"`
+	// Файл базы SQLite; создается при первом запуске
+	SQLitePath string `yaml:"sqlitePath" toml:"sqlitePath" env:"SQLITE_PATH" desc:"путь к файлу базы SQLite"`
+
+	// Сохранение in-memory хранилища: журнал мутаций и снимки в каталоге; пусто — только память
+	MemoryDataDir          string        `yaml:"memoryDataDir" toml:"memoryDataDir" env:"MEMORY_DATA_DIR" desc:"каталог журнала и снимков in-memory хранилища"`
+	MemoryFsync            string        `yaml:"memoryFsync" toml:"memoryFsync" env:"MEMORY_FSYNC" desc:"когда сбрасывать журнал на диск: always, interval или never"`
+	MemoryFsyncInterval    time.Duration `yaml:"memoryFsyncInterval" toml:"memoryFsyncInterval" env:"MEMORY_FSYNC_INTERVAL" desc:"интервал fsync в режиме interval"`
+	MemorySnapshotInterval time.Duration `yaml:"memorySnapshotInterval" toml:"memorySnapshotInterval" env:"MEMORY_SNAPSHOT_INTERVAL" desc:"как часто сворачивать журнал в снимок"`
+
+	// Пул соединений PostgreSQL; 0 — значение database/sql по умолчанию
+	MaxOpenConns    int           `yaml:"maxOpenConns" toml:"maxOpenConns" env:"POSTGRES_MAX_OPEN_CONNS" desc:"максимум открытых соединений"`
+	MaxIdleConns    int           `yaml:"maxIdleConns" toml:"maxIdleConns" env:"POSTGRES_MAX_IDLE_CONNS" desc:"максимум простаивающих соединений"`
+	ConnMaxLifetime time.Duration `yaml:"connMaxLifetime" toml:"connMaxLifetime" env:"POSTGRES_CONN_MAX_LIFETIME" desc:"максимальное время жизни соединения"`
+	ConnMaxIdleTime time.Duration `yaml:"connMaxIdleTime" toml:"connMaxIdleTime" env:"POSTGRES_CONN_MAX_IDLE_TIME" desc:"максимальное время простоя соединения"`
+
+	// Реплики для чтения: запросы распределяются по кругу, записи идут в primary
+	ReplicaDSNs          []string      `yaml:"replicaDsns" toml:"replicaDsns" env:"POSTGRES_REPLICA_DSNS" secret:"dsn" desc:"строки подключения к репликам через запятую"`
+	ReplicaCheckInterval time.Duration `yaml:"replicaCheckInterval" toml:"replicaCheckInterval" env:"POSTGRES_REPLICA_CHECK_INTERVAL" desc:"как часто проверять исключённые реплики"`
+	// Чтение своих записей: после записи в рамках HTTP-запроса чтения идут в primary
+	ReadYourWrites bool `yaml:"readYourWrites" toml:"readYourWrites" env:"POSTGRES_READ_YOUR_WRITES" desc:"читать с primary после записи в том же запросе"`
+
+	// Ожидание PostgreSQL при запуске и повторы чтений при временных ошибках
+	ConnectTimeout time.Duration `yaml:"connectTimeout" toml:"connectTimeout" env:"POSTGRES_CONNECT_TIMEOUT" desc:"сколько ждать доступности PostgreSQL при запуске"`
+	ReadRetries    int           `yaml:"readRetries" toml:"readRetries" env:"POSTGRES_READ_RETRIES" desc:"число попыток чтения при временных ошибках"`
+	RetryBackoff   time.Duration `yaml:"retryBackoff" toml:"retryBackoff" env:"POSTGRES_RETRY_BACKOFF" desc:"начальная пауза между повторами"`
+}
+
+// SchemaCommentMaxLength — ограничение CHECK на длину комментария в схемах
+// PostgreSQL и SQLite: больший лимит пропускал бы комментарии, которые
+// хранилище отвергнет при вставке.
+const SchemaCommentMaxLength = 2000
+
+// LimitsConfig — ограничения на размер запросов и контента.
+type LimitsConfig struct {
+	CommentMaxLength int   `yaml:"commentMaxLength" toml:"commentMaxLength" env:"COMMENT_MAX_LENGTH" desc:"максимальная длина комментария"`
+	MaxRequestBody   int64 `yaml:"maxRequestBody" toml:"maxRequestBody" env:"MAX_REQUEST_BODY" desc:"максимальный размер тела запроса в байтах"`
+	QueryComplexity  int   `yaml:"queryComplexity" toml:"queryComplexity" env:"QUERY_COMPLEXITY" desc:"лимит сложности GraphQL-запроса, 0 — без лимита"`
+}
+
+// AuthConfig — ключи доступа к API: ключи администраторов и ключи авторов,
+// от имени которых выполняются запросы к их черновикам.
+type AuthConfig struct {
+	APIKeys    []string `yaml:"apiKeys" toml:"apiKeys" env:"API_KEYS" secret:"true" desc:"API-ключи через запятую"`
+	AuthorKeys []string `yaml:"authorKeys" toml:"authorKeys" env:"AUTHOR_KEYS" secret:"true" desc:"ключи авторов через запятую в виде автор=ключ"`
+}
+
+// LogConfig — уровень (debug, info, warn, error) и формат (json, text) логов.
+type LogConfig struct {
+	Level  string `yaml:"level" toml:"level" env:"LOG_LEVEL" desc:"уровень логирования"`
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT" desc:"формат логов: json или text"`
+}
+
+// FilterConfig — фильтрация контента.
+type FilterConfig struct {
+	BannedWords       []string      `yaml:"bannedWords" toml:"bannedWords" env:"FILTER_BANNED_WORDS" desc:"запрещённые слова через запятую"`
+	BannedWordsAction string        `yaml:"bannedWordsAction" toml:"bannedWordsAction" env:"FILTER_BANNED_WORDS_ACTION" desc:"действие для запрещённых слов: reject, flag или rewrite"`
+	MaxLinks          int           `yaml:"maxLinks" toml:"maxLinks" env:"FILTER_MAX_LINKS" desc:"максимум ссылок в тексте"`
+	DuplicateWindow   time.Duration `yaml:"duplicateWindow" toml:"duplicateWindow" env:"FILTER_DUPLICATE_WINDOW" desc:"окно поиска дубликатов"`
+	RulesFile         string        `yaml:"rulesFile" toml:"rulesFile" env:"FILTER_RULES_FILE" desc:"файл с regex-правилами"`
+	BansRefresh       time.Duration `yaml:"bansRefresh" toml:"bansRefresh" env:"FILTER_BANS_REFRESH" desc:"как часто перечитывать блокировки авторов из хранилища"`
+}
+
+// EventsConfig — окно хранения событий подписок для досылки после переподключения.
+type EventsConfig struct {
+	RetentionSize   int           `yaml:"retentionSize" toml:"retentionSize" env:"EVENTS_RETENTION_SIZE" desc:"сколько событий хранить для досылки"`
+	RetentionWindow time.Duration `yaml:"retentionWindow" toml:"retentionWindow" env:"EVENTS_RETENTION_WINDOW" desc:"сколько времени хранить события для досылки"`
+}
+
+// SchedulerConfig — публикация запланированных постов.
+type SchedulerConfig struct {
+	Interval time.Duration `yaml:"interval" toml:"interval" env:"SCHEDULER_INTERVAL" desc:"как часто проверять запланированные посты"`
+}
+
+// TracingConfig — трассировка OpenTelemetry.
+type TracingConfig struct {
+	ServiceName    string        `yaml:"serviceName" toml:"serviceName" env:"OTEL_SERVICE_NAME" desc:"имя сервиса в трассировке"`
+	Exporter       string        `yaml:"exporter" toml:"exporter" env:"TRACING_EXPORTER" desc:"экспортер: none, otlp или stdout"`
+	SampleRatio    float64       `yaml:"sampleRatio" toml:"sampleRatio" env:"TRACING_SAMPLE_RATIO" desc:"доля сэмплируемых трасс"`
+	FieldThreshold time.Duration `yaml:"fieldThreshold" toml:"fieldThreshold" env:"TRACING_FIELD_THRESHOLD" desc:"минимальная длительность резолвера для span"`
+}
+
+// Default возвращает конфигурацию со значениями по умолчанию.
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Addr:               ":8080",
+			GRPCAddr:           ":9090",
+			ReadHeaderTimeout:  10 * time.Second,
+			ReadTimeout:        30 * time.Second,
+			IdleTimeout:        2 * time.Minute,
+			ShutdownTimeout:    15 * time.Second,
+			ReadinessTimeout:   2 * time.Second,
+			SSEHeartbeat:       15 * time.Second,
+			WebsocketKeepAlive: 10 * time.Second,
+		},
+		Storage: StorageConfig{
+			Type:       "memory",
+			SQLitePath: "ozon.db",
+
+			MemoryFsync:            "interval",
+			MemoryFsyncInterval:    time.Second,
+			MemorySnapshotInterval: 5 * time.Minute,
+
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+
+			ReplicaCheckInterval: 5 * time.Second,
+			ReadYourWrites:       true,
+
+			ConnectTimeout: 30 * time.Second,
+			ReadRetries:    3,
+			RetryBackoff:   50 * time.Millisecond,
+		},
+		Limits: LimitsConfig{
+			CommentMaxLength: SchemaCommentMaxLength,
+			MaxRequestBody:   1 << 20,
+		},
+		Log: LogConfig{Level: "info", Format: "json"},
+		Filter: FilterConfig{
+			BannedWordsAction: "reject",
+			MaxLinks:          5,
+			DuplicateWindow:   time.Minute,
+			BansRefresh:       10 * time.Second,
+		},
+		Events: EventsConfig{
+			RetentionSize:   10000,
+			RetentionWindow: time.Hour,
+		},
+		Scheduler: SchedulerConfig{Interval: 10 * time.Second},
+		Tracing: TracingConfig{
+			ServiceName:    "ozon_test",
+			Exporter:       "none",
+			SampleRatio:    1,
+			FieldThreshold: 5 * time.Millisecond,
+		},
+	}
+}
+
+// Validate проверяет согласованность конфигурации, чтобы опечатки
+// обнаруживались при запуске, а не подменялись значениями по умолчанию.
+func (c *Config) Validate() error {
+	var errs []error
+
+	switch c.Storage.Type {
+	case "memory":
+		switch c.Storage.MemoryFsync {
+		case "always", "never":
+		case "interval":
+			if c.Storage.MemoryFsyncInterval <= 0 {
+				errs = append(errs, errors.New("storage.memoryFsyncInterval: для режима interval нужен положительный интервал"))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("storage.memoryFsync: неизвестный режим %q (always, interval, never)", c.Storage.MemoryFsync))
+		}
+	case "postgres":
+		if c.Storage.DSN == "" {
+			errs = append(errs, errors.New("storage.dsn: для PostgreSQL нужна строка подключения (POSTGRES_DSN)"))
+		}
+	case "sqlite":
+		if c.Storage.SQLitePath == "" {
+			errs = append(errs, errors.New("storage.sqlitePath: для SQLite нужен путь к файлу базы (SQLITE_PATH)"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("storage.type: неизвестный тип хранилища %q (memory, postgres, sqlite)", c.Storage.Type))
+	}
+	if c.Server.Addr == "" {
+		errs = append(errs, errors.New("server.addr: адрес не задан"))
+	}
+	if c.Server.GRPCAddr != "" && c.Server.GRPCAddr == c.Server.Addr {
+		errs = append(errs, errors.New("server.grpcAddr: совпадает с адресом HTTP-сервера"))
+	}
+	if c.Storage.MaxOpenConns < 0 || c.Storage.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("storage: размер пула не может быть отрицательным"))
+	}
+	if c.Storage.ReadRetries < 1 {
+		errs = append(errs, errors.New("storage.readRetries: нужна хотя бы одна попытка"))
+	}
+	if c.Storage.RetryBackoff <= 0 {
+		errs = append(errs, errors.New("storage.retryBackoff: должна быть больше нуля"))
+	}
+	if c.Storage.ReplicaCheckInterval <= 0 {
+		errs = append(errs, errors.New("storage.replicaCheckInterval: должен быть больше нуля"))
+	}
+	if c.Limits.CommentMaxLength <= 0 {
+		errs = append(errs, errors.New("limits.commentMaxLength: должно быть больше нуля"))
+	} else if c.Limits.CommentMaxLength > SchemaCommentMaxLength {
+		errs = append(errs, fmt.Errorf("limits.commentMaxLength: не больше %d — ограничение схемы хранилища", SchemaCommentMaxLength))
+	}
+	adminKeys := make(map[string]bool, len(c.Auth.APIKeys))
+	for _, key := range c.Auth.APIKeys {
+		adminKeys[key] = true
+	}
+	for _, entry := range c.Auth.AuthorKeys {
+		author, key, ok := strings.Cut(entry, "=")
+		switch {
+		case !ok || strings.TrimSpace(author) == "" || strings.TrimSpace(key) == "":
+			errs = append(errs, errors.New("auth.authorKeys: ожидается автор=ключ"))
+		case adminKeys[strings.TrimSpace(key)]:
+			errs = append(errs, fmt.Errorf("auth.authorKeys: ключ автора %q совпадает с ключом администратора", strings.TrimSpace(author)))
+		}
+	}
+	if c.Filter.BansRefresh <= 0 {
+		errs = append(errs, errors.New("filter.bansRefresh: должен быть больше нуля"))
+	}
+	if c.Scheduler.Interval <= 0 {
+		errs = append(errs, errors.New("scheduler.interval: должен быть больше нуля"))
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		errs = append(errs, fmt.Errorf("log.level: неизвестный уровень %q", c.Log.Level))
+	}
+	if f := strings.ToLower(c.Log.Format); f != "json" && f != "text" {
+		errs = append(errs, fmt.Errorf("log.format: неизвестный формат %q (json, text)", c.Log.Format))
+	}
+	switch strings.ToLower(c.Filter.BannedWordsAction) {
+	case "reject", "flag", "rewrite":
+	default:
+		errs = append(errs, fmt.Errorf("filter.bannedWordsAction: неизвестное действие %q", c.Filter.BannedWordsAction))
+	}
+	switch c.Tracing.Exporter {
+	case "none", "otlp", "stdout":
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter: неизвестный экспортер %q (none, otlp, stdout)", c.Tracing.Exporter))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, errors.New("tracing.sampleRatio: должно быть от 0 до 1"))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,251 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv — переменная окружения с путём к файлу конфигурации; флаг -config
+// имеет приоритет над ней.
+const FileEnv = "CONFIG_FILE"
+
+// Load собирает конфигурацию: значения по умолчанию, файл, окружение и флаги
+// из args (без имени программы). Результат проверяется Validate.
+// При -h возвращается flag.ErrHelp.
+func Load(name string, args []string) (*Config, error) {
+	return LoadFlags(flag.NewFlagSet(name, flag.ContinueOnError), args)
+}
+
+// LoadFlags — Load с набором флагов команды: собственные флаги команды
+// регистрируются в fs заранее и разбираются вместе с флагами конфигурации.
+func LoadFlags(fs *flag.FlagSet, args []string) (*Config, error) {
+	cfg := Default()
+	fields := collectFields(cfg)
+
+	path := fs.String("config", os.Getenv(FileEnv), "файл конфигурации (YAML или TOML)")
+
+	// Флаги разбираются первыми, чтобы узнать путь к файлу, а применяются
+	// последними, поверх файла и окружения.
+	var overrides []func() error
+	for _, f := range fields {
+		fs.Var(&flagValue{
+			def: formatValue(f.value),
+			typ: f.value.Type(),
+			set: func(s string) {
+				overrides = append(overrides, func() error { return setValue(f.value, s) })
+			},
+		}, f.path, f.usage())
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("неожиданные аргументы: %s", strings.Join(fs.Args(), " "))
+	}
+
+	if *path != "" {
+		if err := loadFile(*path, cfg); err != nil {
+			return nil, err
+		}
+	}
+	if err := loadEnv(fields); err != nil {
+		return nil, err
+	}
+	for _, apply := range overrides {
+		if err := apply(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("некорректная конфигурация:\n%w", err)
+	}
+	return cfg, nil
+}
+
+// loadFile читает YAML или TOML в зависимости от расширения. Неизвестные
+// ключи считаются ошибкой, чтобы опечатки не проходили незамеченными.
+func loadFile(path string, cfg *Config) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("чтение конфигурации: %w", err)
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(f)
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	case ".toml":
+		md, err := toml.NewDecoder(f).Decode(cfg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("%s: неизвестные ключи %v", path, undecoded)
+		}
+	default:
+		return fmt.Errorf("%s: неизвестный формат конфигурации (нужен .yaml, .yml или .toml)", path)
+	}
+	return nil
+}
+
+// loadEnv применяет переменные окружения. PORT поддерживается для
+// совместимости, если ADDR не задан.
+func loadEnv(fields []field) error {
+	for _, f := range fields {
+		value, ok := os.LookupEnv(f.env)
+		if f.env == "" || !ok || value == "" {
+			continue
+		}
+		if err := setValue(f.value, value); err != nil {
+			return fmt.Errorf("%s: %w", f.env, err)
+		}
+	}
+	if port := os.Getenv("PORT"); port != "" && os.Getenv("ADDR") == "" {
+		for _, f := range fields {
+			if f.path == "server.addr" {
+				f.value.SetString(":" + port)
+			}
+		}
+	}
+	return nil
+}
+
+// field — лист структуры конфигурации с путём вида storage.dsn.
+type field struct {
+	path   string
+	env    string
+	desc   string
+	secret string
+	value  reflect.Value
+}
+
+func (f field) usage() string {
+	if f.env == "" {
+		return f.desc
+	}
+	return fmt.Sprintf("%s (%s)", f.desc, f.env)
+}
+
+func collectFields(cfg *Config) []field {
+	var fields []field
+	var walk func(prefix string, v reflect.Value)
+	walk = func(prefix string, v reflect.Value) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			path := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+			if prefix != "" {
+				path = prefix + "." + path
+			}
+			if sf.Type.Kind() == reflect.Struct {
+				walk(path, v.Field(i))
+				continue
+			}
+			fields = append(fields, field{
+				path:   path,
+				env:    sf.Tag.Get("env"),
+				desc:   sf.Tag.Get("desc"),
+				secret: sf.Tag.Get("secret"),
+				value:  v.Field(i),
+			})
+		}
+	}
+	walk("", reflect.ValueOf(cfg).Elem())
+	return fields
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setValue разбирает строку s в значение поля: строки, числа, bool,
+// длительности и списки через запятую.
+func setValue(v reflect.Value, s string) error {
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(s)
+	case v.Kind() == reflect.Int || v.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case v.Kind() == reflect.Float64:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		var list []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		v.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("неподдерживаемый тип %s", v.Type())
+	}
+	return nil
+}
+
+func formatValue(v reflect.Value) string {
+	switch {
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String()
+	case v.Kind() == reflect.Slice:
+		return strings.Join(v.Interface().([]string), ",")
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// flagValue — флаг для поля конфигурации; значение проверяется сразу,
+// а применяется после файла и окружения.
+type flagValue struct {
+	def string
+	typ reflect.Type
+	set func(string)
+}
+
+func (f *flagValue) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.def
+}
+
+func (f *flagValue) Set(s string) error {
+	if err := setValue(reflect.New(f.typ).Elem(), s); err != nil {
+		return err
+	}
+	f.set(s)
+	return nil
+}
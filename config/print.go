@@ -0,0 +1,100 @@
+package config
+
+import (
+	"io"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// masked заменяет значения секретов при выводе конфигурации.
+const masked = "xxxxx"
+
+// Print выводит действующую конфигурацию в YAML (пригодном для -config)
+// с замаскированными секретами.
+func Print(w io.Writer, cfg *Config) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	sections := map[string]*yaml.Node{}
+
+	for _, f := range collectFields(cfg) {
+		section, key, _ := strings.Cut(f.path, ".")
+		node, ok := sections[section]
+		if !ok {
+			node = &yaml.Node{Kind: yaml.MappingNode}
+			sections[section] = node
+			root.Content = append(root.Content, scalar(section), node)
+		}
+
+		value := valueNode(f.value)
+		if f.env != "" {
+			value.LineComment = f.env
+		}
+		mask(value, f.secret)
+		node.Content = append(node.Content, scalar(key), value)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func scalar(s string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s}
+}
+
+func valueNode(v reflect.Value) *yaml.Node {
+	if v.Kind() == reflect.Slice {
+		node := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+		for _, item := range v.Interface().([]string) {
+			node.Content = append(node.Content, scalar(item))
+		}
+		return node
+	}
+	node := &yaml.Node{Kind: yaml.ScalarNode, Value: formatValue(v)}
+	if v.Kind() == reflect.String || v.Type() == durationType {
+		node.Tag = "!!str"
+	}
+	return node
+}
+
+// dsnPassword находит пароль в DSN вида "key=value".
+var dsnPassword = regexp.MustCompile(`(password=)('[^']*'|\S+)`)
+
+func mask(node *yaml.Node, secret string) {
+	switch secret {
+	case "":
+	case "dsn":
+		if node.Kind == yaml.SequenceNode {
+			for _, item := range node.Content {
+				item.Value = MaskDSN(item.Value)
+			}
+		} else {
+			node.Value = MaskDSN(node.Value)
+		}
+	default:
+		if node.Kind == yaml.SequenceNode {
+			for _, item := range node.Content {
+				item.Value = masked
+			}
+		} else if node.Value != "" {
+			node.Value = masked
+		}
+	}
+}
+
+// MaskDSN скрывает пароль в строке подключения: в URL и в форме key=value.
+func MaskDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), masked)
+		}
+		return u.String()
+	}
+	return dsnPassword.ReplaceAllString(dsn, "${1}"+masked)
+}
@@ -0,0 +1,226 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"ozon_test/graph/model"
+)
+
+// Type — тип события.
+type Type string
+
+const (
+	PostCreated         Type = "postCreated"
+	CommentAdded        Type = "commentAdded"
+	CommentUpdated      Type = "commentUpdated"
+	CommentDeleted      Type = "commentDeleted"
+	PostCommentsToggled Type = "postCommentsToggled"
+)
+
+// subscriberBuffer — размер буфера канала подписчика сверх досылаемых событий.
+const subscriberBuffer = 64
+
+// ErrCursorExpired возвращается, если события после запрошенного курсора
+// уже вытеснены из окна хранения или курсор выдан другим запуском сервера.
+var ErrCursorExpired = errors.New("курсор устарел: пропущенные события больше не хранятся, переподпишитесь без since")
+
+// Event — событие, публикуемое мутациями. Заполнены только поля,
+// относящиеся к типу события.
+type Event struct {
+	Cursor          model.Cursor
+	Type            Type
+	PostID          string
+	Post            *model.Post
+	Comment         *model.Comment
+	CommentID       string
+	CommentsAllowed bool
+
+	publishedAt time.Time
+}
+
+// Retention задает окно хранения событий для досылки после переподключения.
+type Retention struct {
+	// Size — максимальное число хранимых событий.
+	Size int
+	// Window — максимальный возраст хранимого события.
+	Window time.Duration
+}
+
+// DefaultRetention — окно хранения по умолчанию.
+var DefaultRetention = Retention{Size: 10000, Window: time.Hour}
+
+type subscriber struct {
+	ch     chan Event
+	match  func(Event) bool
+	closed bool
+}
+
+// Broker рассылает события мутаций всем подходящим подписчикам. Каждое
+// событие получает монотонно возрастающий курсор; последние события
+// хранятся в ограниченном окне, чтобы переподключившийся клиент мог
+// получить пропущенное.
+type Broker struct {
+	mu        sync.RWMutex
+	epoch     int64
+	seq       uint64
+	retention Retention
+	log       []Event
+	next      int
+	subs      map[int]*subscriber
+	closed    bool
+}
+
+// NewBroker создает брокер событий; нулевые поля retention заменяются значениями по умолчанию.
+func NewBroker(retention Retention) *Broker {
+	if retention.Size <= 0 {
+		retention.Size = DefaultRetention.Size
+	}
+	if retention.Window <= 0 {
+		retention.Window = DefaultRetention.Window
+	}
+	return &Broker{
+		epoch:     time.Now().UnixNano(),
+		retention: retention,
+		subs:      make(map[int]*subscriber),
+	}
+}
+
+// Publish присваивает событию курсор, сохраняет его в окне хранения и
+// отправляет подписчикам. Публикация не блокируется: подписка, буфер
+// которой переполнен, завершается, а не теряет событие молча, — клиент
+// переподключается с курсором последнего полученного события и получает
+// пропущенное из окна хранения.
+func (b *Broker) Publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	e.Cursor = model.Cursor{Epoch: b.epoch, Seq: b.seq}
+	if e.Type == CommentAdded && e.Comment != nil {
+		e.Cursor.CreatedAt, e.Cursor.CommentID = e.Comment.CreatedAt, e.Comment.ID
+	}
+	e.publishedAt = time.Now()
+	b.log = append(b.log, e)
+	b.trim(e.publishedAt)
+
+	for id, s := range b.subs {
+		if s.match != nil && !s.match(e) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+			slog.Warn("Подписчик не успевает читать события, подписка завершена",
+				slog.String("type", string(e.Type)), slog.String("post_id", e.PostID))
+			delete(b.subs, id)
+			s.closed = true
+			close(s.ch)
+		}
+	}
+}
+
+// trim вытесняет события, вышедшие за окно хранения.
+func (b *Broker) trim(now time.Time) {
+	drop := 0
+	if len(b.log) > b.retention.Size {
+		drop = len(b.log) - b.retention.Size
+	}
+	for drop < len(b.log) && now.Sub(b.log[drop].publishedAt) > b.retention.Window {
+		drop++
+	}
+	if drop > 0 {
+		b.log = append(b.log[:0:0], b.log[drop:]...)
+	}
+}
+
+// Subscribe возвращает канал событий, для которых match возвращает true
+// (nil — все события). Канал закрывается при отмене ctx.
+func (b *Broker) Subscribe(ctx context.Context, match func(Event) bool) <-chan Event {
+	ch, _ := b.SubscribeSince(ctx, nil, match)
+	return ch
+}
+
+// SubscribeSince работает как Subscribe, но сначала досылает сохранённые
+// события с курсором больше since. Если since вышел за окно хранения,
+// возвращается ErrCursorExpired: окно живет в памяти процесса, и
+// пропущенные комментарии досылает уже сервис из хранилища по позиции
+// комментария в курсоре.
+func (b *Broker) SubscribeSince(ctx context.Context, since *model.Cursor, match func(Event) bool) (<-chan Event, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var backlog []Event
+	if since != nil {
+		if since.Epoch != b.epoch || since.Seq > b.seq {
+			return nil, ErrCursorExpired
+		}
+		b.trim(time.Now())
+		oldest := b.seq + 1
+		if len(b.log) > 0 {
+			oldest = b.log[0].Cursor.Seq
+		}
+		if since.Seq+1 < oldest {
+			return nil, ErrCursorExpired
+		}
+		for _, e := range b.log {
+			if e.Cursor.Seq > since.Seq && (match == nil || match(e)) {
+				backlog = append(backlog, e)
+			}
+		}
+	}
+
+	s := &subscriber{ch: make(chan Event, len(backlog)+subscriberBuffer), match: match}
+	for _, e := range backlog {
+		s.ch <- e
+	}
+	if b.closed {
+		close(s.ch)
+		return s.ch, nil
+	}
+
+	id := b.next
+	b.next++
+	b.subs[id] = s
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		delete(b.subs, id)
+		if !s.closed {
+			s.closed = true
+			close(s.ch)
+		}
+		b.mu.Unlock()
+	}()
+
+	return s.ch, nil
+}
+
+// Subscribers возвращает число активных подписок.
+func (b *Broker) Subscribers() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return len(b.subs)
+}
+
+// Close завершает все подписки: каналы подписчиков закрываются, и транспорты
+// отправляют клиентам сообщение complete. Новые подписки после Close сразу
+// завершаются; публикация продолжает работать, но событие никому не доставляется.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for id, s := range b.subs {
+		delete(b.subs, id)
+		if !s.closed {
+			s.closed = true
+			close(s.ch)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package events
+
+import (
+	"context"
+	"sync"
+
+	"ozon_test/graph/model"
+)
+
+type resumeKey struct{}
+type trackerKey struct{}
+
+// WithResume сохраняет в контексте курсор, с которого транспорт просит
+// продолжить подписку (например, из заголовка Last-Event-ID).
+func WithResume(ctx context.Context, cursor model.Cursor) context.Context {
+	return context.WithValue(ctx, resumeKey{}, cursor)
+}
+
+// ResumeFrom возвращает курсор, сохранённый WithResume, или nil.
+func ResumeFrom(ctx context.Context) *model.Cursor {
+	if c, ok := ctx.Value(resumeKey{}).(model.Cursor); ok {
+		return &c
+	}
+	return nil
+}
+
+// Tracker сообщает транспорту курсоры отправленных событий в порядке
+// отправки: подписка добавляет курсор перед передачей события, транспорт
+// забирает по одному курсору на каждый ответ.
+type Tracker struct {
+	mu    sync.Mutex
+	queue []model.Cursor
+}
+
+// WithTracker добавляет в контекст новый Tracker.
+func WithTracker(ctx context.Context) (context.Context, *Tracker) {
+	t := &Tracker{}
+	return context.WithValue(ctx, trackerKey{}, t), t
+}
+
+// TrackerFrom возвращает Tracker из контекста или nil.
+func TrackerFrom(ctx context.Context) *Tracker {
+	t, _ := ctx.Value(trackerKey{}).(*Tracker)
+	return t
+}
+
+// Push добавляет курсор отправляемого события.
+func (t *Tracker) Push(c model.Cursor) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.queue = append(t.queue, c)
+}
+
+// Pop возвращает курсор самого раннего неподтверждённого события.
+func (t *Tracker) Pop() (model.Cursor, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.queue) == 0 {
+		return model.Cursor{}, false
+	}
+	c := t.queue[0]
+	t.queue = t.queue[1:]
+	return c, true
+}
@@ -0,0 +1,169 @@
+package filter
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ozon_test/graph/model"
+	"ozon_test/storage"
+)
+
+// Ban — заблокированный автор.
+type Ban struct {
+	Author   string
+	Reason   string
+	BannedAt time.Time
+}
+
+// Bans отклоняет посты и комментарии заблокированных авторов. Список
+// пополняется администраторами через API. Проверка идет по копии в памяти;
+// с хранилищем (NewStoredBans) блокировки сначала записываются в него,
+// а блокировки других экземпляров подтягиваются через Reload или Run.
+type Bans struct {
+	mu    sync.RWMutex
+	now   func() time.Time
+	store storage.BanStore
+	bans  map[string]Ban
+}
+
+// NewBans создает пустой список блокировок, который живет только в памяти процесса.
+func NewBans() *Bans {
+	return &Bans{now: time.Now, bans: make(map[string]Ban)}
+}
+
+// NewStoredBans создает список блокировок поверх хранилища и загружает его.
+func NewStoredBans(ctx context.Context, store storage.BanStore) (*Bans, error) {
+	f := NewBans()
+	f.store = store
+	if err := f.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *Bans) Name() string { return "banned_user" }
+
+func (f *Bans) Check(c *Content) Decision {
+	f.mu.RLock()
+	ban, banned := f.bans[banKey(c.Author)]
+	f.mu.RUnlock()
+
+	if !banned {
+		return Decision{Action: Allow}
+	}
+	reason := "автор заблокирован"
+	if ban.Reason != "" {
+		reason += ": " + ban.Reason
+	}
+	return Decision{Action: Reject, Reason: reason}
+}
+
+// Ban блокирует автора; повторная блокировка обновляет причину.
+func (f *Bans) Ban(ctx context.Context, author, reason string) (Ban, error) {
+	key := banKey(author)
+	ban := Ban{Author: author, Reason: reason, BannedAt: f.now()}
+	if f.store != nil {
+		err := f.store.SaveBan(ctx, key, &model.BannedUser{Author: ban.Author, Reason: ban.Reason, BannedAt: ban.BannedAt})
+		if err != nil {
+			return Ban{}, err
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.bans[key] = ban
+	return ban, nil
+}
+
+// Unban снимает блокировку; false — автор не был заблокирован.
+func (f *Bans) Unban(ctx context.Context, author string) (bool, error) {
+	key := banKey(author)
+	stored := false
+	if f.store != nil {
+		err := f.store.DeleteBan(ctx, key)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return false, err
+		}
+		stored = err == nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, banned := f.bans[key]
+	delete(f.bans, key)
+	return stored || banned, nil
+}
+
+// Reload заменяет список в памяти блокировками из хранилища. Без
+// хранилища ничего не делает.
+func (f *Bans) Reload(ctx context.Context) error {
+	if f.store == nil {
+		return nil
+	}
+	stored, err := f.store.ListBans(ctx)
+	if err != nil {
+		return err
+	}
+
+	bans := make(map[string]Ban, len(stored))
+	for _, b := range stored {
+		bans[banKey(b.Author)] = Ban{Author: b.Author, Reason: b.Reason, BannedAt: b.BannedAt}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.bans = bans
+	return nil
+}
+
+// Run перечитывает блокировки из хранилища каждые interval, пока не
+// отменен ctx: так блокировки, добавленные через другой экземпляр,
+// начинают действовать и здесь.
+func (f *Bans) Run(ctx context.Context, interval time.Duration) {
+	if f.store == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := f.Reload(ctx); err != nil && ctx.Err() == nil {
+				slog.WarnContext(ctx, "Ошибка загрузки блокировок авторов", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// List возвращает блокировки, начиная с последней.
+func (f *Bans) List() []Ban {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	list := make([]Ban, 0, len(f.bans))
+	for _, ban := range f.bans {
+		list = append(list, ban)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].BannedAt.After(list[j].BannedAt) })
+	return list
+}
+
+// Len возвращает число заблокированных авторов.
+func (f *Bans) Len() int {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return len(f.bans)
+}
+
+// banKey — имя автора без учета регистра и пробелов по краям.
+func banKey(author string) string {
+	return strings.ToLower(strings.TrimSpace(author))
+}
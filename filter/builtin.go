@@ -0,0 +1,107 @@
+package filter
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+var linkRe = regexp.MustCompile(`(?i)\bhttps?://\S+|\bwww\.\S+`)
+
+// MaxLinks отклоняет контент, в котором ссылок больше допустимого.
+type MaxLinks struct {
+	max int
+}
+
+// NewMaxLinks создает фильтр с лимитом ссылок.
+func NewMaxLinks(max int) *MaxLinks {
+	return &MaxLinks{max: max}
+}
+
+func (f *MaxLinks) Name() string { return "max_links" }
+
+func (f *MaxLinks) Check(c *Content) Decision {
+	n := len(linkRe.FindAllStringIndex(c.Title, -1)) + len(linkRe.FindAllStringIndex(c.Body, -1))
+	if n > f.max {
+		return Decision{
+			Action: Reject,
+			Reason: fmt.Sprintf("слишком много ссылок: %d (максимум %d)", n, f.max),
+		}
+	}
+	return Decision{Action: Allow}
+}
+
+// Duplicate отклоняет повторную публикацию одинакового контента одним автором
+// в пределах временного окна. Контент запоминается только после сохранения
+// (Pipeline.Accept).
+type Duplicate struct {
+	mu     sync.Mutex
+	window time.Duration
+	now    func() time.Time
+	seen   map[[sha256.Size]byte]time.Time
+	// order — запомненный контент в порядке сохранения: устаревшие записи
+	// вытесняются с начала, без обхода всей карты.
+	order []duplicateEntry
+}
+
+type duplicateEntry struct {
+	key [sha256.Size]byte
+	at  time.Time
+}
+
+// NewDuplicate создает детектор повторов с заданным окном.
+func NewDuplicate(window time.Duration) *Duplicate {
+	return &Duplicate{
+		window: window,
+		now:    time.Now,
+		seen:   make(map[[sha256.Size]byte]time.Time),
+	}
+}
+
+func (f *Duplicate) Name() string { return "duplicate" }
+
+func (f *Duplicate) Check(c *Content) Decision {
+	key := sha256.Sum256([]byte(string(c.Kind) + "\x00" + c.Author + "\x00" + c.PostID + "\x00" +
+		strings.ToLower(strings.Join(strings.Fields(c.Title+" "+c.Body), " "))))
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.now()
+	f.expire(now)
+	if t, ok := f.seen[key]; ok {
+		return Decision{
+			Action: Reject,
+			Reason: fmt.Sprintf("повтор контента, опубликованного %s назад", now.Sub(t).Round(time.Second)),
+		}
+	}
+	c.OnAccept(func() { f.record(key) })
+	return Decision{Action: Allow}
+}
+
+// record запоминает сохраненный контент.
+func (f *Duplicate) record(key [sha256.Size]byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.now()
+	f.expire(now)
+	f.seen[key] = now
+	f.order = append(f.order, duplicateEntry{key: key, at: now})
+}
+
+// expire забывает контент, сохраненный раньше окна.
+func (f *Duplicate) expire(now time.Time) {
+	i := 0
+	for ; i < len(f.order) && now.Sub(f.order[i].at) > f.window; i++ {
+		e := f.order[i]
+		// Ключ мог быть запомнен заново позже — тогда запись актуальна
+		if f.seen[e.key].Equal(e.at) {
+			delete(f.seen, e.key)
+		}
+	}
+	f.order = f.order[i:]
+}
@@ -0,0 +1,40 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+
+	"ozon_test/config"
+)
+
+// NewPipelineFromConfig собирает пайплайн из встроенных фильтров по
+// конфигурации; bans — список блокировок (NewStoredBans или NewBans),
+// journal — журнал модерации (NewStoredJournal или NewJournal).
+func NewPipelineFromConfig(cfg *config.Config, bans *Bans, journal *Journal) (*Pipeline, error) {
+	p := NewPipeline(journal)
+	// Блокировки первыми: контент заблокированного автора не доходит до
+	// фильтров с состоянием (например, поиска дубликатов)
+	p.UseBans(bans)
+
+	if len(cfg.Filter.BannedWords) > 0 {
+		action := Action(strings.ToUpper(cfg.Filter.BannedWordsAction))
+		if action != Reject && action != Flag && action != Rewrite {
+			return nil, fmt.Errorf("неизвестное действие для запрещённых слов: %q", cfg.Filter.BannedWordsAction)
+		}
+		p.Use(NewBannedWords(cfg.Filter.BannedWords, action))
+	}
+	if cfg.Filter.MaxLinks > 0 {
+		p.Use(NewMaxLinks(cfg.Filter.MaxLinks))
+	}
+	if cfg.Filter.DuplicateWindow > 0 {
+		p.Use(NewDuplicate(cfg.Filter.DuplicateWindow))
+	}
+	if cfg.Filter.RulesFile != "" {
+		rules, err := LoadRegexRules(cfg.Filter.RulesFile)
+		if err != nil {
+			return nil, err
+		}
+		p.Use(rules)
+	}
+	return p, nil
+}
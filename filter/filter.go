@@ -0,0 +1,186 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Action — решение фильтра относительно контента.
+type Action string
+
+const (
+	Allow   Action = "ALLOW"
+	Rewrite Action = "REWRITE"
+	Flag    Action = "FLAG"
+	Reject  Action = "REJECT"
+)
+
+// severity задаёт порядок решений: итоговое решение пайплайна — самое строгое.
+var severity = map[Action]int{Allow: 0, Rewrite: 1, Flag: 2, Reject: 3}
+
+// Kind — тип проверяемого контента.
+type Kind string
+
+const (
+	KindPost    Kind = "post"
+	KindComment Kind = "comment"
+)
+
+// Content — контент, проходящий через фильтры. Фильтры, переписывающие
+// текст, изменяют поля Title и Body напрямую.
+type Content struct {
+	Kind   Kind
+	ID     string
+	PostID string
+	Author string
+	Title  string
+	Body   string
+
+	onAccept []func()
+}
+
+// OnAccept регистрирует действие, которое выполнится, когда контент будет
+// сохранен (Pipeline.Accept); фильтры запоминают через него принятый контент.
+func (c *Content) OnAccept(fn func()) {
+	c.onAccept = append(c.onAccept, fn)
+}
+
+// Decision — решение одного фильтра.
+type Decision struct {
+	Filter string
+	Action Action
+	Reason string
+}
+
+// Filter — отдельное правило проверки контента.
+type Filter interface {
+	Name() string
+	Check(c *Content) Decision
+}
+
+// RejectedError возвращается, если хотя бы один фильтр отклонил контент.
+type RejectedError struct {
+	Decision Decision
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("контент отклонён фильтром %s: %s", e.Decision.Filter, e.Decision.Reason)
+}
+
+// Result — итог прохождения пайплайна.
+type Result struct {
+	Action    Action
+	Decisions []Decision
+}
+
+// Flagged сообщает, что контент требует внимания модератора.
+func (r Result) Flagged() bool {
+	return r.Action == Flag
+}
+
+// Pipeline последовательно применяет фильтры к контенту перед сохранением.
+type Pipeline struct {
+	mu      sync.RWMutex
+	filters []Filter
+	journal *Journal
+	bans    *Bans
+}
+
+// NewPipeline создает пайплайн; journal может быть nil, тогда решения не сохраняются.
+func NewPipeline(journal *Journal, filters ...Filter) *Pipeline {
+	return &Pipeline{filters: filters, journal: journal}
+}
+
+// Use добавляет фильтр в конец пайплайна.
+func (p *Pipeline) Use(f Filter) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.filters = append(p.filters, f)
+}
+
+// UseBans добавляет в конец пайплайна список блокировок авторов;
+// он доступен администраторам через Bans.
+func (p *Pipeline) UseBans(b *Bans) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.bans = b
+	p.filters = append(p.filters, b)
+}
+
+// Bans возвращает список блокировок; nil — блокировки не подключены.
+func (p *Pipeline) Bans() *Bans {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.bans
+}
+
+// Journal возвращает журнал решений пайплайна.
+func (p *Pipeline) Journal() *Journal {
+	return p.journal
+}
+
+// Run прогоняет контент через все фильтры. Отклонённый контент возвращает
+// *RejectedError; решения всех фильтров записываются в журнал, если итог
+// отличается от Allow. Ошибка записи в журнал только пишется в лог: она не
+// должна менять решение о контенте.
+func (p *Pipeline) Run(ctx context.Context, c *Content) (Result, error) {
+	p.mu.RLock()
+	filters := p.filters
+	p.mu.RUnlock()
+
+	c.onAccept = nil
+	result := Result{Action: Allow}
+	var rejected *Decision
+	for _, f := range filters {
+		d := f.Check(c)
+		if d.Filter == "" {
+			d.Filter = f.Name()
+		}
+		if d.Action == "" {
+			d.Action = Allow
+		}
+		result.Decisions = append(result.Decisions, d)
+		if severity[d.Action] > severity[result.Action] {
+			result.Action = d.Action
+		}
+		if d.Action == Reject {
+			rejected = &result.Decisions[len(result.Decisions)-1]
+			break
+		}
+	}
+
+	if p.journal != nil && result.Action != Allow {
+		err := p.journal.Add(ctx, Record{
+			TargetID:  c.ID,
+			PostID:    c.PostID,
+			Kind:      c.Kind,
+			Author:    c.Author,
+			Action:    result.Action,
+			Decisions: result.Decisions,
+			CreatedAt: time.Now(),
+		})
+		if err != nil {
+			slog.WarnContext(ctx, "Ошибка записи в журнал модерации", slog.Any("error", err))
+		}
+	}
+
+	if rejected != nil {
+		return result, &RejectedError{Decision: *rejected}
+	}
+	return result, nil
+}
+
+// Accept сообщает фильтрам, что контент, прошедший Run, сохранен. До этого
+// фильтры ничего не запоминают: контент, отклоненный позже или не
+// записанный в хранилище, не мешает повторной попытке автора.
+func (p *Pipeline) Accept(c *Content) {
+	for _, fn := range c.onAccept {
+		fn()
+	}
+	c.onAccept = nil
+}
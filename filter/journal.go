@@ -0,0 +1,153 @@
+package filter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"ozon_test/graph/model"
+	"ozon_test/storage"
+
+	"github.com/google/uuid"
+)
+
+// defaultJournalSize — сколько записей журнал хранит по умолчанию.
+const defaultJournalSize = 1000
+
+// Record — запись журнала модерации о прохождении контента через фильтры.
+type Record struct {
+	ID        string
+	TargetID  string
+	PostID    string
+	Kind      Kind
+	Author    string
+	Action    Action
+	Decisions []Decision
+	CreatedAt time.Time
+}
+
+// Model преобразует запись в GraphQL-модель, в которой журнал хранится и
+// отдается модераторам.
+func (r Record) Model() *model.ModerationRecord {
+	out := &model.ModerationRecord{
+		ID:        r.ID,
+		TargetID:  r.TargetID,
+		Kind:      string(r.Kind),
+		Author:    r.Author,
+		Action:    model.ModerationAction(r.Action),
+		Decisions: make([]*model.ModerationDecision, 0, len(r.Decisions)),
+		CreatedAt: r.CreatedAt,
+	}
+	if r.PostID != "" {
+		postID := r.PostID
+		out.PostID = &postID
+	}
+	for _, d := range r.Decisions {
+		out.Decisions = append(out.Decisions, &model.ModerationDecision{
+			Filter: d.Filter,
+			Action: model.ModerationAction(d.Action),
+			Reason: d.Reason,
+		})
+	}
+	return out
+}
+
+// recordFromModel — обратное к Record.Model преобразование записи из хранилища.
+func recordFromModel(m *model.ModerationRecord) Record {
+	rec := Record{
+		ID:        m.ID,
+		TargetID:  m.TargetID,
+		Kind:      Kind(m.Kind),
+		Author:    m.Author,
+		Action:    Action(m.Action),
+		Decisions: make([]Decision, 0, len(m.Decisions)),
+		CreatedAt: m.CreatedAt,
+	}
+	if m.PostID != nil {
+		rec.PostID = *m.PostID
+	}
+	for _, d := range m.Decisions {
+		rec.Decisions = append(rec.Decisions, Decision{Filter: d.Filter, Action: Action(d.Action), Reason: d.Reason})
+	}
+	return rec
+}
+
+// Journal хранит последние решения фильтров, чтобы модераторы видели,
+// почему контент был отклонён или помечен. С хранилищем (NewStoredJournal)
+// записи сохраняются в нем: переживают перезапуск и видны всем экземплярам.
+type Journal struct {
+	mu      sync.RWMutex
+	size    int
+	store   storage.ModerationLogStore
+	records []Record
+}
+
+// NewJournal создает журнал ограниченного размера (size <= 0 — размер по
+// умолчанию), который живет только в памяти процесса.
+func NewJournal(size int) *Journal {
+	if size <= 0 {
+		size = defaultJournalSize
+	}
+	return &Journal{size: size}
+}
+
+// NewStoredJournal создает журнал поверх хранилища; в нем остается не
+// больше size последних записей.
+func NewStoredJournal(store storage.ModerationLogStore, size int) *Journal {
+	j := NewJournal(size)
+	j.store = store
+	return j
+}
+
+// Add добавляет запись, вытесняя самые старые при переполнении.
+func (j *Journal) Add(ctx context.Context, rec Record) error {
+	if rec.ID == "" {
+		rec.ID = uuid.New().String()
+	}
+	if j.store != nil {
+		return j.store.AddModerationRecord(ctx, rec.Model(), j.size)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.records = append(j.records, rec)
+	if len(j.records) > j.size {
+		j.records = j.records[len(j.records)-j.size:]
+	}
+	return nil
+}
+
+// List возвращает записи от новых к старым с пагинацией; отрицательные
+// limit и offset считаются нулем, limit 0 — все записи.
+func (j *Journal) List(ctx context.Context, limit, offset int) ([]Record, error) {
+	limit, offset = max(limit, 0), max(offset, 0)
+	if j.store != nil {
+		stored, err := j.store.ListModerationRecords(ctx, limit, offset)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]Record, 0, len(stored))
+		for _, m := range stored {
+			out = append(out, recordFromModel(m))
+		}
+		return out, nil
+	}
+
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	n := len(j.records)
+	if offset >= n {
+		return nil, nil
+	}
+	end := offset + limit
+	if limit == 0 || end > n {
+		end = n
+	}
+
+	out := make([]Record, 0, end-offset)
+	for i := offset; i < end; i++ {
+		out = append(out, j.records[n-1-i])
+	}
+	return out, nil
+}
@@ -0,0 +1,81 @@
+package filter
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// rule — одно правило из файла регулярных выражений.
+type rule struct {
+	action Action
+	re     *regexp.Regexp
+}
+
+// RegexRules применяет правила из файла. Формат файла — по одному правилу
+// на строку: «<действие> <регулярное выражение>», где действие — reject,
+// flag или rewrite (совпадение заменяется звёздочками). Пустые строки и
+// строки, начинающиеся с #, игнорируются.
+type RegexRules struct {
+	rules []rule
+}
+
+// LoadRegexRules читает правила из файла.
+func LoadRegexRules(path string) (*RegexRules, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rules []rule
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		name, expr, ok := strings.Cut(text, " ")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: ожидается «<действие> <выражение>»", path, line)
+		}
+		action := Action(strings.ToUpper(name))
+		if action != Reject && action != Flag && action != Rewrite {
+			return nil, fmt.Errorf("%s:%d: неизвестное действие %q", path, line, name)
+		}
+		re, err := regexp.Compile(strings.TrimSpace(expr))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		rules = append(rules, rule{action: action, re: re})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return &RegexRules{rules: rules}, nil
+}
+
+func (f *RegexRules) Name() string { return "regex_rules" }
+
+func (f *RegexRules) Check(c *Content) Decision {
+	d := Decision{Action: Allow}
+	for _, r := range f.rules {
+		if !r.re.MatchString(c.Title) && !r.re.MatchString(c.Body) {
+			continue
+		}
+		if r.action == Rewrite {
+			mask := func(s string) string { return strings.Repeat("*", len([]rune(s))) }
+			c.Title = r.re.ReplaceAllStringFunc(c.Title, mask)
+			c.Body = r.re.ReplaceAllStringFunc(c.Body, mask)
+		}
+		if severity[r.action] > severity[d.Action] {
+			d = Decision{Action: r.action, Reason: fmt.Sprintf("совпадение с правилом %s", r.re)}
+		}
+		if d.Action == Reject {
+			break
+		}
+	}
+	return d
+}
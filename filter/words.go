@@ -0,0 +1,113 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// homoglyphs приводит латинские буквы и цифры, похожие на кириллические,
+// к кириллице, чтобы «xyй» и «хуй» считались одним словом.
+var homoglyphs = map[rune]rune{
+	'a': 'а', 'b': 'в', 'c': 'с', 'e': 'е', 'h': 'н', 'k': 'к', 'm': 'м',
+	'o': 'о', 'p': 'р', 't': 'т', 'x': 'х', 'y': 'у',
+	'0': 'о', '3': 'з', '4': 'ч', '6': 'б', '@': 'а',
+	'ё': 'е',
+}
+
+// Normalize приводит слово к каноническому виду: нижний регистр, ё → е,
+// латинские двойники → кириллица, повторяющиеся буквы схлопываются.
+func Normalize(word string) string {
+	var b strings.Builder
+	var prev rune
+	for _, r := range strings.ToLower(word) {
+		if g, ok := homoglyphs[r]; ok {
+			r = g
+		}
+		if r == prev {
+			continue
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+	return b.String()
+}
+
+// isWordRune — символы, из которых состоит слово; цифры и @ входят в слово,
+// так как часто используются для маскировки букв.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '@'
+}
+
+// BannedWords отклоняет или маскирует контент, содержащий запрещённые слова.
+// Слово считается запрещённым, если его нормализованная форма начинается
+// с нормализованной формы слова из списка — так ловятся словоформы.
+type BannedWords struct {
+	action Action
+	words  []string
+}
+
+// NewBannedWords создает фильтр; action — Reject, Flag или Rewrite (маскирование).
+func NewBannedWords(words []string, action Action) *BannedWords {
+	f := &BannedWords{action: action}
+	for _, w := range words {
+		if w = strings.TrimSpace(w); w != "" {
+			f.words = append(f.words, Normalize(w))
+		}
+	}
+	return f
+}
+
+func (f *BannedWords) Name() string { return "banned_words" }
+
+func (f *BannedWords) Check(c *Content) Decision {
+	var found []string
+	c.Title, found = f.scan(c.Title, found)
+	c.Body, found = f.scan(c.Body, found)
+	if len(found) == 0 {
+		return Decision{Action: Allow}
+	}
+	return Decision{
+		Action: f.action,
+		Reason: fmt.Sprintf("запрещённые слова: %s", strings.Join(found, ", ")),
+	}
+}
+
+// scan ищет запрещённые слова в тексте и, если фильтр работает в режиме
+// Rewrite, заменяет их звёздочками.
+func (f *BannedWords) scan(text string, found []string) (string, []string) {
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		if !isWordRune(runes[i]) {
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && isWordRune(runes[j]) {
+			j++
+		}
+		word := string(runes[i:j])
+		if f.banned(Normalize(word)) {
+			found = append(found, word)
+			if f.action == Rewrite {
+				for k := i; k < j; k++ {
+					runes[k] = '*'
+				}
+			}
+		}
+		i = j
+	}
+	if f.action == Rewrite {
+		return string(runes), found
+	}
+	return text, found
+}
+
+func (f *BannedWords) banned(norm string) bool {
+	for _, w := range f.words {
+		if strings.HasPrefix(norm, w) {
+			return true
+		}
+	}
+	return false
+}
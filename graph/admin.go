@@ -0,0 +1,63 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"ozon_test/auth"
+	"ozon_test/filter"
+	"ozon_test/graph/model"
+	"ozon_test/service"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// requireAdmin пропускает только запросы с действительным API-ключом.
+// Отказ возвращается с кодом в extensions, чтобы клиенты отличали его
+// от остальных ошибок.
+func requireAdmin(ctx context.Context) error {
+	err := auth.Require(ctx)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, auth.ErrDisabled):
+		return &gqlerror.Error{Message: err.Error(), Extensions: map[string]any{"code": service.CodeForbidden}}
+	default:
+		return &gqlerror.Error{Message: err.Error(), Extensions: map[string]any{"code": service.CodeUnauthenticated}}
+	}
+}
+
+// requireAuthor возвращает автора, чей ключ предъявлен в запросе. Имя
+// берется только из ключа: аргументом его можно было бы подставить чужое.
+func requireAuthor(ctx context.Context) (string, error) {
+	author, err := auth.RequireAuthor(ctx)
+	if err != nil {
+		return "", &gqlerror.Error{Message: err.Error(), Extensions: map[string]any{"code": service.CodeUnauthenticated}}
+	}
+	return author, nil
+}
+
+// viewer возвращает автора запроса для показа его неопубликованных постов;
+// nil — анонимный запрос.
+func viewer(ctx context.Context) *string {
+	if author, ok := auth.Author(ctx); ok {
+		return &author
+	}
+	return nil
+}
+
+// bans возвращает список блокировок пайплайна фильтров.
+func (r *Resolver) bans() (*filter.Bans, error) {
+	if r.Filter == nil || r.Filter.Bans() == nil {
+		return nil, errors.New("блокировка авторов не подключена")
+	}
+	return r.Filter.Bans(), nil
+}
+
+// bannedUser преобразует блокировку в GraphQL-модель.
+func bannedUser(ban filter.Ban) *model.BannedUser {
+	return &model.BannedUser{
+		Author:   ban.Author,
+		Reason:   ban.Reason,
+		BannedAt: ban.BannedAt,
+	}
+}
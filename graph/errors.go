@@ -0,0 +1,48 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"ozon_test/auth"
+	"ozon_test/logging"
+	"ozon_test/service"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// ErrorCode возвращает код ошибки резолвера: из extensions.code, для
+// ошибок авторизации или по ошибке сервиса (service.ErrorCode).
+func ErrorCode(err error) string {
+	var gqlErr *gqlerror.Error
+	if errors.As(err, &gqlErr) {
+		if code, ok := gqlErr.Extensions["code"].(string); ok {
+			return code
+		}
+	}
+
+	switch {
+	case errors.Is(err, auth.ErrUnauthorized), errors.Is(err, auth.ErrNoAuthor):
+		return service.CodeUnauthenticated
+	case errors.Is(err, auth.ErrDisabled):
+		return service.CodeForbidden
+	}
+	return service.ErrorCode(err)
+}
+
+// ErrorPresenter дополняет logging.ErrorPresenter кодом ошибки резолвера в
+// extensions.code, если его еще нет; внутренние ошибки кода не получают,
+// как и раньше.
+func ErrorPresenter(ctx context.Context, err error) *gqlerror.Error {
+	gqlErr := logging.ErrorPresenter(ctx, err)
+	if _, ok := gqlErr.Extensions["code"]; ok {
+		return gqlErr
+	}
+	if code := ErrorCode(err); code != service.CodeInternal {
+		if gqlErr.Extensions == nil {
+			gqlErr.Extensions = map[string]any{}
+		}
+		gqlErr.Extensions["code"] = code
+	}
+	return gqlErr
+}
@@ -0,0 +1,22 @@
+package graph
+
+import (
+	"errors"
+
+	"ozon_test/events"
+	"ozon_test/service"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// subscriptionError дополняет ошибку устаревшего курсора кодом в extensions,
+// чтобы клиент мог отличить её и переподписаться без since.
+func subscriptionError(err error) error {
+	if errors.Is(err, events.ErrCursorExpired) {
+		return &gqlerror.Error{
+			Message:    err.Error(),
+			Extensions: map[string]any{"code": service.CodeCursorExpired},
+		}
+	}
+	return err
+}
@@ -0,0 +1,91 @@
+package model
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cursor — позиция события в потоке брокера. Epoch идентифицирует запуск
+// сервера: номера событий разных запусков несравнимы. Курсор нового
+// комментария несет еще и его позицию в хранилище (CreatedAt, CommentID):
+// по ней пропущенные комментарии дочитываются из хранилища, когда брокер
+// их уже не хранит, например после перезапуска.
+type Cursor struct {
+	Epoch     int64
+	Seq       uint64
+	CreatedAt time.Time
+	CommentID string
+}
+
+// String кодирует курсор строкой «epoch-seq», а с позицией комментария —
+// «epoch-seq-микросекунды-id».
+func (c Cursor) String() string {
+	if c.CommentID == "" {
+		return fmt.Sprintf("%d-%d", c.Epoch, c.Seq)
+	}
+	return fmt.Sprintf("%d-%d-%d-%s", c.Epoch, c.Seq, c.CreatedAt.UnixMicro(), c.CommentID)
+}
+
+// ParseCursor разбирает курсор из строкового представления «epoch-seq» или
+// «epoch-seq-микросекунды-id».
+func ParseCursor(s string) (Cursor, error) {
+	parts := strings.SplitN(s, "-", 4)
+	if len(parts) != 2 && len(parts) != 4 {
+		return Cursor{}, fmt.Errorf("некорректный курсор %q", s)
+	}
+	e, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("некорректный курсор %q", s)
+	}
+	n, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("некорректный курсор %q", s)
+	}
+	c := Cursor{Epoch: e, Seq: n}
+	if len(parts) == 4 {
+		micros, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil || parts[3] == "" {
+			return Cursor{}, fmt.Errorf("некорректный курсор %q", s)
+		}
+		c.CreatedAt, c.CommentID = time.UnixMicro(micros).UTC(), parts[3]
+	}
+	return c, nil
+}
+
+// UnmarshalGQL реализует graphql.Unmarshaler.
+func (c *Cursor) UnmarshalGQL(v any) error {
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("курсор должен быть строкой")
+	}
+	parsed, err := ParseCursor(s)
+	if err != nil {
+		return err
+	}
+	*c = parsed
+	return nil
+}
+
+// MarshalGQL реализует graphql.Marshaler.
+func (c Cursor) MarshalGQL(w io.Writer) {
+	_, _ = io.WriteString(w, strconv.Quote(c.String()))
+}
+
+// MarshalText кодирует курсор той же строкой, что и в GraphQL; нужен
+// клиентам, которые разбирают ответы через encoding/json.
+func (c Cursor) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText разбирает курсор из строки, как ParseCursor.
+func (c *Cursor) UnmarshalText(text []byte) error {
+	parsed, err := ParseCursor(string(text))
+	if err != nil {
+		return err
+	}
+	*c = parsed
+	return nil
+}
@@ -2,13 +2,72 @@
 
 package model
 
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+type PostEvent interface {
+	IsPostEvent()
+}
+
+// Заблокированный автор: его новые посты и комментарии отклоняются.
+type BannedUser struct {
+	Author   string    `json:"author"`
+	Reason   string    `json:"reason"`
+	BannedAt time.Time `json:"bannedAt"`
+}
+
 type Comment struct {
-	ID        string  `json:"id"`
-	PostID    string  `json:"postId"`
-	ParentID  *string `json:"parentId,omitempty"`
-	Author    string  `json:"author"`
-	Content   string  `json:"content"`
-	CreatedAt string  `json:"createdAt"`
+	ID        string    `json:"id"`
+	PostID    string    `json:"postId"`
+	ParentID  *string   `json:"parentId,omitempty"`
+	Author    string    `json:"author"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Курсор события; заполняется только в подписках.
+	Cursor *Cursor `json:"cursor,omitempty"`
+}
+
+type CommentAddedEvent struct {
+	Cursor  Cursor   `json:"cursor"`
+	Comment *Comment `json:"comment"`
+}
+
+func (CommentAddedEvent) IsPostEvent() {}
+
+type CommentDeletedEvent struct {
+	Cursor    Cursor `json:"cursor"`
+	PostID    string `json:"postId"`
+	CommentID string `json:"commentId"`
+}
+
+func (CommentDeletedEvent) IsPostEvent() {}
+
+type CommentUpdatedEvent struct {
+	Cursor  Cursor   `json:"cursor"`
+	Comment *Comment `json:"comment"`
+}
+
+func (CommentUpdatedEvent) IsPostEvent() {}
+
+type ModerationDecision struct {
+	Filter string           `json:"filter"`
+	Action ModerationAction `json:"action"`
+	Reason string           `json:"reason"`
+}
+
+type ModerationRecord struct {
+	ID        string                `json:"id"`
+	TargetID  string                `json:"targetId"`
+	PostID    *string               `json:"postId,omitempty"`
+	Kind      string                `json:"kind"`
+	Author    string                `json:"author"`
+	Action    ModerationAction      `json:"action"`
+	Decisions []*ModerationDecision `json:"decisions"`
+	CreatedAt time.Time             `json:"createdAt"`
 }
 
 type Mutation struct {
@@ -20,12 +79,129 @@ type Post struct {
 	Content         string     `json:"content"`
 	Author          string     `json:"author"`
 	CommentsAllowed bool       `json:"commentsAllowed"`
-	CreatedAt       string     `json:"createdAt"`
-	Comments        []*Comment `json:"comments,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	Status          PostStatus `json:"status"`
+	// Время публикации: запланированное у SCHEDULED, фактическое у опубликованных; у черновиков null.
+	PublishAt *time.Time `json:"publishAt,omitempty"`
+	Comments  []*Comment `json:"comments,omitempty"`
+}
+
+type PostCommentsToggledEvent struct {
+	Cursor          Cursor `json:"cursor"`
+	PostID          string `json:"postId"`
+	CommentsAllowed bool   `json:"commentsAllowed"`
 }
 
+func (PostCommentsToggledEvent) IsPostEvent() {}
+
 type Query struct {
 }
 
+// Сводка для администраторов.
+type Stats struct {
+	Posts int `json:"posts"`
+	// Посты, закрытые для комментариев.
+	LockedPosts int `json:"lockedPosts"`
+	Comments    int `json:"comments"`
+	BannedUsers int `json:"bannedUsers"`
+	// Активные подписки.
+	Subscriptions int `json:"subscriptions"`
+}
+
+// Подписка, не успевающая читать события, завершается сервером, чтобы не
+// пропускать события молча; клиент переподключается с курсором последнего
+// полученного события.
 type Subscription struct {
-}
\ No newline at end of file
+}
+
+type ModerationAction string
+
+const (
+	ModerationActionAllow   ModerationAction = "ALLOW"
+	ModerationActionRewrite ModerationAction = "REWRITE"
+	ModerationActionFlag    ModerationAction = "FLAG"
+	ModerationActionReject  ModerationAction = "REJECT"
+)
+
+var AllModerationAction = []ModerationAction{
+	ModerationActionAllow,
+	ModerationActionRewrite,
+	ModerationActionFlag,
+	ModerationActionReject,
+}
+
+func (e ModerationAction) IsValid() bool {
+	switch e {
+	case ModerationActionAllow, ModerationActionRewrite, ModerationActionFlag, ModerationActionReject:
+		return true
+	}
+	return false
+}
+
+func (e ModerationAction) String() string {
+	return string(e)
+}
+
+func (e *ModerationAction) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ModerationAction(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ModerationAction", str)
+	}
+	return nil
+}
+
+func (e ModerationAction) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+// Этап жизни поста. Черновики и запланированные посты видны только автору,
+// архивные не попадают в общий список posts.
+type PostStatus string
+
+const (
+	PostStatusDraft     PostStatus = "DRAFT"
+	PostStatusScheduled PostStatus = "SCHEDULED"
+	PostStatusPublished PostStatus = "PUBLISHED"
+	PostStatusArchived  PostStatus = "ARCHIVED"
+)
+
+var AllPostStatus = []PostStatus{
+	PostStatusDraft,
+	PostStatusScheduled,
+	PostStatusPublished,
+	PostStatusArchived,
+}
+
+func (e PostStatus) IsValid() bool {
+	switch e {
+	case PostStatusDraft, PostStatusScheduled, PostStatusPublished, PostStatusArchived:
+		return true
+	}
+	return false
+}
+
+func (e PostStatus) String() string {
+	return string(e)
+}
+
+func (e *PostStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = PostStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid PostStatus", str)
+	}
+	return nil
+}
+
+func (e PostStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
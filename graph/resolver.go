@@ -5,110 +5,240 @@ package graph
 
 import (
 	"context"
-	"errors"
+	"strings"
 	"time"
 
+	"ozon_test/filter"
 	"ozon_test/graph/model"
-	"ozon_test/storage"
-
-	"github.com/google/uuid"
+	"ozon_test/service"
 )
 
-type Resolver struct{}
+type Resolver struct {
+	// PostService — операции с постами.
+	PostService *service.PostService
+	// CommentService — операции с комментариями.
+	CommentService *service.CommentService
+	// Filter — журнал модерации и блокировки авторов для административных
+	// операций; nil — отключены. Проверку контента выполняют сервисы.
+	Filter *filter.Pipeline
+}
 
 // Создание нового поста
 func (r *mutationResolver) CreatePost(ctx context.Context, title string, content string, author string, commentsAllowed bool) (*model.Post, error) {
-	post := &model.Post{
-		ID:              uuid.New().String(),
-		Title:           title,
-		Content:         content,
-		Author:          author,
-		CommentsAllowed: commentsAllowed,
-		CreatedAt:       time.Now().Format(time.RFC3339),
+	return r.PostService.Create(ctx, title, content, author, commentsAllowed)
+}
+
+// Добавление комментария
+func (r *mutationResolver) AddComment(ctx context.Context, postID string, parentID *string, author, content string) (*model.Comment, error) {
+	return r.CommentService.Add(ctx, postID, parentID, author, content)
+}
+
+// Редактирование комментария (только администраторы)
+func (r *mutationResolver) UpdateComment(ctx context.Context, id string, content string) (*model.Comment, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
 	}
+	return r.CommentService.Update(ctx, id, content)
+}
 
-	err := storage.DB.CreatePost(post)
+// Удаление комментария вместе с ответами на него (только администраторы)
+func (r *mutationResolver) DeleteComment(ctx context.Context, id string) (bool, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return false, err
+	}
+	if err := r.CommentService.Delete(ctx, id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Включение и отключение комментариев к посту (только администраторы)
+func (r *mutationResolver) SetCommentsAllowed(ctx context.Context, postID string, allowed bool) (*model.Post, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return r.PostService.SetCommentsAllowed(ctx, postID, allowed)
+}
+
+// Создание или правка черновика поста (от имени автора по ключу)
+func (r *mutationResolver) SavePostDraft(ctx context.Context, id *string, title string, content string, commentsAllowed bool) (*model.Post, error) {
+	author, err := requireAuthor(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return post, nil
+	return r.PostService.SaveDraft(ctx, id, title, content, author, commentsAllowed)
 }
 
-// Добавление комментария
-func (r *mutationResolver) AddComment(ctx context.Context, postID string, parentID *string, author, content string) (*model.Comment, error) {
-	post, err := storage.DB.GetPostByID(postID)
+// Немедленная публикация черновика
+func (r *mutationResolver) PublishPost(ctx context.Context, id string) (*model.Post, error) {
+	author, err := requireAuthor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.PostService.Publish(ctx, id, author)
+}
 
-	if err != nil || post == nil {
-		return nil, errors.New("пост не найден")
+// Планирование публикации черновика
+func (r *mutationResolver) SchedulePost(ctx context.Context, id string, publishAt time.Time) (*model.Post, error) {
+	author, err := requireAuthor(ctx)
+	if err != nil {
+		return nil, err
 	}
+	return r.PostService.Schedule(ctx, id, author, publishAt)
+}
 
-	if !post.CommentsAllowed {
-		return nil, errors.New("комментарии к этому посту запрещены")
+// Перенос опубликованного поста в архив
+func (r *mutationResolver) ArchivePost(ctx context.Context, id string) (*model.Post, error) {
+	author, err := requireAuthor(ctx)
+	if err != nil {
+		return nil, err
 	}
-	if len(content) > 2000 {
-		return nil, errors.New("Комментарий слишком длинный (максимум 2000 символов)")
+	return r.PostService.Archive(ctx, id, author)
+}
+
+// Удаление поста вместе с комментариями (только администраторы)
+func (r *mutationResolver) DeletePost(ctx context.Context, id string) (bool, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return false, err
+	}
+	if err := r.PostService.Delete(ctx, id); err != nil {
+		return false, err
 	}
+	return true, nil
+}
 
-	comment := &model.Comment{
-		ID:        uuid.New().String(),
-		PostID:    postID,
-		ParentID:  parentID,
-		Author:    author,
-		Content:   content,
-		CreatedAt: time.Now().Format(time.RFC3339),
+// Блокировка автора: его новые посты и комментарии отклоняются (только администраторы)
+func (r *mutationResolver) BanUser(ctx context.Context, author string, reason *string) (*model.BannedUser, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	bans, err := r.bans()
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(author) == "" {
+		return nil, service.InvalidInput("автор не указан")
 	}
 
-	err = storage.DB.CreateComment(comment)
+	var why string
+	if reason != nil {
+		why = *reason
+	}
+	ban, err := bans.Ban(ctx, author, why)
 	if err != nil {
 		return nil, err
 	}
+	return bannedUser(ban), nil
+}
 
-	return comment, nil
+// Снятие блокировки автора (только администраторы)
+func (r *mutationResolver) UnbanUser(ctx context.Context, author string) (bool, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return false, err
+	}
+	bans, err := r.bans()
+	if err != nil {
+		return false, err
+	}
+	return bans.Unban(ctx, author)
 }
 
 // Получение всех постов
 func (r *queryResolver) Posts(ctx context.Context) ([]*model.Post, error) {
-	return storage.DB.GetAllPosts()
+	return r.PostService.List(ctx, viewer(ctx))
 }
 
 // Получение поста по ID
 func (r *queryResolver) Post(ctx context.Context, id string) (*model.Post, error) {
-	post, err := storage.DB.GetPostByID(id)
-	if err != nil || post == nil {
-		return nil, errors.New("пост не найден")
+	return r.PostService.Get(ctx, id, viewer(ctx))
+}
+
+// Получение комментариев к посту с поддержкой пагинации
+func (r *queryResolver) Comments(ctx context.Context, postID string, limit int, offset int) ([]*model.Comment, error) {
+	return r.CommentService.List(ctx, postID, limit, offset)
+}
+
+// Журнал решений фильтров контента для модераторов (только администраторы)
+func (r *queryResolver) ModerationLog(ctx context.Context, limit int, offset int) ([]*model.ModerationRecord, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if limit < 0 || offset < 0 {
+		return nil, service.InvalidInput("limit и offset не могут быть отрицательными")
+	}
+	if r.Filter == nil || r.Filter.Journal() == nil {
+		return []*model.ModerationRecord{}, nil
 	}
 
-	// Загрузка комментариев к посту
-	comments, err := storage.DB.GetCommentsByPostID(id, 10, 0)
+	records, err := r.Filter.Journal().List(ctx, limit, offset)
 	if err != nil {
 		return nil, err
 	}
-	post.Comments = comments // Добавляем комментарии к объекту поста
+	result := make([]*model.ModerationRecord, 0, len(records))
+	for _, rec := range records {
+		result = append(result, rec.Model())
+	}
+	return result, nil
+}
+
+// Сводка по хранилищу, блокировкам и подпискам (только администраторы)
+func (r *queryResolver) Stats(ctx context.Context) (*model.Stats, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
 
-	return post, nil
+	stats, err := r.PostService.Stats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if bans, err := r.bans(); err == nil {
+		stats.BannedUsers = bans.Len()
+	}
+	return stats, nil
 }
 
-// Получение комментариев к посту с поддержкой пагинации
-func (r *queryResolver) Comments(ctx context.Context, postID string, limit int, offset int) ([]*model.Comment, error) {
-	return storage.DB.GetCommentsByPostID(postID, limit, offset)
+// Список заблокированных авторов, начиная с последнего (только администраторы)
+func (r *queryResolver) BannedUsers(ctx context.Context) ([]*model.BannedUser, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	bans, err := r.bans()
+	if err != nil {
+		return []*model.BannedUser{}, nil
+	}
+
+	list := bans.List()
+	result := make([]*model.BannedUser, 0, len(list))
+	for _, ban := range list {
+		result = append(result, bannedUser(ban))
+	}
+	return result, nil
 }
 
-// Поддержка подписки на новые комментарии (GraphQL Subscriptions)
-func (r *subscriptionResolver) CommentAdded(ctx context.Context, postID string) (<-chan *model.Comment, error) {
-	commentChan := make(chan *model.Comment, 1)
+// Поддержка подписки на новые комментарии (GraphQL Subscriptions).
+// С since (или Last-Event-ID в SSE) сначала досылаются комментарии,
+// пропущенные после этого курсора.
+func (r *subscriptionResolver) CommentAdded(ctx context.Context, postID string, since *model.Cursor) (<-chan *model.Comment, error) {
+	comments, err := r.CommentService.Watch(ctx, postID, since)
+	if err != nil {
+		return nil, subscriptionError(err)
+	}
+	return comments, nil
+}
 
-	go func() {
-		for {
-			// Ожидаем новый комментарий в БД
-			time.Sleep(1 * time.Second)
-			comments, _ := storage.DB.GetCommentsByPostID(postID, 1, 0) // Получаем последний комментарий
-			if len(comments) > 0 {
-				commentChan <- comments[0] // Отправляем реальный комментарий
-			}
-		}
-	}()
+// Подписка на все события поста: новые, изменённые и удалённые комментарии,
+// переключение режима «только для чтения»
+func (r *subscriptionResolver) PostEvents(ctx context.Context, postID string, since *model.Cursor) (<-chan model.PostEvent, error) {
+	postEvents, err := r.PostService.WatchEvents(ctx, postID, since)
+	if err != nil {
+		return nil, subscriptionError(err)
+	}
+	return postEvents, nil
+}
 
-	return commentChan, nil
+// Подписка на новые посты, опционально только от указанного автора
+func (r *subscriptionResolver) PostCreated(ctx context.Context, author *string) (<-chan *model.Post, error) {
+	return r.PostService.WatchCreated(ctx, author), nil
 }
 
 func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
@@ -0,0 +1,8 @@
+// Package postpb — сообщения и gRPC-заглушки PostService, сгенерированные
+// по post.proto. После изменения post.proto (нужны protoc, protoc-gen-go и
+// protoc-gen-go-grpc):
+//
+//	go generate ./grpcapi/postpb
+package postpb
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative grpcapi/postpb/post.proto
@@ -0,0 +1,257 @@
+// Package grpcapi — gRPC-сервер PostService (grpcapi/postpb/post.proto) для
+// внутренних сервисов. Как GraphQL и REST-шлюз, он вызывает пакет service,
+// поэтому хранилище, брокер событий, проверки и фильтры контента у всех API
+// общие.
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"ozon_test/graph/model"
+	"ozon_test/grpcapi/postpb"
+	"ozon_test/service"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain — домен в errdetails.ErrorInfo; Reason содержит тот же код,
+// что extensions.code в GraphQL.
+const ErrorDomain = "ozon_test"
+
+// Server реализует postpb.PostServiceServer поверх сервисов постов и
+// комментариев.
+type Server struct {
+	postpb.UnimplementedPostServiceServer
+	posts    *service.PostService
+	comments *service.CommentService
+}
+
+// NewServer создает реализацию PostService.
+func NewServer(posts *service.PostService, comments *service.CommentService) *Server {
+	return &Server{posts: posts, comments: comments}
+}
+
+// New создает grpc.Server с зарегистрированным PostService и логированием
+// вызовов.
+func New(posts *service.PostService, comments *service.CommentService, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append([]grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(logUnary),
+		grpc.ChainStreamInterceptor(logStream),
+	}, opts...)
+	s := grpc.NewServer(opts...)
+	postpb.RegisterPostServiceServer(s, NewServer(posts, comments))
+	return s
+}
+
+func (s *Server) CreatePost(ctx context.Context, req *postpb.CreatePostRequest) (*postpb.Post, error) {
+	post, err := s.posts.Create(ctx, req.GetTitle(), req.GetContent(), req.GetAuthor(), req.GetCommentsAllowed())
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return toPost(post), nil
+}
+
+func (s *Server) GetPost(ctx context.Context, req *postpb.GetPostRequest) (*postpb.Post, error) {
+	post, err := s.posts.Get(ctx, req.GetId(), nil)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return toPost(post), nil
+}
+
+func (s *Server) ListPosts(ctx context.Context, _ *postpb.ListPostsRequest) (*postpb.ListPostsResponse, error) {
+	posts, err := s.posts.List(ctx, nil)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	resp := &postpb.ListPostsResponse{Posts: make([]*postpb.Post, 0, len(posts))}
+	for _, post := range posts {
+		resp.Posts = append(resp.Posts, toPost(post))
+	}
+	return resp, nil
+}
+
+func (s *Server) AddComment(ctx context.Context, req *postpb.AddCommentRequest) (*postpb.Comment, error) {
+	comment, err := s.comments.Add(ctx, req.GetPostId(), req.ParentId, req.GetAuthor(), req.GetContent())
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return toComment(comment), nil
+}
+
+func (s *Server) ListComments(ctx context.Context, req *postpb.ListCommentsRequest) (*postpb.ListCommentsResponse, error) {
+	// Как в REST: для неизвестного поста NOT_FOUND, а не пустой список
+	if _, err := s.posts.Get(ctx, req.GetPostId(), nil); err != nil {
+		return nil, statusError(ctx, err)
+	}
+	comments, err := s.comments.List(ctx, req.GetPostId(), int(req.GetLimit()), int(req.GetOffset()))
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	resp := &postpb.ListCommentsResponse{Comments: make([]*postpb.Comment, 0, len(comments))}
+	for _, comment := range comments {
+		resp.Comments = append(resp.Comments, toComment(comment))
+	}
+	return resp, nil
+}
+
+func (s *Server) WatchComments(req *postpb.WatchCommentsRequest, stream grpc.ServerStreamingServer[postpb.CommentEvent]) error {
+	ctx := stream.Context()
+
+	var since *model.Cursor
+	if req.GetSince() != "" {
+		cursor, err := model.ParseCursor(req.GetSince())
+		if err != nil {
+			return statusError(ctx, &service.InputError{Message: err.Error()})
+		}
+		since = &cursor
+	}
+	if _, err := s.posts.Get(ctx, req.GetPostId(), nil); err != nil {
+		return statusError(ctx, err)
+	}
+	comments, err := s.comments.Watch(ctx, req.GetPostId(), since)
+	if err != nil {
+		return statusError(ctx, err)
+	}
+	// Заголовки сразу после подписки: клиент может дождаться их через
+	// Header() и не пропустить комментарии, добавленные следом
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for comment := range comments {
+		event := &postpb.CommentEvent{Comment: toComment(comment)}
+		if comment.Cursor != nil {
+			event.Cursor = comment.Cursor.String()
+		}
+		if err := stream.Send(event); err != nil {
+			return err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+	// Канал закрыт без отмены вызова: брокер остановлен вместе с сервером
+	return status.Error(codes.Unavailable, "сервер останавливается")
+}
+
+// grpcCode сопоставляет код ошибки сервиса коду gRPC.
+func grpcCode(code string) codes.Code {
+	switch code {
+	case service.CodeNotFound:
+		return codes.NotFound
+	case service.CodeBadUserInput, service.CodeContentRejected:
+		return codes.InvalidArgument
+	case service.CodeCommentsDisabled:
+		return codes.FailedPrecondition
+	case service.CodeUnauthenticated:
+		return codes.Unauthenticated
+	case service.CodeForbidden:
+		return codes.PermissionDenied
+	case service.CodeCursorExpired:
+		return codes.OutOfRange
+	}
+	return codes.Internal
+}
+
+// statusError преобразует ошибку сервиса в статус gRPC. Код ошибки
+// передается в деталях ErrorInfo: по нему клиент отличит, например,
+// отклоненный фильтром контент от некорректного запроса. Текст внутренних
+// ошибок клиенту не показывается.
+func statusError(ctx context.Context, err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+
+	code := service.ErrorCode(err)
+	if code == service.CodeInternal {
+		slog.ErrorContext(ctx, "grpc request failed", slog.Any("error", err))
+		return status.Error(codes.Internal, "внутренняя ошибка сервера")
+	}
+	st := status.New(grpcCode(code), err.Error())
+	if detailed, derr := st.WithDetails(&errdetails.ErrorInfo{Reason: code, Domain: ErrorDomain}); derr == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// ErrorCode возвращает код ошибки из деталей статуса gRPC (NOT_FOUND,
+// CONTENT_REJECTED и т.д.) или пустую строку.
+func ErrorCode(err error) string {
+	st, ok := status.FromError(err)
+	if !ok {
+		return ""
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.GetDomain() == ErrorDomain {
+			return info.GetReason()
+		}
+	}
+	return ""
+}
+
+func toPost(post *model.Post) *postpb.Post {
+	pb := &postpb.Post{
+		Id:              post.ID,
+		Title:           post.Title,
+		Content:         post.Content,
+		Author:          post.Author,
+		CommentsAllowed: post.CommentsAllowed,
+		CreatedAt:       timestamp(post.CreatedAt),
+	}
+	for _, comment := range post.Comments {
+		pb.Comments = append(pb.Comments, toComment(comment))
+	}
+	return pb
+}
+
+func toComment(comment *model.Comment) *postpb.Comment {
+	return &postpb.Comment{
+		Id:        comment.ID,
+		PostId:    comment.PostID,
+		ParentId:  comment.ParentID,
+		Author:    comment.Author,
+		Content:   comment.Content,
+		CreatedAt: timestamp(comment.CreatedAt),
+	}
+}
+
+// timestamp кодирует время так же, как скаляр Time в GraphQL.
+func timestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// logUnary пишет в лог каждый вызов: метод, длительность и код ответа.
+func logUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	logCall(ctx, info.FullMethod, start, err)
+	return resp, err
+}
+
+func logStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	logCall(ss.Context(), info.FullMethod, start, err)
+	return err
+}
+
+func logCall(ctx context.Context, method string, start time.Time, err error) {
+	code := status.Code(err)
+	level := slog.LevelInfo
+	if code != codes.OK && code != codes.Canceled {
+		level = slog.LevelWarn
+	}
+	slog.Log(ctx, level, "grpc call",
+		slog.String("method", method),
+		slog.Duration("duration", time.Since(start)),
+		slog.String("code", code.String()),
+	)
+}
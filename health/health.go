@@ -0,0 +1,135 @@
+// Package health реализует эндпоинты проверки живости и готовности сервиса.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Check — проверка одной зависимости. Details попадают в подробный ответ.
+type Check struct {
+	Name  string
+	Check func(ctx context.Context) (details map[string]any, err error)
+}
+
+// CheckResult — результат проверки зависимости в подробном ответе.
+type CheckResult struct {
+	Name      string         `json:"name"`
+	Status    string         `json:"status"`
+	LatencyMs float64        `json:"latencyMs"`
+	Error     string         `json:"error,omitempty"`
+	Details   map[string]any `json:"details,omitempty"`
+}
+
+// Report — подробный ответ /readyz.
+type Report struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+const (
+	StatusOK           = "ok"
+	StatusUnavailable  = "unavailable"
+	StatusShuttingDown = "shutting_down"
+)
+
+// Health отвечает на пробы оркестратора: /healthz — процесс жив,
+// /readyz — зависимости доступны и сервер не останавливается.
+type Health struct {
+	timeout      time.Duration
+	checks       []Check
+	shuttingDown atomic.Bool
+}
+
+// New создает набор проверок; timeout ограничивает каждую проверку.
+func New(timeout time.Duration, checks ...Check) *Health {
+	return &Health{timeout: timeout, checks: checks}
+}
+
+// SetShuttingDown переводит /readyz в 503, чтобы балансировщик перестал
+// направлять запросы до завершения остановки.
+func (h *Health) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Liveness — обработчик /healthz: отвечает 200, пока процесс обслуживает запросы.
+func (h *Health) Liveness() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte(StatusOK))
+	})
+}
+
+// Readiness — обработчик /readyz. По умолчанию отвечает текстом статуса;
+// с ?verbose=1 или Accept: application/json возвращает JSON со статусом
+// и задержкой каждой зависимости.
+func (h *Health) Readiness() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := h.Run(r.Context())
+
+		code := http.StatusOK
+		if report.Status != StatusOK {
+			code = http.StatusServiceUnavailable
+		}
+
+		if r.URL.Query().Get("verbose") == "" && !strings.Contains(r.Header.Get("Accept"), "application/json") {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.WriteHeader(code)
+			_, _ = w.Write([]byte(report.Status))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
+
+// Run выполняет все проверки параллельно.
+func (h *Health) Run(ctx context.Context) Report {
+	results := make([]CheckResult, len(h.checks))
+	done := make(chan struct{}, len(h.checks))
+	for i, c := range h.checks {
+		go func() {
+			results[i] = h.run(ctx, c)
+			done <- struct{}{}
+		}()
+	}
+	for range h.checks {
+		<-done
+	}
+
+	report := Report{Status: StatusOK, Checks: results}
+	for _, res := range results {
+		if res.Status != StatusOK {
+			report.Status = StatusUnavailable
+		}
+	}
+	if h.shuttingDown.Load() {
+		report.Status = StatusShuttingDown
+	}
+	return report
+}
+
+func (h *Health) run(ctx context.Context, c Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	details, err := c.Check(ctx)
+	res := CheckResult{
+		Name:      c.Name,
+		Status:    StatusOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		Details:   details,
+	}
+	if err != nil {
+		res.Status = StatusUnavailable
+		res.Error = err.Error()
+	}
+	return res
+}
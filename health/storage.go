@@ -0,0 +1,37 @@
+package health
+
+import (
+	"context"
+
+	"ozon_test/storage"
+)
+
+// StorageCheck проверяет доступность хранилища и, если оно управляется
+// миграциями, сообщает версию схемы. Для PostgreSQL с репликами в деталях
+// показывается, сколько реплик участвует в балансировке; исключённые
+// реплики готовность не снимают — чтения идут на primary.
+func StorageCheck(s storage.Storage) Check {
+	return Check{
+		Name: "storage",
+		Check: func(ctx context.Context) (map[string]any, error) {
+			if err := s.Ping(ctx); err != nil {
+				return nil, err
+			}
+			v, ok := storage.As[storage.Versioned](s)
+			if !ok {
+				return nil, nil
+			}
+			version, err := v.MigrationVersion(ctx)
+			if err != nil {
+				return nil, err
+			}
+			details := map[string]any{"migrationVersion": version}
+			if pg, ok := storage.As[*storage.PostgresStorage](s); ok {
+				if healthy, total := pg.Replicas(); total > 0 {
+					details["replicas"] = map[string]int{"healthy": healthy, "total": total}
+				}
+			}
+			return details, nil
+		},
+	}
+}
@@ -0,0 +1,120 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// redacted заменяет значения переменных: в них могут быть тексты
+// комментариев и другие пользовательские данные.
+const redacted = "[REDACTED]"
+
+// Extension — расширение gqlgen, записывающее в лог каждую операцию:
+// имя, тип, длительность и результат. Значения переменных не логируются.
+// Подписки логируются при начале и завершении.
+type Extension struct {
+	Logger *slog.Logger
+}
+
+var (
+	_ graphql.HandlerExtension     = Extension{}
+	_ graphql.OperationInterceptor = Extension{}
+)
+
+func (Extension) ExtensionName() string { return "OperationLogger" }
+
+func (Extension) Validate(graphql.ExecutableSchema) error { return nil }
+
+func (e Extension) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	opCtx := graphql.GetOperationContext(ctx)
+	logger := e.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	typ, name := "unknown", opCtx.OperationName
+	if opCtx.Operation != nil {
+		typ = string(opCtx.Operation.Operation)
+		if name == "" {
+			name = opCtx.Operation.Name
+		}
+	}
+	attrs := []any{
+		slog.String("operation", name),
+		slog.String("type", typ),
+		slog.Any("variables", redactVariables(opCtx.Variables)),
+	}
+
+	start := opCtx.Stats.OperationStart
+	handler := next(ctx)
+
+	if typ == string(ast.Subscription) {
+		logger.InfoContext(ctx, "graphql subscription started", attrs...)
+		return func(ctx context.Context) *graphql.Response {
+			resp := handler(ctx)
+			if resp == nil {
+				logger.InfoContext(ctx, "graphql subscription completed",
+					append(attrs, slog.Duration("duration", time.Since(start)))...)
+			} else if len(resp.Errors) > 0 {
+				logger.WarnContext(ctx, "graphql subscription error",
+					append(attrs, slog.String("error", resp.Errors.Error()))...)
+			}
+			return resp
+		}
+	}
+
+	return func(ctx context.Context) *graphql.Response {
+		resp := handler(ctx)
+		attrs := append(attrs, slog.Duration("duration", time.Since(start)))
+		if resp != nil && len(resp.Errors) > 0 {
+			logger.WarnContext(ctx, "graphql operation failed",
+				append(attrs, slog.String("outcome", "error"), slog.String("error", resp.Errors.Error()))...)
+		} else {
+			logger.InfoContext(ctx, "graphql operation", append(attrs, slog.String("outcome", "ok"))...)
+		}
+		return resp
+	}
+}
+
+// redactVariables оставляет имена переменных, скрывая значения.
+func redactVariables(vars map[string]any) map[string]string {
+	if len(vars) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := make(map[string]string, len(vars))
+	for _, name := range names {
+		out[name] = redacted
+	}
+	return out
+}
+
+// ErrorPresenter добавляет ID запроса в extensions каждой GraphQL-ошибки,
+// чтобы клиент мог сослаться на него при обращении в поддержку.
+func ErrorPresenter(ctx context.Context, err error) *gqlerror.Error {
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+	if id := RequestID(ctx); id != "" {
+		if gqlErr.Extensions == nil {
+			gqlErr.Extensions = map[string]any{}
+		}
+		gqlErr.Extensions["requestId"] = id
+	}
+
+	var target *gqlerror.Error
+	if !errors.As(err, &target) {
+		slog.DebugContext(ctx, "graphql resolver error", slog.String("error", err.Error()))
+	}
+	return gqlErr
+}
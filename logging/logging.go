@@ -0,0 +1,66 @@
+// Package logging настраивает структурированное логирование на log/slog:
+// уровень и формат из конфигурации, ID запроса в каждой записи и журнал
+// GraphQL-операций.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"ozon_test/config"
+)
+
+// Setup создает логгер по конфигурации и делает его логгером по умолчанию
+// для slog и стандартного пакета log.
+func Setup(cfg *config.Config, w io.Writer) (*slog.Logger, error) {
+	logger, err := New(cfg.Log.Level, cfg.Log.Format, w)
+	if err != nil {
+		return nil, err
+	}
+	slog.SetDefault(logger)
+	return logger, nil
+}
+
+// New создает логгер с уровнем level (debug, info, warn, error) и форматом
+// format (json или text). Записи дополняются ID запроса из контекста.
+func New(level, format string, w io.Writer) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("неизвестный уровень логирования %q", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	var h slog.Handler
+	switch strings.ToLower(format) {
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	case "text":
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("неизвестный формат логов %q", format)
+	}
+	return slog.New(contextHandler{h}), nil
+}
+
+// contextHandler добавляет в запись ID запроса, сохранённый в контексте.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
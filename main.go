@@ -1,37 +1,317 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"ozon_test/auth"
 	"ozon_test/config"
+	"ozon_test/events"
+	"ozon_test/filter"
 	"ozon_test/graph"
+	"ozon_test/grpcapi"
+	"ozon_test/health"
+	"ozon_test/logging"
+	"ozon_test/metrics"
+	"ozon_test/rest"
+	"ozon_test/service"
+	"ozon_test/sse"
 	"ozon_test/storage"
+	"ozon_test/tracing"
 
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/vektah/gqlparser/v2/ast"
+	"google.golang.org/grpc"
 )
 
 func main() {
-	// Загружаем конфиг
-	cfg := config.LoadConfig()
-	// Инициализируем хранилище
-	storage.InitStorage(cfg)
+	args := os.Args[1:]
+	if len(args) > 0 {
+		switch args[0] {
+		case "config":
+			os.Exit(configCommand(args[1:]))
+		case "export":
+			os.Exit(exportCommand(args[1:]))
+		case "import":
+			os.Exit(importCommand(args[1:]))
+		case "migrate-data":
+			os.Exit(migrateDataCommand(args[1:]))
+		}
+	}
+	if len(args) > 0 && args[0] == "serve" {
+		args = args[1:]
+	}
+
+	// Загружаем конфиг: файл, окружение и флаги
+	cfg := loadConfig("serve", args)
+	// Структурированные логи; ID запроса попадает в каждую запись
+	if _, err := logging.Setup(cfg, os.Stderr); err != nil {
+		fatal("Ошибка настройки логирования", err)
+	}
 
-	// GraphQL-сервер
-	srv := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{}}))
+	// Контекст фоновых задач; отменяется при получении SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	// Открываем хранилище; PostgreSQL ждем до storage.connectTimeout
+	store, err := storage.Open(ctx, cfg.Storage)
+	if err != nil {
+		fatal("Ошибка инициализации хранилища", err)
+	}
+
+	// Метрики Prometheus; вызовы хранилища измеряются декоратором
+	promMetrics := metrics.New()
+	if pg, ok := storage.As[*storage.PostgresStorage](store); ok {
+		promMetrics.RegisterDBStats(pg.DB, "postgres")
+		for i, replica := range pg.ReplicaDBs() {
+			promMetrics.RegisterDBStats(replica, fmt.Sprintf("postgres_replica%d", i))
+		}
+	}
+	if lite, ok := storage.As[*storage.SQLiteStorage](store); ok {
+		promMetrics.RegisterDBStats(lite.DB, "sqlite")
+	}
+	store = promMetrics.InstrumentStorage(store)
+
+	// Трассировка OpenTelemetry
+	shutdownTracing, err := tracing.Setup(ctx, cfg)
+	if err != nil {
+		fatal("Ошибка настройки трассировки", err)
+	}
+
+	// Блокировки авторов хранятся в хранилище: переживают перезапуск и
+	// общие для всех экземпляров
+	bans := filter.NewBans()
+	if banStore, ok := storage.As[storage.BanStore](store); ok {
+		if bans, err = filter.NewStoredBans(ctx, banStore); err != nil {
+			fatal("Ошибка загрузки блокировок авторов", err)
+		}
+		go bans.Run(ctx, cfg.Filter.BansRefresh)
+	}
+
+	// Журнал модерации хранится там же, где блокировки
+	journal := filter.NewJournal(0)
+	if logStore, ok := storage.As[storage.ModerationLogStore](store); ok {
+		journal = filter.NewStoredJournal(logStore, 0)
+	}
+
+	// Фильтры контента для новых постов и комментариев
+	contentFilter, err := filter.NewPipelineFromConfig(cfg, bans, journal)
+	if err != nil {
+		fatal("Ошибка настройки фильтров контента", err)
+	}
+
+	// Брокер событий для подписок
+	broker := events.NewBroker(events.Retention{
+		Size:   cfg.Events.RetentionSize,
+		Window: cfg.Events.RetentionWindow,
+	})
+	promMetrics.RegisterSubscriptions(broker.Subscribers)
+
+	// GraphQL-сервер и REST-шлюз с общими сервисами
+	posts, comments := service.New(service.Deps{
+		Store:            store,
+		Events:           broker,
+		Filter:           contentFilter,
+		CommentMaxLength: cfg.Limits.CommentMaxLength,
+	})
+	resolver := &graph.Resolver{PostService: posts, CommentService: comments, Filter: contentFilter}
+	srv := newGraphQLServer(cfg, promMetrics, graph.NewExecutableSchema(graph.Config{Resolvers: resolver}))
+	api := rest.New(posts, comments, cfg.Server.SSEHeartbeat)
+
+	// Публикация запланированных постов до получения сигнала остановки
+	go service.NewScheduler(posts, cfg.Scheduler.Interval).Run(ctx)
+
+	if len(cfg.Auth.APIKeys) == 0 {
+		slog.Warn("API-ключи не настроены: административные операции отключены")
+	}
+	authors, err := auth.ParseAuthorKeys(cfg.Auth.AuthorKeys)
+	if err != nil {
+		fatal("Ошибка ключей авторов", err)
+	}
+	keys := auth.Keys{Admin: cfg.Auth.APIKeys, Authors: authors}
+
+	// Пробы живости и готовности
+	probes := health.New(cfg.Server.ReadinessTimeout, health.StorageCheck(store))
 
 	// маршруты
-	http.Handle("/", playground.Handler("GraphQL Playground", "/query"))
-	http.Handle("/query", srv)
+	mux := http.NewServeMux()
+	mux.Handle("/", playground.Handler("GraphQL Playground", "/query"))
+	mux.Handle("/query", auth.Middleware(keys, http.MaxBytesHandler(srv, cfg.Limits.MaxRequestBody)))
+	mux.Handle("/api/", auth.Middleware(keys, http.MaxBytesHandler(api, cfg.Limits.MaxRequestBody)))
+	mux.Handle("/healthz", probes.Liveness())
+	mux.Handle("/readyz", probes.Readiness())
+	mux.Handle("/metrics", promMetrics.Handler())
+
+	// Базовый контекст соединений отменяется последним: это закрывает
+	// websocket-соединения, которые http.Server.Shutdown не отслеживает.
+	connCtx, closeConns := context.WithCancel(context.Background())
+	server := &http.Server{
+		Addr:              cfg.Server.Addr,
+		Handler:           tracing.Middleware(logging.Middleware(readYourWrites(cfg, mux))),
+		BaseContext:       func(net.Listener) context.Context { return connCtx },
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+
+	go func() {
+		slog.Info("Server running", slog.String("addr", cfg.Server.Addr))
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fatal("Ошибка HTTP-сервера", err)
+		}
+	}()
 
-	// порт (по умолчанию 8080)
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	// gRPC-сервер PostService на отдельном порту
+	var grpcServer *grpc.Server
+	if cfg.Server.GRPCAddr != "" {
+		lis, err := net.Listen("tcp", cfg.Server.GRPCAddr)
+		if err != nil {
+			fatal("Ошибка запуска gRPC-сервера", err)
+		}
+		grpcServer = grpcapi.New(posts, comments)
+		go func() {
+			slog.Info("gRPC server running", slog.String("addr", cfg.Server.GRPCAddr))
+			if err := grpcServer.Serve(lis); err != nil {
+				fatal("Ошибка gRPC-сервера", err)
+			}
+		}()
 	}
 
-	log.Printf("Server running on http://localhost:%s/\n", port)
-	log.Fatal(http.ListenAndServe(":"+port, nil))
+	<-ctx.Done()
+	stop()
+	slog.Info("Получен сигнал остановки, завершаем работу", slog.Duration("timeout", cfg.Server.ShutdownTimeout))
+
+	// /readyz отвечает 503, пока балансировщик не исключит инстанс
+	probes.SetShuttingDown()
+	time.Sleep(cfg.Server.ShutdownDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	// Сначала завершаем подписки: клиенты получают complete, а SSE-запросы
+	// заканчиваются и не задерживают остановку сервера.
+	broker.Close()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("Не все запросы завершились до таймаута", slog.Any("error", err))
+	}
+	if grpcServer != nil {
+		stopGRPC(shutdownCtx, grpcServer)
+	}
+	closeConns()
+
+	if err := store.Close(); err != nil {
+		slog.Warn("Ошибка закрытия хранилища", slog.Any("error", err))
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Warn("Ошибка отправки трассировки", slog.Any("error", err))
+	}
+	slog.Info("Сервер остановлен")
+}
+
+// newGraphQLServer собирает GraphQL-обработчик с транспортами: подписки
+// доступны по websocket и по SSE (text/event-stream) с одними и теми же резолверами.
+func newGraphQLServer(cfg *config.Config, m *metrics.Metrics, es graphql.ExecutableSchema) *handler.Server {
+	srv := handler.New(es)
+
+	srv.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: cfg.Server.WebsocketKeepAlive,
+		InitFunc:              m.WebsocketInit,
+		CloseFunc:             m.WebsocketClose,
+	})
+	// SSE раньше GET/POST: они тоже принимают запросы с Accept: text/event-stream
+	srv.AddTransport(sse.Transport{Heartbeat: cfg.Server.SSEHeartbeat})
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.MultipartForm{})
+
+	srv.SetErrorPresenter(graph.ErrorPresenter)
+	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+
+	srv.Use(extension.Introspection{})
+	srv.Use(extension.AutomaticPersistedQuery{
+		Cache: lru.New[string](100),
+	})
+	if cfg.Limits.QueryComplexity > 0 {
+		srv.Use(extension.FixedComplexityLimit(cfg.Limits.QueryComplexity))
+	}
+	srv.Use(m.Tracer())
+	srv.Use(tracing.Extension{FieldThreshold: cfg.Tracing.FieldThreshold})
+	srv.Use(logging.Extension{})
+
+	return srv
+}
+
+// stopGRPC дожидается завершения вызовов gRPC, а по истечении ctx
+// прерывает оставшиеся.
+func stopGRPC(ctx context.Context, s *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		slog.Warn("Не все вызовы gRPC завершились до таймаута")
+		s.Stop()
+	}
+}
+
+// fatal пишет ошибку запуска в лог и завершает процесс.
+func fatal(msg string, err error) {
+	slog.Error(msg, slog.Any("error", err))
+	os.Exit(1)
+}
+
+// readYourWrites включает чтение своих записей в рамках HTTP-запроса:
+// после мутации последующие чтения того же запроса идут на primary.
+func readYourWrites(cfg *config.Config, next http.Handler) http.Handler {
+	if !cfg.Storage.ReadYourWrites {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(storage.WithReadYourWrites(r.Context())))
+	})
+}
+
+// loadConfig загружает конфигурацию и завершает процесс, если она некорректна.
+func loadConfig(name string, args []string) *config.Config {
+	cfg, err := config.Load(name, args)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	return cfg
+}
+
+// configCommand обрабатывает `config print`: выводит действующую
+// конфигурацию с замаскированными секретами.
+func configCommand(args []string) int {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprintln(os.Stderr, "использование: config print [-config файл] [флаги]")
+		return 2
+	}
+	cfg := loadConfig("config print", args[1:])
+	if err := config.Print(os.Stdout, cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
 }
@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Tracer — расширение gqlgen, считающее ответы и длительность операций.
+// Для подписок каждое отправленное событие считается отдельным ответом,
+// а длительность не измеряется.
+type Tracer struct {
+	m *Metrics
+}
+
+var (
+	_ graphql.HandlerExtension    = Tracer{}
+	_ graphql.ResponseInterceptor = Tracer{}
+)
+
+// Tracer возвращает расширение для handler.Server.Use.
+func (m *Metrics) Tracer() Tracer {
+	return Tracer{m: m}
+}
+
+func (Tracer) ExtensionName() string { return "PrometheusMetrics" }
+
+func (Tracer) Validate(graphql.ExecutableSchema) error { return nil }
+
+func (t Tracer) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+	if resp == nil || !graphql.HasOperationContext(ctx) {
+		return resp
+	}
+
+	opCtx := graphql.GetOperationContext(ctx)
+	name, typ := operationLabels(opCtx)
+	t.m.operations.WithLabelValues(name, typ, errorCode(resp.Errors)).Inc()
+	if typ != string(ast.Subscription) {
+		elapsed := graphql.Now().Sub(opCtx.Stats.OperationStart)
+		t.m.operationDuration.WithLabelValues(name, typ).Observe(elapsed.Seconds())
+	}
+	return resp
+}
+
+// operationLabels возвращает имя операции (или первого корневого поля для
+// анонимных операций) и её тип.
+func operationLabels(opCtx *graphql.OperationContext) (string, string) {
+	op := opCtx.Operation
+	if op == nil {
+		return "invalid", "unknown"
+	}
+	name := op.Name
+	if name == "" && len(op.SelectionSet) > 0 {
+		if f, ok := op.SelectionSet[0].(*ast.Field); ok {
+			name = f.Name
+		}
+	}
+	if name == "" {
+		name = "anonymous"
+	}
+	return name, string(op.Operation)
+}
+
+// errorCode возвращает код первой ошибки ответа из extensions.code или OK.
+func errorCode(errs gqlerror.List) string {
+	if len(errs) == 0 {
+		return "OK"
+	}
+	if code, ok := errs[0].Extensions["code"]; ok {
+		return fmt.Sprint(code)
+	}
+	return "ERROR"
+}
@@ -0,0 +1,109 @@
+// Package metrics собирает метрики Prometheus: GraphQL-операции, вызовы
+// хранилища, пул соединений с БД, подписки и websocket-соединения.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "ozon"
+
+// Metrics хранит собственный реестр метрик, чтобы их можно было проверять
+// в тестах без глобального состояния и без сервера Prometheus.
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	operations        *prometheus.CounterVec
+	operationDuration *prometheus.HistogramVec
+	storageDuration   *prometheus.HistogramVec
+	storageErrors     *prometheus.CounterVec
+	postsCreated      prometheus.Counter
+	commentsCreated   prometheus.Counter
+	websockets        prometheus.Gauge
+}
+
+// New создает и регистрирует метрики.
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "graphql",
+			Name:      "operations_total",
+			Help:      "Количество GraphQL-ответов по операции, типу и коду результата.",
+		}, []string{"operation", "type", "code"}),
+		operationDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "graphql",
+			Name:      "operation_duration_seconds",
+			Help:      "Длительность выполнения запросов и мутаций.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation", "type"}),
+		storageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "call_duration_seconds",
+			Help:      "Длительность вызовов методов хранилища.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"method"}),
+		storageErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "errors_total",
+			Help:      "Количество ошибок вызовов методов хранилища.",
+		}, []string{"method"}),
+		postsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "posts_created_total",
+			Help:      "Количество созданных постов.",
+		}),
+		commentsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "comments_created_total",
+			Help:      "Количество созданных комментариев.",
+		}),
+		websockets: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "websocket_connections",
+			Help:      "Количество подключённых websocket-клиентов.",
+		}),
+	}
+
+	m.Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.operations,
+		m.operationDuration,
+		m.storageDuration,
+		m.storageErrors,
+		m.postsCreated,
+		m.commentsCreated,
+		m.websockets,
+	)
+	return m
+}
+
+// Handler — обработчик /metrics.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{Registry: m.Registry})
+}
+
+// RegisterDBStats публикует статистику пула соединений sql.DB.
+func (m *Metrics) RegisterDBStats(db *sql.DB, name string) {
+	m.Registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterSubscriptions публикует число активных подписок, которое
+// возвращает count (например, Broker.Subscribers).
+func (m *Metrics) RegisterSubscriptions(count func() int) {
+	m.Registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_subscriptions",
+		Help:      "Количество активных GraphQL-подписок.",
+	}, func() float64 { return float64(count()) }))
+}
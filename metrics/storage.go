@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"ozon_test/graph/model"
+	"ozon_test/storage"
+)
+
+// instrumentedStorage измеряет длительность и ошибки каждого метода хранилища
+// и считает созданные посты и комментарии.
+type instrumentedStorage struct {
+	next storage.Storage
+	m    *Metrics
+	// tx — счетчики незафиксированной транзакции; они попадают в метрики
+	// только после фиксации.
+	tx *txCounts
+}
+
+type txCounts struct {
+	posts, comments int
+}
+
+// InstrumentStorage оборачивает хранилище сбором метрик.
+func (m *Metrics) InstrumentStorage(s storage.Storage) storage.Storage {
+	return &instrumentedStorage{next: s, m: m}
+}
+
+func (s *instrumentedStorage) Unwrap() storage.Storage { return s.next }
+
+// observe начинает замер вызова method; возвращаемую функцию нужно вызвать
+// через defer, чтобы учесть итоговую ошибку *err.
+func (s *instrumentedStorage) observe(method string, err *error) func() {
+	start := time.Now()
+	return func() {
+		s.m.storageDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+		if *err != nil {
+			s.m.storageErrors.WithLabelValues(method).Inc()
+		}
+	}
+}
+
+func (s *instrumentedStorage) GetPostByID(ctx context.Context, id string) (post *model.Post, err error) {
+	defer s.observe("GetPostByID", &err)()
+	return s.next.GetPostByID(ctx, id)
+}
+
+func (s *instrumentedStorage) GetPostForUpdate(ctx context.Context, id string) (post *model.Post, err error) {
+	defer s.observe("GetPostForUpdate", &err)()
+	return s.next.GetPostForUpdate(ctx, id)
+}
+
+func (s *instrumentedStorage) GetAllPosts(ctx context.Context) (posts []*model.Post, err error) {
+	defer s.observe("GetAllPosts", &err)()
+	return s.next.GetAllPosts(ctx)
+}
+
+func (s *instrumentedStorage) GetDuePosts(ctx context.Context, now time.Time) (posts []*model.Post, err error) {
+	defer s.observe("GetDuePosts", &err)()
+	return s.next.GetDuePosts(ctx, now)
+}
+
+func (s *instrumentedStorage) CreatePost(ctx context.Context, post *model.Post) (err error) {
+	defer s.observe("CreatePost", &err)()
+	if err = s.next.CreatePost(ctx, post); err == nil {
+		if s.tx != nil {
+			s.tx.posts++
+		} else {
+			s.m.postsCreated.Inc()
+		}
+	}
+	return err
+}
+
+func (s *instrumentedStorage) CreateComment(ctx context.Context, comment *model.Comment) (err error) {
+	defer s.observe("CreateComment", &err)()
+	if err = s.next.CreateComment(ctx, comment); err == nil {
+		if s.tx != nil {
+			s.tx.comments++
+		} else {
+			s.m.commentsCreated.Inc()
+		}
+	}
+	return err
+}
+
+func (s *instrumentedStorage) GetCommentsByPostID(ctx context.Context, postID string, limit, offset int) (comments []*model.Comment, err error) {
+	defer s.observe("GetCommentsByPostID", &err)()
+	return s.next.GetCommentsByPostID(ctx, postID, limit, offset)
+}
+
+func (s *instrumentedStorage) GetCommentsAfter(ctx context.Context, postID string, after time.Time, afterID string) (comments []*model.Comment, err error) {
+	defer s.observe("GetCommentsAfter", &err)()
+	return s.next.GetCommentsAfter(ctx, postID, after, afterID)
+}
+
+func (s *instrumentedStorage) UpdatePost(ctx context.Context, post *model.Post) (err error) {
+	defer s.observe("UpdatePost", &err)()
+	return s.next.UpdatePost(ctx, post)
+}
+
+func (s *instrumentedStorage) DeletePost(ctx context.Context, id string) (err error) {
+	defer s.observe("DeletePost", &err)()
+	return s.next.DeletePost(ctx, id)
+}
+
+func (s *instrumentedStorage) GetCommentByID(ctx context.Context, id string) (comment *model.Comment, err error) {
+	defer s.observe("GetCommentByID", &err)()
+	return s.next.GetCommentByID(ctx, id)
+}
+
+func (s *instrumentedStorage) UpdateComment(ctx context.Context, comment *model.Comment) (err error) {
+	defer s.observe("UpdateComment", &err)()
+	return s.next.UpdateComment(ctx, comment)
+}
+
+func (s *instrumentedStorage) DeleteComment(ctx context.Context, id string) (err error) {
+	defer s.observe("DeleteComment", &err)()
+	return s.next.DeleteComment(ctx, id)
+}
+
+func (s *instrumentedStorage) WithTx(ctx context.Context, fn func(tx storage.Storage) error) (err error) {
+	defer s.observe("WithTx", &err)()
+
+	counts := s.tx
+	if counts == nil {
+		counts = &txCounts{}
+	}
+	err = s.next.WithTx(ctx, func(tx storage.Storage) error {
+		return fn(&instrumentedStorage{next: tx, m: s.m, tx: counts})
+	})
+	if err == nil && s.tx == nil {
+		s.m.postsCreated.Add(float64(counts.posts))
+		s.m.commentsCreated.Add(float64(counts.comments))
+	}
+	return err
+}
+
+func (s *instrumentedStorage) Ping(ctx context.Context) (err error) {
+	defer s.observe("Ping", &err)()
+	return s.next.Ping(ctx)
+}
+
+func (s *instrumentedStorage) Close() error {
+	return s.next.Close()
+}
@@ -0,0 +1,24 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+)
+
+type websocketKey struct{}
+
+// WebsocketInit считает websocket-клиента подключённым после connection_init;
+// используется как transport.Websocket.InitFunc.
+func (m *Metrics) WebsocketInit(ctx context.Context, payload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+	m.websockets.Inc()
+	return context.WithValue(ctx, websocketKey{}, true), nil, nil
+}
+
+// WebsocketClose уменьшает число подключённых клиентов; используется как
+// transport.Websocket.CloseFunc. Соединения, закрытые до connection_init, не учитываются.
+func (m *Metrics) WebsocketClose(ctx context.Context, closeCode int) {
+	if counted, _ := ctx.Value(websocketKey{}).(bool); counted {
+		m.websockets.Dec()
+	}
+}
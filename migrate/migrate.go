@@ -0,0 +1,377 @@
+// Package migrate переносит данные из одного хранилища в другое без
+// остановки сервиса: массовое копирование, догоняние по ленте изменений
+// источника (storage.ChangeFeed) и проверка результата.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"ozon_test/graph/model"
+	"ozon_test/storage"
+	"ozon_test/transfer"
+)
+
+// Options — параметры переноса.
+type Options struct {
+	// BatchSize — записей в транзакции копирования и изменений в одной
+	// выборке из ленты; 0 — transfer.DefaultBatchSize.
+	BatchSize int
+	// Follow — после догоняния читать ленту, пока не отменен ctx; затем
+	// выполняются последнее догоняние и проверка. Нужен для переключения:
+	// запись в источник останавливается, пока идет слежение.
+	Follow bool
+	// PollInterval — пауза между чтениями ленты при Follow; 0 — секунда.
+	PollInterval time.Duration
+	// Consumer — имя потребителя ленты изменений источника; пустое —
+	// DefaultConsumer. Переносы с одним именем не должны идти одновременно.
+	Consumer string
+}
+
+// DefaultConsumer — имя потребителя ленты по умолчанию.
+const DefaultConsumer = "migrate-data"
+
+// Report — итог переноса.
+type Report struct {
+	Copied transfer.Progress
+	// Changes — сколько изменений из ленты применено к приемнику.
+	Changes int
+	// LastSeq — номер последнего применённого изменения.
+	LastSeq int64
+	// Repaired — посты, синхронизированные заново после расхождения.
+	Repaired []string
+	Verify   Verification
+}
+
+// Verification — сравнение источника и приемника.
+type Verification struct {
+	SourcePosts    int
+	TargetPosts    int
+	SourceComments int
+	TargetComments int
+	// Mismatched — ID постов, которые есть только в одном хранилище или
+	// различаются содержимым, статусом либо комментариями.
+	Mismatched []string
+}
+
+// OK сообщает, совпадают ли хранилища.
+func (v Verification) OK() bool {
+	return v.SourcePosts == v.TargetPosts && v.SourceComments == v.TargetComments && len(v.Mismatched) == 0
+}
+
+// ErrMismatch — после переноса хранилища различаются.
+var ErrMismatch = errors.New("данные источника и приемника различаются")
+
+// Run переносит данные из from в to. До копирования Run регистрируется
+// потребителем ленты изменений источника и запоминает номер последнего
+// изменения, поэтому все записи, сделанные во время копирования, затем
+// применяются по ленте: изменённая сущность читается из источника заново
+// и записывается в приемник или удаляется из него. Применённые изменения
+// подтверждаются и удаляются из ленты, а по завершении регистрация
+// снимается и лента больше не ведется. Если источник не ведет ленту
+// (in-memory), запись в него во время переноса должна быть остановлена.
+func Run(ctx context.Context, from, to storage.Storage, opts Options) (*Report, error) {
+	batch := opts.BatchSize
+	if batch <= 0 {
+		batch = transfer.DefaultBatchSize
+	}
+	consumer := opts.Consumer
+	if consumer == "" {
+		consumer = DefaultConsumer
+	}
+	report := &Report{}
+
+	feed, hasFeed := storage.As[storage.ChangeFeed](from)
+	if hasFeed {
+		seq, err := feed.TrackChanges(ctx, consumer)
+		if err != nil {
+			return report, fmt.Errorf("лента изменений: %w", err)
+		}
+		report.LastSeq = seq
+		defer func() {
+			if err := feed.UntrackChanges(context.WithoutCancel(ctx), consumer); err != nil {
+				slog.WarnContext(ctx, "Не удалось отключить ленту изменений", slog.String("consumer", consumer), slog.Any("error", err))
+			}
+		}()
+	} else {
+		slog.WarnContext(ctx, "Источник не ведет ленту изменений: записи во время переноса не будут перенесены")
+	}
+
+	slog.InfoContext(ctx, "Копирование", slog.Int64("seq", report.LastSeq))
+	copied, err := transfer.Copy(ctx, from, to, transfer.Options{
+		BatchSize: batch,
+		Progress: func(p transfer.Progress) {
+			slog.InfoContext(ctx, "Копирование", slog.Int("posts", p.Posts), slog.Int("comments", p.Comments))
+		},
+	})
+	report.Copied = copied
+	if err != nil {
+		return report, fmt.Errorf("копирование: %w", err)
+	}
+
+	if hasFeed {
+		if err := catchUp(ctx, feed, consumer, from, to, batch, report); err != nil {
+			return report, err
+		}
+		if opts.Follow {
+			follow(ctx, feed, consumer, from, to, batch, opts.PollInterval, report)
+			// Слежение остановлено отменой ctx; последние шаги выполняются до конца
+			ctx = context.WithoutCancel(ctx)
+			if err := catchUp(ctx, feed, consumer, from, to, batch, report); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	report.Verify, err = Verify(ctx, from, to)
+	if err != nil {
+		return report, fmt.Errorf("проверка: %w", err)
+	}
+	if !report.Verify.OK() {
+		// Расхождения — изменения между догонянием и проверкой или
+		// пропущенные лентой: посты синхронизируются целиком
+		for _, postID := range report.Verify.Mismatched {
+			if err := resync(ctx, from, to, postID); err != nil {
+				return report, fmt.Errorf("синхронизация поста %s: %w", postID, err)
+			}
+			report.Repaired = append(report.Repaired, postID)
+		}
+		if report.Verify, err = Verify(ctx, from, to); err != nil {
+			return report, fmt.Errorf("проверка: %w", err)
+		}
+	}
+	if !report.Verify.OK() {
+		return report, ErrMismatch
+	}
+	return report, nil
+}
+
+// catchUp применяет изменения из ленты после report.LastSeq, пока лента
+// не закончится, и подтверждает каждую применённую выборку.
+func catchUp(ctx context.Context, feed storage.ChangeFeed, consumer string, from, to storage.Storage, batch int, report *Report) error {
+	for {
+		changes, err := feed.Changes(ctx, report.LastSeq, batch)
+		if err != nil {
+			return fmt.Errorf("лента изменений: %w", err)
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+
+		err = to.WithTx(ctx, func(tx storage.Storage) error {
+			// Сущность читается из источника целиком, поэтому повторные
+			// изменения в выборке достаточно применить один раз
+			applied := make(map[storage.Change]bool, len(changes))
+			for _, c := range changes {
+				key := storage.Change{Entity: c.Entity, ID: c.ID}
+				if applied[key] {
+					continue
+				}
+				applied[key] = true
+				if err := apply(ctx, from, tx, c); err != nil {
+					return fmt.Errorf("изменение %d (%s %s): %w", c.Seq, c.Entity, c.ID, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		report.Changes += len(changes)
+		report.LastSeq = changes[len(changes)-1].Seq
+		if err := feed.AckChanges(ctx, consumer, report.LastSeq); err != nil {
+			slog.WarnContext(ctx, "Не удалось очистить ленту изменений", slog.Any("error", err))
+		}
+		slog.InfoContext(ctx, "Догоняние", slog.Int("changes", report.Changes), slog.Int64("seq", report.LastSeq))
+	}
+}
+
+// follow догоняет ленту раз в interval, пока не отменен ctx.
+func follow(ctx context.Context, feed storage.ChangeFeed, consumer string, from, to storage.Storage, batch int, interval time.Duration, report *Report) {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	slog.InfoContext(ctx, "Слежение за лентой изменений; остановите запись в источник и прервите команду для завершения")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := catchUp(ctx, feed, consumer, from, to, batch, report); err != nil && ctx.Err() == nil {
+				slog.WarnContext(ctx, "Ошибка догоняния, повтор", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// apply переносит актуальное состояние изменённой сущности.
+func apply(ctx context.Context, from, tx storage.Storage, c storage.Change) error {
+	var p transfer.Progress
+	switch c.Entity {
+	case storage.EntityPost:
+		post, err := from.GetPostByID(ctx, c.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ignoreNoRows(tx.DeletePost(ctx, c.ID))
+		}
+		if err != nil {
+			return err
+		}
+		return transfer.Upsert(ctx, tx, transfer.Record{Post: post}, &p)
+
+	case storage.EntityComment:
+		comment, err := from.GetCommentByID(ctx, c.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ignoreNoRows(tx.DeleteComment(ctx, c.ID))
+		}
+		if err != nil {
+			return err
+		}
+		return transfer.Upsert(ctx, tx, transfer.Record{Comment: comment}, &p)
+
+	default:
+		return fmt.Errorf("неизвестная сущность %q", c.Entity)
+	}
+}
+
+// resync приводит пост и его комментарии в приемнике к состоянию источника.
+func resync(ctx context.Context, from, to storage.Storage, postID string) error {
+	post, err := from.GetPostByID(ctx, postID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Пост удален в источнике
+		return ignoreNoRows(to.DeletePost(ctx, postID))
+	}
+	if err != nil {
+		return err
+	}
+	comments, err := from.GetCommentsByPostID(ctx, postID, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	return to.WithTx(ctx, func(tx storage.Storage) error {
+		var p transfer.Progress
+		if err := transfer.Upsert(ctx, tx, transfer.Record{Post: post}, &p); err != nil {
+			return err
+		}
+		keep := make(map[string]bool, len(comments))
+		for _, c := range transfer.ParentsFirst(comments) {
+			keep[c.ID] = true
+			if err := transfer.Upsert(ctx, tx, transfer.Record{Comment: c}, &p); err != nil {
+				return err
+			}
+		}
+
+		existing, err := tx.GetCommentsByPostID(ctx, postID, 0, 0)
+		if err != nil {
+			return err
+		}
+		for _, c := range existing {
+			if !keep[c.ID] {
+				// Ответы могли уже удалиться каскадом вместе с родителем
+				if err := ignoreNoRows(tx.DeleteComment(ctx, c.ID)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Verify сравнивает число постов и комментариев и контрольные суммы
+// каждого поста: его полей и полей его комментариев.
+func Verify(ctx context.Context, from, to storage.Storage) (Verification, error) {
+	var v Verification
+	source, err := checksums(ctx, from, &v.SourcePosts, &v.SourceComments)
+	if err != nil {
+		return v, fmt.Errorf("источник: %w", err)
+	}
+	target, err := checksums(ctx, to, &v.TargetPosts, &v.TargetComments)
+	if err != nil {
+		return v, fmt.Errorf("приемник: %w", err)
+	}
+
+	for postID, sum := range source {
+		if target[postID] != sum {
+			v.Mismatched = append(v.Mismatched, postID)
+		}
+	}
+	for postID := range target {
+		if _, ok := source[postID]; !ok {
+			v.Mismatched = append(v.Mismatched, postID)
+		}
+	}
+	sort.Strings(v.Mismatched)
+	return v, nil
+}
+
+// checksums возвращает для каждого поста SHA-256 его полей и полей его
+// комментариев по порядку ID и считает посты и комментарии. Время
+// сравнивается с точностью до микросекунды, как его хранит PostgreSQL.
+func checksums(ctx context.Context, s storage.Storage, posts, comments *int) (map[string]string, error) {
+	all, err := s.GetAllPosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sums := make(map[string]string, len(all))
+	for _, post := range all {
+		list, err := s.GetCommentsByPostID(ctx, post.ID, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+
+		h := sha256.New()
+		// JSON экранирует поля, поэтому граница между ними однозначна
+		enc := json.NewEncoder(h)
+		if err := enc.Encode([]any{
+			post.ID, post.Title, post.Content, post.Author, post.CommentsAllowed,
+			postStatus(post), checksumTime(post.PublishAt),
+		}); err != nil {
+			return nil, err
+		}
+		for _, c := range list {
+			if err := enc.Encode([]any{c.ID, c.ParentID, c.Author, c.Content}); err != nil {
+				return nil, err
+			}
+		}
+		sums[post.ID] = hex.EncodeToString(h.Sum(nil))
+		*comments += len(list)
+	}
+	*posts = len(all)
+	return sums, nil
+}
+
+// postStatus — статус поста; пустой (записи до появления статусов)
+// считается опубликованным, как в хранилищах.
+func postStatus(post *model.Post) model.PostStatus {
+	if post.Status == "" {
+		return model.PostStatusPublished
+	}
+	return post.Status
+}
+
+func checksumTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano)
+}
+
+func ignoreNoRows(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	return err
+}
@@ -1,3 +1,3 @@
-
-CREATE INDEX IF NOT EXISTS idx_comments_post_id ON comments(post_id);
+
+CREATE INDEX IF NOT EXISTS idx_comments_post_id ON comments(post_id);
 CREATE INDEX IF NOT EXISTS idx_comments_parent_id ON comments(parent_id);
\ No newline at end of file
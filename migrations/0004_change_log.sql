@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS change_log (
+    seq BIGSERIAL PRIMARY KEY,
+    entity TEXT NOT NULL,
+    entity_id UUID NOT NULL,
+    changed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE OR REPLACE FUNCTION log_change() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        INSERT INTO change_log (entity, entity_id) VALUES (TG_ARGV[0], OLD.id);
+    ELSE
+        INSERT INTO change_log (entity, entity_id) VALUES (TG_ARGV[0], NEW.id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS posts_change_log ON posts;
+CREATE TRIGGER posts_change_log AFTER INSERT OR UPDATE OR DELETE ON posts
+    FOR EACH ROW EXECUTE FUNCTION log_change('post');
+
+DROP TRIGGER IF EXISTS comments_change_log ON comments;
+CREATE TRIGGER comments_change_log AFTER INSERT OR UPDATE OR DELETE ON comments
+    FOR EACH ROW EXECUTE FUNCTION log_change('comment');
+
+INSERT INTO schema_migrations (version) VALUES (4)
+ON CONFLICT (version) DO NOTHING;
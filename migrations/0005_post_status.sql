@@ -0,0 +1,13 @@
+-- Статус поста (DRAFT, SCHEDULED, PUBLISHED, ARCHIVED) и время публикации:
+-- запланированное у SCHEDULED, фактическое у опубликованных. Существующие
+-- посты считаются опубликованными в момент создания.
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'PUBLISHED';
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS publish_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE posts SET publish_at = created_at WHERE status = 'PUBLISHED' AND publish_at IS NULL;
+
+-- Планировщик ищет запланированные посты
+CREATE INDEX IF NOT EXISTS idx_posts_scheduled ON posts(publish_at) WHERE status = 'SCHEDULED';
+
+INSERT INTO schema_migrations (version) VALUES (5)
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,28 @@
+-- Потребители ленты изменений (migrate-data) и номер последнего
+-- применённого ими изменения. Триггеры пишут в change_log, только пока
+-- есть хотя бы один потребитель; без них запись не платит за ленту.
+CREATE TABLE IF NOT EXISTS change_log_consumers (
+    name TEXT PRIMARY KEY,
+    seq BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE OR REPLACE FUNCTION log_change() RETURNS trigger AS $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM change_log_consumers) THEN
+        RETURN NULL;
+    END IF;
+    IF TG_OP = 'DELETE' THEN
+        INSERT INTO change_log (entity, entity_id) VALUES (TG_ARGV[0], OLD.id);
+    ELSE
+        INSERT INTO change_log (entity, entity_id) VALUES (TG_ARGV[0], NEW.id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Накопленная лента без потребителей никому не нужна
+DELETE FROM change_log;
+
+INSERT INTO schema_migrations (version) VALUES (6)
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,11 @@
+-- Блокировки авторов: переживают перезапуск и общие для всех экземпляров.
+-- author_key — имя без учета регистра и пробелов по краям.
+CREATE TABLE IF NOT EXISTS banned_users (
+    author_key TEXT PRIMARY KEY,
+    author TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    banned_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO schema_migrations (version) VALUES (7)
+ON CONFLICT (version) DO NOTHING;
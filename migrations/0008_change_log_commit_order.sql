@@ -0,0 +1,20 @@
+-- Номер seq выдается при вставке строки, а не при фиксации транзакции:
+-- транзакция с меньшим seq может зафиксироваться после того, как
+-- потребитель подтвердил больший, и ее изменение пропало бы из ленты.
+-- Поэтому лента читается по pos: его получают только строки транзакций,
+-- завершившихся раньше всех еще идущих (xid ниже xmin снимка), а выдача
+-- pos сериализуется, так что новые строки всегда встают после выданных.
+ALTER TABLE change_log ADD COLUMN IF NOT EXISTS xid xid8 NOT NULL DEFAULT pg_current_xact_id();
+ALTER TABLE change_log ADD COLUMN IF NOT EXISTS pos BIGINT;
+
+CREATE SEQUENCE IF NOT EXISTS change_log_pos;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_change_log_pos ON change_log(pos);
+CREATE INDEX IF NOT EXISTS idx_change_log_pending ON change_log(xid) WHERE pos IS NULL;
+
+-- Записанные до миграции строки сохраняют номера, а новые позиции
+-- продолжают seq: подтверждения потребителей остаются верными
+UPDATE change_log SET pos = seq WHERE pos IS NULL;
+SELECT setval('change_log_pos', (SELECT last_value FROM change_log_seq_seq));
+
+INSERT INTO schema_migrations (version) VALUES (8)
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,17 @@
+-- Журнал модерации: решения фильтров контента переживают перезапуск и
+-- видны всем экземплярам. seq задает порядок записей, decisions — решения
+-- отдельных фильтров в JSON.
+CREATE TABLE IF NOT EXISTS moderation_log (
+    seq BIGSERIAL PRIMARY KEY,
+    id UUID NOT NULL UNIQUE,
+    target_id TEXT NOT NULL DEFAULT '',
+    post_id TEXT,
+    kind TEXT NOT NULL,
+    author TEXT NOT NULL,
+    action TEXT NOT NULL,
+    decisions JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+INSERT INTO schema_migrations (version) VALUES (9)
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,11 @@
+// Package migrations встраивает миграции PostgreSQL в бинарник: их
+// применяет storage при подключении (см. storage.NewPostgresStorage).
+// Те же файлы docker-entrypoint-initdb.d выполняет на пустом томе.
+package migrations
+
+import "embed"
+
+// FS содержит файлы NNNN_описание.sql; номер — версия в schema_migrations.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,280 @@
+// Package rest — REST/JSON-шлюз для клиентов, не умеющих GraphQL. Маршруты
+// вызывают те же сервисы, что и GraphQL, поэтому проверки, фильтры
+// контента, события подписок и коды ошибок у обоих API общие. Описание
+// в формате OpenAPI 3 отдается на /api/openapi.json.
+package rest
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ozon_test/graph/model"
+	"ozon_test/logging"
+	"ozon_test/service"
+)
+
+//go:embed openapi.json
+var openAPI []byte
+
+// DefaultPageSize — limit списка комментариев, если он не указан.
+const DefaultPageSize = 100
+
+// Handler обслуживает маршруты /api/.
+type Handler struct {
+	posts     *service.PostService
+	comments  *service.CommentService
+	heartbeat time.Duration
+	mux       *http.ServeMux
+}
+
+// New создает шлюз поверх сервисов постов и комментариев; heartbeat —
+// интервал пингов в потоке комментариев, 0 — без пингов.
+func New(posts *service.PostService, comments *service.CommentService, heartbeat time.Duration) *Handler {
+	h := &Handler{posts: posts, comments: comments, heartbeat: heartbeat, mux: http.NewServeMux()}
+
+	h.mux.HandleFunc("GET /api/posts", h.listPosts)
+	h.mux.HandleFunc("POST /api/posts", h.createPost)
+	h.mux.HandleFunc("GET /api/posts/{id}", h.getPost)
+	h.mux.HandleFunc("GET /api/posts/{id}/comments", h.listComments)
+	h.mux.HandleFunc("POST /api/posts/{id}/comments", h.addComment)
+	h.mux.HandleFunc("GET /api/posts/{id}/comments/stream", h.streamComments)
+	h.mux.HandleFunc("GET /api/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(openAPI)
+	})
+	h.mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, r, &statusError{status: http.StatusNotFound, code: service.CodeNotFound, message: "маршрут не найден"})
+	})
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+type newPost struct {
+	Title           *string `json:"title"`
+	Content         *string `json:"content"`
+	Author          *string `json:"author"`
+	CommentsAllowed *bool   `json:"commentsAllowed"`
+}
+
+type newComment struct {
+	ParentID *string `json:"parentId"`
+	Author   *string `json:"author"`
+	Content  *string `json:"content"`
+}
+
+func (h *Handler) listPosts(w http.ResponseWriter, r *http.Request) {
+	posts, err := h.posts.List(r.Context(), nil)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if posts == nil {
+		posts = []*model.Post{}
+	}
+	writeJSON(w, http.StatusOK, posts)
+}
+
+func (h *Handler) createPost(w http.ResponseWriter, r *http.Request) {
+	var req newPost
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := required(
+		field{"title", req.Title != nil},
+		field{"content", req.Content != nil},
+		field{"author", req.Author != nil},
+		field{"commentsAllowed", req.CommentsAllowed != nil},
+	); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	post, err := h.posts.Create(r.Context(), *req.Title, *req.Content, *req.Author, *req.CommentsAllowed)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", "/api/posts/"+post.ID)
+	writeJSON(w, http.StatusCreated, post)
+}
+
+func (h *Handler) getPost(w http.ResponseWriter, r *http.Request) {
+	post, err := h.posts.Get(r.Context(), r.PathValue("id"), nil)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, post)
+}
+
+func (h *Handler) listComments(w http.ResponseWriter, r *http.Request) {
+	limit, err := intParam(r, "limit", DefaultPageSize)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	offset, err := intParam(r, "offset", 0)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// GraphQL возвращает для неизвестного поста пустой список, REST — 404
+	postID := r.PathValue("id")
+	if _, err := h.posts.Get(r.Context(), postID, nil); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	comments, err := h.comments.List(r.Context(), postID, limit, offset)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if comments == nil {
+		comments = []*model.Comment{}
+	}
+	writeJSON(w, http.StatusOK, comments)
+}
+
+func (h *Handler) addComment(w http.ResponseWriter, r *http.Request) {
+	var req newComment
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := required(field{"author", req.Author != nil}, field{"content", req.Content != nil}); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	comment, err := h.comments.Add(r.Context(), r.PathValue("id"), req.ParentID, *req.Author, *req.Content)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, comment)
+}
+
+// decodeBody разбирает JSON-тело запроса; неизвестные поля — ошибка, чтобы
+// опечатка в имени поля не терялась молча.
+func decodeBody(r *http.Request, dst any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return &statusError{status: http.StatusRequestEntityTooLarge, code: service.CodeBadUserInput, message: "тело запроса слишком большое"}
+		}
+		if errors.Is(err, io.EOF) {
+			return &service.InputError{Message: "пустое тело запроса"}
+		}
+		return &service.InputError{Message: fmt.Sprintf("тело запроса не является корректным JSON: %v", err)}
+	}
+	return nil
+}
+
+// field — обязательное поле тела запроса и признак его наличия.
+type field struct {
+	name    string
+	present bool
+}
+
+// required проверяет, что обязательные поля (в GraphQL — аргументы с !)
+// переданы.
+func required(fields ...field) error {
+	for _, f := range fields {
+		if !f.present {
+			return &service.InputError{Message: fmt.Sprintf("не указано поле %s", f.name)}
+		}
+	}
+	return nil
+}
+
+// intParam читает целый параметр запроса или возвращает def.
+func intParam(r *http.Request, name string, def int) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return def, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, &service.InputError{Message: fmt.Sprintf("параметр %s должен быть целым числом", name)}
+	}
+	return v, nil
+}
+
+// statusError — ошибка самого шлюза с заранее известным HTTP-статусом.
+type statusError struct {
+	status  int
+	code    string
+	message string
+}
+
+func (e *statusError) Error() string { return e.message }
+
+// httpStatus сопоставляет код ошибки сервиса HTTP-статусу.
+func httpStatus(code string) int {
+	switch code {
+	case service.CodeNotFound:
+		return http.StatusNotFound
+	case service.CodeBadUserInput:
+		return http.StatusBadRequest
+	case service.CodeCommentsDisabled:
+		return http.StatusConflict
+	case service.CodeContentRejected:
+		return http.StatusUnprocessableEntity
+	case service.CodeUnauthenticated:
+		return http.StatusUnauthorized
+	case service.CodeForbidden:
+		return http.StatusForbidden
+	case service.CodeCursorExpired:
+		return http.StatusGone
+	}
+	return http.StatusInternalServerError
+}
+
+type errorBody struct {
+	Error struct {
+		Code      string `json:"code"`
+		Message   string `json:"message"`
+		RequestID string `json:"requestId,omitempty"`
+	} `json:"error"`
+}
+
+// writeError отвечает ошибкой с тем же кодом, что GraphQL пишет в
+// extensions.code. Текст внутренних ошибок клиенту не показывается.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var body errorBody
+	status := http.StatusInternalServerError
+	var se *statusError
+	if errors.As(err, &se) {
+		status, body.Error.Code, body.Error.Message = se.status, se.code, se.message
+	} else {
+		body.Error.Code = service.ErrorCode(err)
+		status = httpStatus(body.Error.Code)
+		body.Error.Message = err.Error()
+	}
+	if status == http.StatusInternalServerError {
+		slog.ErrorContext(r.Context(), "rest request failed", slog.String("path", r.URL.Path), slog.Any("error", err))
+		body.Error.Message = "внутренняя ошибка сервера"
+	}
+	body.Error.RequestID = logging.RequestID(r.Context())
+	writeJSON(w, status, body)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,110 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"ozon_test/graph/model"
+	"ozon_test/service"
+)
+
+// streamComments отдает новые комментарии поста потоком Server-Sent Events:
+// событие comment с id, равным курсору. Переподключение с Last-Event-ID
+// (или параметром since) досылает пропущенные комментарии, как аргумент
+// since подписки commentAdded.
+func (h *Handler) streamComments(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	var since *model.Cursor
+	raw := r.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = r.URL.Query().Get("since")
+	}
+	if raw != "" {
+		cursor, err := model.ParseCursor(raw)
+		if err != nil {
+			writeError(w, r, &service.InputError{Message: err.Error()})
+			return
+		}
+		since = &cursor
+	}
+
+	ctx := r.Context()
+	postID := r.PathValue("id")
+	if _, err := h.posts.Get(ctx, postID, nil); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	comments, err := h.comments.Watch(ctx, postID, since)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	s := &eventStream{w: w, f: flusher}
+	s.write(":\n\n")
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	defer func() {
+		close(done)
+		wg.Wait()
+	}()
+	if h.heartbeat > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticker := time.NewTicker(h.heartbeat)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					s.write(": ping\n\n")
+				}
+			}
+		}()
+	}
+
+	// Канал закрывается при отключении клиента или остановке брокера
+	for comment := range comments {
+		data, err := json.Marshal(comment)
+		if err != nil {
+			continue
+		}
+		id := ""
+		if comment.Cursor != nil {
+			id = "id: " + comment.Cursor.String() + "\n"
+		}
+		s.write(fmt.Sprintf("%sevent: comment\ndata: %s\n\n", id, data))
+	}
+}
+
+// eventStream сериализует запись: пинги пишутся из отдельной горутины.
+type eventStream struct {
+	mu sync.Mutex
+	w  http.ResponseWriter
+	f  http.Flusher
+}
+
+func (s *eventStream) write(text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, _ = s.w.Write([]byte(text))
+	s.f.Flush()
+}
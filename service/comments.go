@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"ozon_test/events"
+	"ozon_test/filter"
+	"ozon_test/graph/model"
+	"ozon_test/storage"
+)
+
+// CommentService — операции с комментариями.
+type CommentService struct {
+	deps Deps
+}
+
+// Add добавляет комментарий или ответ на комментарий parentID.
+func (s *CommentService) Add(ctx context.Context, postID string, parentID *string, author, content string) (*model.Comment, error) {
+	var (
+		comment *model.Comment
+		checked *filter.Content
+	)
+
+	// Проверка поста и вставка в одной транзакции: пост не закроют
+	// для комментариев между проверкой и записью.
+	err := s.deps.Store.WithTx(ctx, func(tx storage.Storage) error {
+		post, err := tx.GetPostByID(ctx, postID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrPostNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("чтение поста %s: %w", postID, err)
+		}
+		if !visible(post, nil) {
+			return ErrPostNotFound
+		}
+		if !post.CommentsAllowed || post.Status == model.PostStatusArchived {
+			return ErrCommentsDisabled
+		}
+		if parentID != nil {
+			// Без проверки отсутствующий родитель или родитель из другого
+			// поста дошел бы до хранилища и вернулся бы внутренней ошибкой
+			parent, err := tx.GetCommentByID(ctx, *parentID)
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrCommentNotFound
+			}
+			if err != nil {
+				return fmt.Errorf("чтение комментария %s: %w", *parentID, err)
+			}
+			if parent.PostID != postID {
+				return InvalidInput("комментарий %s относится к другому посту", *parentID)
+			}
+		}
+		if err := s.checkLength(content); err != nil {
+			return err
+		}
+
+		comment = &model.Comment{
+			ID:        s.deps.IDs.NewID(),
+			PostID:    postID,
+			ParentID:  parentID,
+			Author:    author,
+			Content:   content,
+			CreatedAt: s.deps.now(),
+		}
+		if checked, err = s.filter(ctx, comment); err != nil {
+			return err
+		}
+		return tx.CreateComment(ctx, comment)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.deps.accept(checked)
+
+	s.deps.Events.Publish(events.Event{Type: events.CommentAdded, PostID: postID, Comment: comment})
+	return comment, nil
+}
+
+// Update меняет текст комментария.
+func (s *CommentService) Update(ctx context.Context, id, content string) (*model.Comment, error) {
+	existing, err := s.deps.Store.GetCommentByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCommentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("чтение комментария %s: %w", id, err)
+	}
+	if err := s.checkLength(content); err != nil {
+		return nil, err
+	}
+
+	comment := *existing
+	comment.Content = content
+	checked, err := s.filter(ctx, &comment)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.deps.Store.UpdateComment(ctx, &comment); err != nil {
+		return nil, err
+	}
+	s.deps.accept(checked)
+
+	s.deps.Events.Publish(events.Event{Type: events.CommentUpdated, PostID: comment.PostID, Comment: &comment})
+	return &comment, nil
+}
+
+// Delete удаляет комментарий вместе с ответами на него.
+func (s *CommentService) Delete(ctx context.Context, id string) error {
+	comment, err := s.deps.Store.GetCommentByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrCommentNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("чтение комментария %s: %w", id, err)
+	}
+	if err := s.deps.Store.DeleteComment(ctx, id); err != nil {
+		return err
+	}
+
+	s.deps.Events.Publish(events.Event{Type: events.CommentDeleted, PostID: comment.PostID, CommentID: id})
+	return nil
+}
+
+// List возвращает страницу комментариев поста; limit 0 — все комментарии.
+func (s *CommentService) List(ctx context.Context, postID string, limit, offset int) ([]*model.Comment, error) {
+	if limit < 0 || offset < 0 {
+		return nil, InvalidInput("limit и offset не могут быть отрицательными")
+	}
+	return s.deps.Store.GetCommentsByPostID(ctx, postID, limit, offset)
+}
+
+// Watch подписывает на новые комментарии поста. С since (или курсором
+// транспорта, например Last-Event-ID) сначала досылаются комментарии,
+// пропущенные после этого курсора: из окна брокера, а если брокер их уже
+// не хранит (перезапуск, вытеснение) — из хранилища по позиции
+// комментария в курсоре.
+func (s *CommentService) Watch(ctx context.Context, postID string, since *model.Cursor) (<-chan *model.Comment, error) {
+	since = resumeCursor(ctx, since)
+	match := func(e events.Event) bool {
+		return e.Type == events.CommentAdded && e.PostID == postID
+	}
+	sub, err := s.deps.Events.SubscribeSince(ctx, since, match)
+	if errors.Is(err, events.ErrCursorExpired) && since != nil && since.CommentID != "" {
+		sub, err = s.replay(ctx, postID, *since, match)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return forward(ctx, sub, func(e events.Event) *model.Comment {
+		comment := *e.Comment
+		comment.Cursor = &e.Cursor
+		return &comment
+	}), nil
+}
+
+// replay досылает комментарии поста после позиции since из хранилища и
+// затем переключается на живые события брокера. Подписка оформляется до
+// чтения хранилища, чтобы не потерять комментарий, записанный между ними;
+// уже досланные комментарии из живых событий пропускаются.
+func (s *CommentService) replay(ctx context.Context, postID string, since model.Cursor, match func(events.Event) bool) (<-chan events.Event, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	live := s.deps.Events.Subscribe(ctx, match)
+	stored, err := s.deps.Store.GetCommentsAfter(ctx, postID, since.CreatedAt, since.CommentID)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("чтение комментариев поста %s: %w", postID, err)
+	}
+
+	out := make(chan events.Event, len(stored))
+	replayed := make(map[string]bool, len(stored))
+	for _, comment := range stored {
+		replayed[comment.ID] = true
+		out <- events.Event{
+			Cursor:  model.Cursor{CreatedAt: comment.CreatedAt, CommentID: comment.ID},
+			Type:    events.CommentAdded,
+			PostID:  postID,
+			Comment: comment,
+		}
+	}
+	go func() {
+		defer cancel()
+		defer close(out)
+		for e := range live {
+			if replayed[e.Comment.ID] {
+				continue
+			}
+			select {
+			case out <- e:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// checkLength проверяет длину комментария по настроенному ограничению.
+func (s *CommentService) checkLength(content string) error {
+	if len(content) > s.deps.CommentMaxLength {
+		return InvalidInput("Комментарий слишком длинный (максимум %d символов)", s.deps.CommentMaxLength)
+	}
+	return nil
+}
+
+// filter прогоняет комментарий через фильтры контента и возвращает
+// проверенный контент для deps.accept после записи.
+func (s *CommentService) filter(ctx context.Context, comment *model.Comment) (*filter.Content, error) {
+	if s.deps.Filter == nil {
+		return nil, nil
+	}
+
+	c := &filter.Content{
+		Kind:   filter.KindComment,
+		ID:     comment.ID,
+		PostID: comment.PostID,
+		Author: comment.Author,
+		Body:   comment.Content,
+	}
+	if _, err := s.deps.Filter.Run(ctx, c); err != nil {
+		return nil, err
+	}
+	comment.Content = c.Body
+	return c, nil
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"ozon_test/events"
+	"ozon_test/filter"
+)
+
+// Коды ошибок, общие для всех API: extensions.code в GraphQL, тело ошибок
+// REST и ErrorInfo.Reason в gRPC.
+const (
+	CodeNotFound         = "NOT_FOUND"
+	CodeBadUserInput     = "BAD_USER_INPUT"
+	CodeCommentsDisabled = "COMMENTS_DISABLED"
+	CodeContentRejected  = "CONTENT_REJECTED"
+	CodeUnauthenticated  = "UNAUTHENTICATED"
+	CodeForbidden        = "FORBIDDEN"
+	CodeCursorExpired    = "CURSOR_EXPIRED"
+	CodeInternal         = "INTERNAL"
+)
+
+var (
+	ErrPostNotFound     = errors.New("пост не найден")
+	ErrCommentNotFound  = errors.New("комментарий не найден")
+	ErrCommentsDisabled = errors.New("комментарии к этому посту запрещены")
+)
+
+// InputError — некорректные входные данные: слишком длинный комментарий,
+// отрицательная пагинация и т.п.
+type InputError struct {
+	Message string
+}
+
+func (e *InputError) Error() string { return e.Message }
+
+// InvalidInput создает InputError с форматированным сообщением.
+func InvalidInput(format string, args ...any) error {
+	return &InputError{Message: fmt.Sprintf(format, args...)}
+}
+
+// ErrorCode возвращает код ошибки сервиса; для непредвиденных ошибок
+// (например, недоступности хранилища) — CodeInternal.
+func ErrorCode(err error) string {
+	var inputErr *InputError
+	var rejected *filter.RejectedError
+	switch {
+	case errors.Is(err, ErrPostNotFound), errors.Is(err, ErrCommentNotFound):
+		return CodeNotFound
+	case errors.Is(err, ErrCommentsDisabled):
+		return CodeCommentsDisabled
+	case errors.As(err, &inputErr):
+		return CodeBadUserInput
+	case errors.As(err, &rejected):
+		return CodeContentRejected
+	case errors.Is(err, events.ErrCursorExpired):
+		return CodeCursorExpired
+	}
+	return CodeInternal
+}
@@ -0,0 +1,380 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"ozon_test/events"
+	"ozon_test/filter"
+	"ozon_test/graph/model"
+	"ozon_test/storage"
+)
+
+// PreviewComments — сколько комментариев возвращается вместе с постом.
+const PreviewComments = 10
+
+// PostService — операции с постами.
+type PostService struct {
+	deps Deps
+}
+
+// Create создает опубликованный пост и рассылает событие о нем.
+func (s *PostService) Create(ctx context.Context, title, content, author string, commentsAllowed bool) (*model.Post, error) {
+	now := s.deps.now()
+	post := &model.Post{
+		ID:              s.deps.IDs.NewID(),
+		Title:           title,
+		Content:         content,
+		Author:          author,
+		CommentsAllowed: commentsAllowed,
+		CreatedAt:       now,
+		Status:          model.PostStatusPublished,
+		PublishAt:       &now,
+	}
+
+	checked, err := s.filter(ctx, post)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.deps.Store.CreatePost(ctx, post); err != nil {
+		return nil, err
+	}
+	s.deps.accept(checked)
+
+	s.deps.Events.Publish(events.Event{Type: events.PostCreated, PostID: post.ID, Post: post})
+	return post, nil
+}
+
+// Get возвращает пост с первыми PreviewComments комментариями. Черновик
+// и запланированный пост видны только автору viewer.
+func (s *PostService) Get(ctx context.Context, id string, viewer *string) (*model.Post, error) {
+	post, err := s.deps.Store.GetPostByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPostNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("чтение поста %s: %w", id, err)
+	}
+	if !visible(post, viewer) {
+		return nil, ErrPostNotFound
+	}
+
+	comments, err := s.deps.Store.GetCommentsByPostID(ctx, id, PreviewComments, 0)
+	if err != nil {
+		return nil, err
+	}
+	// In-memory хранилище отдает свой экземпляр поста: комментарии
+	// добавляются к копии, чтобы не менять его у других читателей
+	result := *post
+	result.Comments = comments
+	return &result, nil
+}
+
+// List возвращает опубликованные посты без комментариев, а автору viewer —
+// еще и его черновики, запланированные и архивные посты.
+func (s *PostService) List(ctx context.Context, viewer *string) ([]*model.Post, error) {
+	posts, err := s.deps.Store.GetAllPosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.Post, 0, len(posts))
+	for _, post := range posts {
+		if visible(post, viewer) && (post.Status != model.PostStatusArchived || ownedBy(post, viewer)) {
+			result = append(result, post)
+		}
+	}
+	return result, nil
+}
+
+// SaveDraft создает черновик, если id не задан, или сохраняет черновик
+// автора. Запланированный пост после правки снова становится черновиком.
+// Фильтры контента черновик не проходит — они срабатывают при публикации.
+func (s *PostService) SaveDraft(ctx context.Context, id *string, title, content, author string, commentsAllowed bool) (*model.Post, error) {
+	if id == nil {
+		post := &model.Post{
+			ID:              s.deps.IDs.NewID(),
+			Title:           title,
+			Content:         content,
+			Author:          author,
+			CommentsAllowed: commentsAllowed,
+			CreatedAt:       s.deps.now(),
+			Status:          model.PostStatusDraft,
+		}
+		if err := s.deps.Store.CreatePost(ctx, post); err != nil {
+			return nil, err
+		}
+		return post, nil
+	}
+
+	return s.change(ctx, *id, author, func(post *model.Post) error {
+		if !unpublished(post) {
+			return InvalidInput("опубликованный пост нельзя сохранить как черновик")
+		}
+		post.Title, post.Content, post.CommentsAllowed = title, content, commentsAllowed
+		post.Status, post.PublishAt = model.PostStatusDraft, nil
+		return nil
+	})
+}
+
+// Publish сразу публикует черновик или запланированный пост автора и
+// рассылает событие о новом посте.
+func (s *PostService) Publish(ctx context.Context, id, author string) (*model.Post, error) {
+	var checked *filter.Content
+	post, err := s.change(ctx, id, author, func(post *model.Post) (err error) {
+		if !unpublished(post) {
+			return InvalidInput("пост уже опубликован")
+		}
+		if checked, err = s.filter(ctx, post); err != nil {
+			return err
+		}
+		now := s.deps.now()
+		post.Status, post.PublishAt = model.PostStatusPublished, &now
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.deps.accept(checked)
+
+	s.deps.Events.Publish(events.Event{Type: events.PostCreated, PostID: post.ID, Post: post})
+	return post, nil
+}
+
+// Schedule планирует публикацию черновика автора на at. Фильтры контента
+// проверяются сразу, чтобы автор узнал об отказе до наступления срока.
+func (s *PostService) Schedule(ctx context.Context, id, author string, at time.Time) (*model.Post, error) {
+	at = at.UTC().Truncate(time.Microsecond)
+	if !at.After(s.deps.now()) {
+		return nil, InvalidInput("время публикации должно быть в будущем")
+	}
+
+	var checked *filter.Content
+	post, err := s.change(ctx, id, author, func(post *model.Post) (err error) {
+		if !unpublished(post) {
+			return InvalidInput("пост уже опубликован")
+		}
+		if checked, err = s.filter(ctx, post); err != nil {
+			return err
+		}
+		post.Status, post.PublishAt = model.PostStatusScheduled, &at
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.deps.accept(checked)
+	return post, nil
+}
+
+// Archive переносит опубликованный пост автора в архив: он пропадает из
+// общего списка, а комментарии к нему закрываются.
+func (s *PostService) Archive(ctx context.Context, id, author string) (*model.Post, error) {
+	return s.change(ctx, id, author, func(post *model.Post) error {
+		if post.Status == model.PostStatusArchived {
+			return nil
+		}
+		if unpublished(post) {
+			return InvalidInput("в архив можно перенести только опубликованный пост")
+		}
+		post.Status = model.PostStatusArchived
+		return nil
+	})
+}
+
+// PublishDue публикует запланированные посты, срок которых наступил, и
+// возвращает их число. Время публикации остается запланированным. Ошибка
+// публикации одного поста записывается в лог и не мешает остальным.
+func (s *PostService) PublishDue(ctx context.Context) (int, error) {
+	posts, err := s.deps.Store.GetDuePosts(ctx, s.deps.now())
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, candidate := range posts {
+		if err := ctx.Err(); err != nil {
+			return published, err
+		}
+
+		// Статус перечитывается в транзакции: автор мог успеть
+		// опубликовать или вернуть пост в черновики.
+		var post *model.Post
+		err := s.deps.Store.WithTx(ctx, func(tx storage.Storage) error {
+			existing, err := tx.GetPostForUpdate(ctx, candidate.ID)
+			if errors.Is(err, sql.ErrNoRows) {
+				// Пост удален после выборки
+				return nil
+			}
+			if err != nil || existing == nil || !due(existing, s.deps.now()) {
+				return err
+			}
+			updated := *existing
+			updated.Status = model.PostStatusPublished
+			if err := tx.UpdatePost(ctx, &updated); err != nil {
+				return err
+			}
+			post = &updated
+			return nil
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Ошибка публикации запланированного поста", slog.String("post_id", candidate.ID), slog.Any("error", err))
+			continue
+		}
+		if post != nil {
+			published++
+			s.deps.Events.Publish(events.Event{Type: events.PostCreated, PostID: post.ID, Post: post})
+		}
+	}
+	return published, nil
+}
+
+// SetCommentsAllowed включает или отключает комментарии к посту.
+func (s *PostService) SetCommentsAllowed(ctx context.Context, id string, allowed bool) (*model.Post, error) {
+	var post model.Post
+	err := s.deps.Store.WithTx(ctx, func(tx storage.Storage) error {
+		existing, err := tx.GetPostForUpdate(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrPostNotFound
+		}
+		if err != nil {
+			return err
+		}
+		post = *existing
+		post.CommentsAllowed = allowed
+		return tx.UpdatePost(ctx, &post)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.deps.Events.Publish(events.Event{Type: events.PostCommentsToggled, PostID: id, CommentsAllowed: allowed})
+	return &post, nil
+}
+
+// Delete удаляет пост вместе с комментариями.
+func (s *PostService) Delete(ctx context.Context, id string) error {
+	err := s.deps.Store.DeletePost(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrPostNotFound
+	}
+	return err
+}
+
+// Stats считает посты, комментарии и активные подписки. Блокировки авторов
+// сервис не ведет, BannedUsers заполняет вызывающий.
+func (s *PostService) Stats(ctx context.Context) (*model.Stats, error) {
+	posts, err := s.deps.Store.GetAllPosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats := &model.Stats{Posts: len(posts), Subscriptions: s.deps.Events.Subscribers()}
+	for _, post := range posts {
+		if !post.CommentsAllowed {
+			stats.LockedPosts++
+		}
+		comments, err := s.deps.Store.GetCommentsByPostID(ctx, post.ID, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		stats.Comments += len(comments)
+	}
+	return stats, nil
+}
+
+// WatchCreated подписывает на новые посты, опционально только от author.
+func (s *PostService) WatchCreated(ctx context.Context, author *string) <-chan *model.Post {
+	sub := s.deps.Events.Subscribe(ctx, func(e events.Event) bool {
+		return e.Type == events.PostCreated && (author == nil || e.Post.Author == *author)
+	})
+	return forward(ctx, sub, func(e events.Event) *model.Post { return e.Post })
+}
+
+// WatchEvents подписывает на все события поста: новые, изменённые и
+// удалённые комментарии, переключение режима «только для чтения». С since
+// сначала досылаются события после этого курсора.
+func (s *PostService) WatchEvents(ctx context.Context, postID string, since *model.Cursor) (<-chan model.PostEvent, error) {
+	sub, err := s.deps.Events.SubscribeSince(ctx, resumeCursor(ctx, since), func(e events.Event) bool {
+		return e.Type != events.PostCreated && e.PostID == postID
+	})
+	if err != nil {
+		return nil, err
+	}
+	return forward(ctx, sub, postEvent), nil
+}
+
+// change применяет apply к посту автора и сохраняет результат в одной
+// транзакции. Чужой пост, как и отсутствующий, — ErrPostNotFound, чтобы не
+// раскрывать чужие черновики.
+func (s *PostService) change(ctx context.Context, id, author string, apply func(post *model.Post) error) (*model.Post, error) {
+	var post model.Post
+	err := s.deps.Store.WithTx(ctx, func(tx storage.Storage) error {
+		existing, err := tx.GetPostForUpdate(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrPostNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if existing == nil || existing.Author != author {
+			return ErrPostNotFound
+		}
+		post = *existing
+		if err := apply(&post); err != nil {
+			return err
+		}
+		return tx.UpdatePost(ctx, &post)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+// unpublished сообщает, что пост еще не опубликован: черновик или
+// запланированный.
+func unpublished(post *model.Post) bool {
+	return post.Status == model.PostStatusDraft || post.Status == model.PostStatusScheduled
+}
+
+// ownedBy сообщает, что viewer — автор поста.
+func ownedBy(post *model.Post, viewer *string) bool {
+	return viewer != nil && post.Author == *viewer
+}
+
+// visible сообщает, виден ли пост читателю viewer: неопубликованные
+// посты видны только автору.
+func visible(post *model.Post, viewer *string) bool {
+	return !unpublished(post) || ownedBy(post, viewer)
+}
+
+// due сообщает, что срок публикации запланированного поста наступил.
+func due(post *model.Post, now time.Time) bool {
+	return post.Status == model.PostStatusScheduled && post.PublishAt != nil && !post.PublishAt.After(now)
+}
+
+// filter прогоняет пост через фильтры контента; переписанный фильтрами
+// текст сохраняется в пост, проверенный контент возвращается для
+// deps.accept после записи.
+func (s *PostService) filter(ctx context.Context, post *model.Post) (*filter.Content, error) {
+	if s.deps.Filter == nil {
+		return nil, nil
+	}
+
+	c := &filter.Content{
+		Kind:   filter.KindPost,
+		ID:     post.ID,
+		Author: post.Author,
+		Title:  post.Title,
+		Body:   post.Content,
+	}
+	if _, err := s.deps.Filter.Run(ctx, c); err != nil {
+		return nil, err
+	}
+	post.Title, post.Content = c.Title, c.Body
+	return c, nil
+}
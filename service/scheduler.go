@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// DefaultSchedulerInterval — период проверки запланированных постов по умолчанию.
+const DefaultSchedulerInterval = 10 * time.Second
+
+// Scheduler публикует запланированные посты, когда наступает их срок.
+// Расписание хранится в самих постах, поэтому после перезапуска сервиса
+// посты, срок которых прошел во время простоя, публикуются при первой проверке.
+type Scheduler struct {
+	posts    *PostService
+	interval time.Duration
+}
+
+// NewScheduler создает планировщик; interval <= 0 — DefaultSchedulerInterval.
+func NewScheduler(posts *PostService, interval time.Duration) *Scheduler {
+	if interval <= 0 {
+		interval = DefaultSchedulerInterval
+	}
+	return &Scheduler{posts: posts, interval: interval}
+}
+
+// Run проверяет запланированные посты сразу и затем каждые interval, пока
+// не отменен ctx.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := s.posts.PublishDue(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Ошибка публикации запланированных постов", slog.Any("error", err))
+		}
+		if n > 0 {
+			slog.InfoContext(ctx, "Опубликованы запланированные посты", slog.Int("count", n))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Package service — бизнес-логика постов и комментариев, общая для GraphQL,
+// REST и gRPC: проверки, генерация ID и времени, фильтры контента и события
+// подписок. Транспорты только разбирают запросы и вызывают сервисы.
+package service
+
+import (
+	"time"
+
+	"ozon_test/events"
+	"ozon_test/filter"
+	"ozon_test/storage"
+
+	"github.com/google/uuid"
+)
+
+// DefaultCommentMaxLength — ограничение длины комментария по умолчанию.
+const DefaultCommentMaxLength = 2000
+
+// Clock возвращает текущее время; в тестах подменяется фиксированным.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc позволяет использовать функцию как Clock.
+type ClockFunc func() time.Time
+
+func (f ClockFunc) Now() time.Time { return f() }
+
+// IDGenerator выдает идентификаторы новых постов и комментариев.
+type IDGenerator interface {
+	NewID() string
+}
+
+// IDFunc позволяет использовать функцию как IDGenerator.
+type IDFunc func() string
+
+func (f IDFunc) NewID() string { return f() }
+
+// Deps — зависимости сервисов.
+type Deps struct {
+	// Store — хранилище постов и комментариев; обязательно.
+	Store storage.Storage
+	// Events рассылает события мутаций подписчикам; nil — отдельный брокер.
+	Events *events.Broker
+	// Filter проверяет посты и комментарии перед сохранением; nil — без фильтрации.
+	Filter *filter.Pipeline
+	// Clock — источник времени создания; nil — системные часы.
+	Clock Clock
+	// IDs — генератор идентификаторов; nil — UUID v4.
+	IDs IDGenerator
+	// CommentMaxLength — максимальная длина комментария; 0 — DefaultCommentMaxLength.
+	CommentMaxLength int
+}
+
+// withDefaults заполняет незаданные зависимости значениями по умолчанию.
+func (d Deps) withDefaults() Deps {
+	if d.Events == nil {
+		d.Events = events.NewBroker(events.DefaultRetention)
+	}
+	if d.Clock == nil {
+		d.Clock = ClockFunc(time.Now)
+	}
+	if d.IDs == nil {
+		d.IDs = IDFunc(uuid.NewString)
+	}
+	if d.CommentMaxLength <= 0 {
+		d.CommentMaxLength = DefaultCommentMaxLength
+	}
+	return d
+}
+
+// New создает сервисы постов и комментариев с общими зависимостями: в
+// частности, с одним брокером событий, даже если Events не задан.
+func New(d Deps) (*PostService, *CommentService) {
+	d = d.withDefaults()
+	return &PostService{deps: d}, &CommentService{deps: d}
+}
+
+// NewPostService создает сервис постов.
+func NewPostService(d Deps) *PostService {
+	return &PostService{deps: d.withDefaults()}
+}
+
+// NewCommentService создает сервис комментариев.
+func NewCommentService(d Deps) *CommentService {
+	return &CommentService{deps: d.withDefaults()}
+}
+
+// now возвращает время создания записи: в UTC и с точностью до
+// микросекунды — столько хранит TIMESTAMPTZ в PostgreSQL, поэтому время и
+// порядок записей одинаковы во всех хранилищах.
+func (d Deps) now() time.Time {
+	return d.Clock.Now().UTC().Truncate(time.Microsecond)
+}
+
+// accept сообщает фильтрам, что проверенный ими контент c сохранен.
+func (d Deps) accept(c *filter.Content) {
+	if d.Filter != nil && c != nil {
+		d.Filter.Accept(c)
+	}
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+
+	"ozon_test/events"
+	"ozon_test/graph/model"
+)
+
+// postEvent преобразует событие брокера в тип объединения PostEvent.
+func postEvent(e events.Event) model.PostEvent {
+	switch e.Type {
+	case events.CommentAdded:
+		return &model.CommentAddedEvent{Cursor: e.Cursor, Comment: e.Comment}
+	case events.CommentUpdated:
+		return &model.CommentUpdatedEvent{Cursor: e.Cursor, Comment: e.Comment}
+	case events.CommentDeleted:
+		return &model.CommentDeletedEvent{Cursor: e.Cursor, PostID: e.PostID, CommentID: e.CommentID}
+	case events.PostCommentsToggled:
+		return &model.PostCommentsToggledEvent{Cursor: e.Cursor, PostID: e.PostID, CommentsAllowed: e.CommentsAllowed}
+	}
+	return nil
+}
+
+// resumeCursor возвращает курсор, с которого продолжить подписку: явный
+// аргумент since или курсор, переданный транспортом (Last-Event-ID).
+func resumeCursor(ctx context.Context, since *model.Cursor) *model.Cursor {
+	if since != nil {
+		return since
+	}
+	return events.ResumeFrom(ctx)
+}
+
+// forward пересылает события подписки в канал, преобразуя их функцией
+// convert. Канал закрывается при отмене ctx. Если транспорт отслеживает
+// курсоры, курсор события сообщается ему до отправки.
+func forward[T any](ctx context.Context, sub <-chan events.Event, convert func(events.Event) T) <-chan T {
+	tracker := events.TrackerFrom(ctx)
+	out := make(chan T, 1)
+	go func() {
+		defer close(out)
+		for e := range sub {
+			if tracker != nil {
+				tracker.Push(e.Cursor)
+			}
+			select {
+			case out <- convert(e):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,215 @@
+// Package sse реализует транспорт GraphQL-подписок поверх Server-Sent Events
+// по протоколу graphql-sse (режим отдельных соединений).
+package sse
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"ozon_test/events"
+	"ozon_test/graph/model"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Transport принимает операции с заголовком Accept: text/event-stream.
+// GET-запросы (для EventSource) передают операцию в параметрах query,
+// variables, operationName и extensions и допускают только подписки:
+// иначе страница с чужого сайта могла бы выполнить мутацию через
+// EventSource. POST передает операцию в JSON-теле. Каждый ответ
+// подписки отправляется событием next с id, равным курсору события, а
+// переподключение с Last-Event-ID досылает пропущенные события.
+type Transport struct {
+	// Heartbeat — интервал комментариев-пингов, не дающих прокси закрыть
+	// простаивающее соединение; 0 — без пингов.
+	Heartbeat time.Duration
+}
+
+var _ graphql.Transport = Transport{}
+
+func (t Transport) Supports(r *http.Request) bool {
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		return false
+	}
+	switch r.Method {
+	case http.MethodGet:
+		return r.Header.Get("Upgrade") == ""
+	case http.MethodPost:
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		return err == nil && mediaType == "application/json"
+	}
+	return false
+}
+
+func (t Transport) Do(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecutor) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	ctx := r.Context()
+	start := graphql.Now()
+	params, err := readParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, exec.DispatchError(ctx, gqlerror.List{gqlerror.Errorf("%s", err)}))
+		return
+	}
+	params.Headers = r.Header
+	params.ReadTime = graphql.TraceTiming{Start: start, End: graphql.Now()}
+
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		if cursor, err := model.ParseCursor(id); err == nil {
+			ctx = events.WithResume(ctx, cursor)
+		}
+	}
+	ctx, tracker := events.WithTracker(ctx)
+
+	rc, opErr := exec.CreateOperationContext(ctx, params)
+	if opErr == nil && r.Method == http.MethodGet && rc.Operation.Operation != ast.Subscription {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, exec.DispatchError(graphql.WithOperationContext(ctx, rc),
+			gqlerror.List{gqlerror.Errorf("GET-запросом можно выполнить только подписку")}))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	s := &stream{w: w, f: flusher}
+	s.comment("")
+
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+	if t.Heartbeat > 0 {
+		wg.Add(1)
+		go func(ctx context.Context) {
+			defer wg.Done()
+			s.heartbeat(ctx, t.Heartbeat)
+		}(ctx)
+	}
+
+	if opErr != nil {
+		s.next("", exec.DispatchError(graphql.WithOperationContext(ctx, rc), opErr))
+		s.complete()
+		return
+	}
+
+	ctx = graphql.WithOperationContext(ctx, rc)
+	responses, ctx := exec.DispatchOperation(ctx, rc)
+	for {
+		resp := responses(ctx)
+		if resp == nil {
+			break
+		}
+		id := ""
+		if cursor, ok := tracker.Pop(); ok {
+			id = cursor.String()
+		}
+		s.next(id, resp)
+	}
+	s.complete()
+}
+
+// writeError отвечает ошибкой в JSON до начала потока событий.
+func writeError(w http.ResponseWriter, status int, resp *graphql.Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// readParams извлекает параметры операции из URL (GET) или тела (POST).
+func readParams(r *http.Request) (*graphql.RawParams, error) {
+	params := &graphql.RawParams{}
+	if r.Method == http.MethodPost {
+		dec := json.NewDecoder(r.Body)
+		dec.UseNumber()
+		if err := dec.Decode(params); err != nil {
+			return nil, fmt.Errorf("тело запроса не является корректным JSON: %w", err)
+		}
+		return params, nil
+	}
+
+	q := r.URL.Query()
+	params.Query = q.Get("query")
+	params.OperationName = q.Get("operationName")
+	for name, dst := range map[string]any{"variables": &params.Variables, "extensions": &params.Extensions} {
+		raw := q.Get(name)
+		if raw == "" {
+			continue
+		}
+		dec := json.NewDecoder(strings.NewReader(raw))
+		dec.UseNumber()
+		if err := dec.Decode(dst); err != nil {
+			return nil, fmt.Errorf("параметр %s не является корректным JSON: %w", name, err)
+		}
+	}
+	return params, nil
+}
+
+// stream сериализует запись событий: пинги пишутся из отдельной горутины.
+type stream struct {
+	mu sync.Mutex
+	w  http.ResponseWriter
+	f  http.Flusher
+}
+
+func (s *stream) write(b []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, _ = s.w.Write(b)
+	s.f.Flush()
+}
+
+func (s *stream) comment(text string) {
+	s.write([]byte(":" + text + "\n\n"))
+}
+
+func (s *stream) next(id string, resp *graphql.Response) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		data, _ = json.Marshal(&graphql.Response{Errors: gqlerror.List{gqlerror.Errorf("%s", err)}})
+	}
+
+	var b bytes.Buffer
+	if id != "" {
+		fmt.Fprintf(&b, "id: %s\n", id)
+	}
+	fmt.Fprintf(&b, "event: next\ndata: %s\n\n", data)
+	s.write(b.Bytes())
+}
+
+func (s *stream) complete() {
+	s.write([]byte("event: complete\ndata:\n\n"))
+}
+
+func (s *stream) heartbeat(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.comment(" ping")
+		}
+	}
+}
@@ -1,155 +1,968 @@
-package storage
-
-import (
-	"database/sql"
-	"ozon_test/graph/model"
-)
-
-// PostgresStorage реализует интерфейс хранилища с использованием PostgreSQL.
-type PostgresStorage struct {
-	DB *sql.DB
-}
-
-// NewPostgresStorage создает подключение к PostgreSQL по переданному DSN.
-func NewPostgresStorage(dsn string) (*PostgresStorage, error) {
-	db, err := sql.Open("postgres", dsn)
-	if err != nil {
-		return nil, err
-	}
-
-	if err := db.Ping(); err != nil {
-		return nil, err
-	}
-	return &PostgresStorage{DB: db}, nil
-}
-
-// GetPostByID возвращает пост по его ID.
-func (p *PostgresStorage) GetPostByID(id string) (*model.Post, error) {
-	const query = `
-		SELECT id, title, content, author, comments_allowed, created_at
-		FROM posts
-		WHERE id = $1
-	`
-	row := p.DB.QueryRow(query, id)
-
-	var post model.Post
-	err := row.Scan(
-		&post.ID,
-		&post.Title,
-		&post.Content,
-		&post.Author,
-		&post.CommentsAllowed,
-		&post.CreatedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	return &post, nil
-}
-
-// GetAllPosts возвращает все посты из базы данных.
-func (p *PostgresStorage) GetAllPosts() ([]*model.Post, error) {
-	const query = `
-		SELECT id, title, content, author, comments_allowed, created_at
-		FROM posts
-	`
-	rows, err := p.DB.Query(query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var posts []*model.Post
-	for rows.Next() {
-		var post model.Post
-		if err := rows.Scan(
-			&post.ID,
-			&post.Title,
-			&post.Content,
-			&post.Author,
-			&post.CommentsAllowed,
-			&post.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		posts = append(posts, &post)
-	}
-
-	return posts, nil
-}
-
-func (p *PostgresStorage) UpdatePost(post *model.Post) error {
-	query := `UPDATE posts SET 
-        comments_allowed = $1 
-        WHERE id = $2`
-	_, err := p.DB.Exec(query, post.CommentsAllowed, post.ID)
-	return err
-}
-
-// CreatePost сохраняет новый пост в базе данных.
-func (p *PostgresStorage) CreatePost(post *model.Post) error {
-	const query = `
-		INSERT INTO posts (id, title, content, author, comments_allowed, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
-	`
-	_, err := p.DB.Exec(query,
-		post.ID,
-		post.Title,
-		post.Content,
-		post.Author,
-		post.CommentsAllowed,
-		post.CreatedAt,
-	)
-	return err
-}
-
-// CreateComment сохраняет комментарий к посту.
-func (p *PostgresStorage) CreateComment(comment *model.Comment) error {
-	const query = `
-		INSERT INTO comments (id, post_id, content, author, created_at)
-		VALUES ($1, $2, $3, $4, $5)
-	`
-	_, err := p.DB.Exec(query,
-		comment.ID,
-		comment.PostID,
-		comment.Content,
-		comment.Author,
-		comment.CreatedAt,
-	)
-	return err
-}
-
-// GetCommentsByPostID возвращает список комментариев к посту с пагинацией.
-func (p *PostgresStorage) GetCommentsByPostID(postID string, limit, offset int) ([]*model.Comment, error) {
-	const query = `
-		SELECT id, post_id, content, author, created_at
-		FROM comments
-		WHERE post_id = $1
-		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
-	`
-
-	rows, err := p.DB.Query(query, postID, limit, offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var comments []*model.Comment
-	for rows.Next() {
-		var comment model.Comment
-		if err := rows.Scan(
-			&comment.ID,
-			&comment.PostID,
-			&comment.Content,
-			&comment.Author,
-			&comment.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		comments = append(comments, &comment)
-	}
-
-	return comments, nil
-}
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"ozon_test/config"
+	"ozon_test/graph/model"
+	"time"
+
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// PostgresStorage реализует интерфейс хранилища с использованием PostgreSQL.
+type PostgresStorage struct {
+	DB *sql.DB
+	// tx — текущая транзакция; задана у хранилища, переданного в WithTx.
+	tx *sql.Tx
+
+	// Реплики для чтения; записи всегда идут в DB (primary).
+	replicas *replicaSet
+	// Чтения повторяются при временных ошибках: они идемпотентны.
+	readRetries  int
+	retryBackoff Backoff
+}
+
+// connectBackoff — паузы между попытками подключения при запуске.
+var connectBackoff = Backoff{Initial: 200 * time.Millisecond, Max: 5 * time.Second}
+
+// NewPostgresStorage создает пулы соединений с primary и репликами и ждет,
+// пока primary станет доступен: временные ошибки повторяются
+// с экспоненциальной паузой в пределах cfg.ConnectTimeout. Недоступные
+// реплики запуск не блокируют — они исключаются при первой ошибке.
+// Затем на primary применяются недостающие миграции из пакета migrations.
+func NewPostgresStorage(ctx context.Context, cfg config.StorageConfig) (*PostgresStorage, error) {
+	db, err := openPool(cfg.DSN, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	connectCtx := ctx
+	if cfg.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		connectCtx, cancel = context.WithTimeout(ctx, cfg.ConnectTimeout)
+		defer cancel()
+	}
+	attempt := 0
+	err = connectBackoff.Retry(connectCtx, 0, func(ctx context.Context) error {
+		attempt++
+		err := db.PingContext(ctx)
+		if err != nil && IsTransient(err) {
+			slog.WarnContext(ctx, "PostgreSQL недоступен, повторяем подключение",
+				slog.Int("attempt", attempt), slog.Any("error", err))
+		}
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("PostgreSQL недоступен после %d попыток: %w", attempt, err)
+	}
+	if err := migratePostgres(ctx, db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("миграции PostgreSQL: %w", err)
+	}
+
+	replicas := make([]*sql.DB, 0, len(cfg.ReplicaDSNs))
+	for _, dsn := range cfg.ReplicaDSNs {
+		replica, err := openPool(dsn, cfg)
+		if err != nil {
+			db.Close()
+			for _, r := range replicas {
+				r.Close()
+			}
+			return nil, fmt.Errorf("реплика PostgreSQL: %w", err)
+		}
+		replicas = append(replicas, replica)
+	}
+
+	return NewPostgresStorageFromDB(db, replicas, cfg), nil
+}
+
+// NewPostgresStorageFromDB собирает хранилище из готовых пулов primary
+// и реплик. Пулы закрываются вместе с хранилищем.
+func NewPostgresStorageFromDB(primary *sql.DB, replicas []*sql.DB, cfg config.StorageConfig) *PostgresStorage {
+	return &PostgresStorage{
+		DB:           primary,
+		replicas:     newReplicaSet(replicas, cfg.ReplicaCheckInterval),
+		readRetries:  max(cfg.ReadRetries, 1),
+		retryBackoff: Backoff{Initial: cfg.RetryBackoff, Max: time.Second},
+	}
+}
+
+// openPool открывает пул соединений с параметрами из конфигурации.
+func openPool(dsn string, cfg config.StorageConfig) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	return db, nil
+}
+
+// querier — общие методы *sql.DB и *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// writer возвращает соединение для записи: транзакцию или primary.
+func (p *PostgresStorage) writer() querier {
+	if p.tx != nil {
+		return p.tx
+	}
+	return p.DB
+}
+
+// read выполняет идемпотентное чтение на реплике (или на primary, если
+// здоровых реплик нет либо контекст требует свежих данных), повторяя его
+// при временных ошибках. Реплика с временной ошибкой исключается,
+// и чтение сразу переходит на следующую. В транзакции чтение выполняется
+// в ней без повторов: после ошибки транзакция всё равно прервана.
+func (p *PostgresStorage) read(ctx context.Context, fn func(ctx context.Context, db querier) error) error {
+	if p.tx != nil {
+		return fn(ctx, p.tx)
+	}
+	return p.retryBackoff.Retry(ctx, p.readRetries, func(ctx context.Context) error {
+		for {
+			r := p.reader(ctx)
+			if r == nil {
+				return fn(ctx, p.DB)
+			}
+			trace.SpanFromContext(ctx).SetAttributes(attribute.String("db.replica", r.name))
+			err := fn(ctx, r.db)
+			if !IsTransient(err) {
+				return err
+			}
+			p.replicas.eject(r, err)
+		}
+	})
+}
+
+// reader выбирает реплику для чтения; nil — читать с primary.
+func (p *PostgresStorage) reader(ctx context.Context) *replica {
+	if readFromPrimary(ctx) {
+		return nil
+	}
+	return p.replicas.pick()
+}
+
+// Replicas возвращает число здоровых и общее число реплик.
+func (p *PostgresStorage) Replicas() (healthy, total int) {
+	return p.replicas.healthyCount(), len(p.replicas.replicas)
+}
+
+// ReplicaDBs возвращает пулы реплик, например для метрик.
+func (p *PostgresStorage) ReplicaDBs() []*sql.DB {
+	dbs := make([]*sql.DB, 0, len(p.replicas.replicas))
+	for _, r := range p.replicas.replicas {
+		dbs = append(dbs, r.db)
+	}
+	return dbs
+}
+
+// Ping проверяет доступность PostgreSQL.
+func (p *PostgresStorage) Ping(ctx context.Context) error {
+	return p.DB.PingContext(ctx)
+}
+
+// MigrationVersion возвращает номер последней применённой миграции из
+// schema_migrations. Без таблицы (база не проходила через runner миграций)
+// версия — 0 с предупреждением в логе: схема может быть рабочей, поэтому
+// готовность из-за этого не снимается.
+func (p *PostgresStorage) MigrationVersion(ctx context.Context) (int, error) {
+	var version int
+	err := p.DB.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "42P01" { // undefined_table
+		slog.WarnContext(ctx, "Таблица schema_migrations не найдена, версия схемы считается 0")
+		return 0, nil
+	}
+	return version, err
+}
+
+// WithTx выполняет fn в транзакции на primary. Внутри транзакции
+// GetPostByID блокирует пост через SELECT ... FOR SHARE, а
+// GetPostForUpdate — через FOR UPDATE, поэтому проверка поста и
+// последующая запись не разойдутся с параллельным изменением поста.
+// Вложенный вызов выполняется в той же транзакции.
+func (p *PostgresStorage) WithTx(ctx context.Context, fn func(tx Storage) error) (err error) {
+	if p.tx != nil {
+		return fn(p)
+	}
+
+	ctx, span := startSpan(ctx, "WithTx", "BEGIN")
+	defer func() { endSpan(span, err) }()
+	markWrite(ctx)
+
+	tx, err := p.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	txStorage := *p
+	txStorage.tx = tx
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+	if err := fn(&txStorage); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+			slog.WarnContext(ctx, "Ошибка отката транзакции", slog.Any("error", rbErr))
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// Close закрывает пулы соединений с primary и репликами. У хранилища
+// транзакции ничего не делает: пулы закрывает владелец.
+func (p *PostgresStorage) Close() error {
+	if p.tx != nil {
+		return nil
+	}
+	replicaErr := p.replicas.close()
+	if err := p.DB.Close(); err != nil {
+		return err
+	}
+	return replicaErr
+}
+
+// GetPostByID возвращает пост по его ID.
+func (p *PostgresStorage) GetPostByID(ctx context.Context, id string) (_ *model.Post, err error) {
+	const query = `
+		SELECT ` + postColumns + `
+		FROM posts
+		WHERE id = $1
+	`
+	q := query
+	if p.tx != nil {
+		q += "FOR SHARE"
+	}
+	ctx, span := startSpan(ctx, "GetPostByID", q)
+	defer func() { endSpan(span, err) }()
+
+	var post *model.Post
+	err = p.read(ctx, func(ctx context.Context, db querier) (err error) {
+		post, err = scanPost(db.QueryRowContext(ctx, q, id))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return post, nil
+}
+
+// GetPostForUpdate возвращает пост для изменения. В транзакции строка
+// блокируется FOR UPDATE: с FOR SHARE два писателя одного поста держали бы
+// разделяемую блокировку и взаимно блокировались бы на UPDATE.
+func (p *PostgresStorage) GetPostForUpdate(ctx context.Context, id string) (_ *model.Post, err error) {
+	if p.tx == nil {
+		return p.GetPostByID(ctx, id)
+	}
+	const query = `
+		SELECT ` + postColumns + `
+		FROM posts
+		WHERE id = $1
+		FOR UPDATE
+	`
+	ctx, span := startSpan(ctx, "GetPostForUpdate", query)
+	defer func() { endSpan(span, err) }()
+
+	return scanPost(p.tx.QueryRowContext(ctx, query, id))
+}
+
+// GetDuePosts возвращает запланированные посты со сроком не позже now;
+// выборка идет по частичному индексу idx_posts_scheduled.
+func (p *PostgresStorage) GetDuePosts(ctx context.Context, now time.Time) (_ []*model.Post, err error) {
+	const query = `
+		SELECT ` + postColumns + `
+		FROM posts
+		WHERE status = 'SCHEDULED' AND publish_at <= $1
+		ORDER BY publish_at
+	`
+	ctx, span := startSpan(ctx, "GetDuePosts", query)
+	defer func() { endSpan(span, err) }()
+
+	var posts []*model.Post
+	err = p.read(ctx, func(ctx context.Context, db querier) error {
+		rows, err := db.QueryContext(ctx, query, now)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		posts = nil
+		for rows.Next() {
+			post, err := scanPost(rows)
+			if err != nil {
+				return err
+			}
+			posts = append(posts, post)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// GetAllPosts возвращает все посты из базы данных.
+func (p *PostgresStorage) GetAllPosts(ctx context.Context) (_ []*model.Post, err error) {
+	const query = `
+		SELECT ` + postColumns + `
+		FROM posts
+	`
+	ctx, span := startSpan(ctx, "GetAllPosts", query)
+	defer func() { endSpan(span, err) }()
+
+	var posts []*model.Post
+	err = p.read(ctx, func(ctx context.Context, db querier) error {
+		rows, err := db.QueryContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		posts = nil
+		for rows.Next() {
+			post, err := scanPost(rows)
+			if err != nil {
+				return err
+			}
+			posts = append(posts, post)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// UpdatePost обновляет заголовок, текст, режим комментирования, статус и
+// время публикации поста; автор и время создания не меняются.
+func (p *PostgresStorage) UpdatePost(ctx context.Context, post *model.Post) (err error) {
+	const query = `UPDATE posts SET title = $1, content = $2, comments_allowed = $3, status = $4, publish_at = $5 WHERE id = $6`
+	ctx, span := startSpan(ctx, "UpdatePost", query)
+	defer func() { endSpan(span, err) }()
+	markWrite(ctx)
+
+	res, err := p.writer().ExecContext(ctx, query, post.Title, post.Content, post.CommentsAllowed, postStatus(post), post.PublishAt, post.ID)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+// DeletePost удаляет пост; комментарии удаляются каскадно.
+func (p *PostgresStorage) DeletePost(ctx context.Context, id string) (err error) {
+	const query = `DELETE FROM posts WHERE id = $1`
+	ctx, span := startSpan(ctx, "DeletePost", query)
+	defer func() { endSpan(span, err) }()
+	markWrite(ctx)
+
+	res, err := p.writer().ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+// CreatePost сохраняет новый пост в базе данных.
+func (p *PostgresStorage) CreatePost(ctx context.Context, post *model.Post) (err error) {
+	const query = `
+		INSERT INTO posts (id, title, content, author, comments_allowed, created_at, status, publish_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	ctx, span := startSpan(ctx, "CreatePost", query)
+	defer func() { endSpan(span, err) }()
+	markWrite(ctx)
+
+	_, err = p.writer().ExecContext(ctx, query,
+		post.ID,
+		post.Title,
+		post.Content,
+		post.Author,
+		post.CommentsAllowed,
+		post.CreatedAt,
+		postStatus(post),
+		post.PublishAt,
+	)
+	return err
+}
+
+// CreateComment сохраняет комментарий вместе со ссылкой на родителя.
+func (p *PostgresStorage) CreateComment(ctx context.Context, comment *model.Comment) (err error) {
+	const query = `
+		INSERT INTO comments (id, post_id, parent_id, content, author, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	ctx, span := startSpan(ctx, "CreateComment", query)
+	defer func() { endSpan(span, err) }()
+	markWrite(ctx)
+
+	_, err = p.writer().ExecContext(ctx, query,
+		comment.ID,
+		comment.PostID,
+		comment.ParentID,
+		comment.Content,
+		comment.Author,
+		comment.CreatedAt,
+	)
+	return err
+}
+
+// GetCommentsByPostID возвращает страницу комментариев к посту от новых
+// к старым; limit <= 0 — без ограничения.
+func (p *PostgresStorage) GetCommentsByPostID(ctx context.Context, postID string, limit, offset int) (_ []*model.Comment, err error) {
+	const query = `
+		SELECT ` + commentColumns + `
+		FROM comments
+		WHERE post_id = $1
+		ORDER BY created_at DESC, id
+		LIMIT $2 OFFSET $3
+	`
+	ctx, span := startSpan(ctx, "GetCommentsByPostID", query)
+	defer func() { endSpan(span, err) }()
+
+	// LIMIT NULL в PostgreSQL — без ограничения
+	var pageLimit any
+	if limit > 0 {
+		pageLimit = limit
+	}
+
+	comments := []*model.Comment{}
+	err = p.read(ctx, func(ctx context.Context, db querier) error {
+		rows, err := db.QueryContext(ctx, query, postID, pageLimit, offset)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		comments = comments[:0]
+		for rows.Next() {
+			comment, err := scanComment(rows)
+			if err != nil {
+				return err
+			}
+			comments = append(comments, comment)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// GetCommentsAfter возвращает комментарии поста после позиции (after,
+// afterID) по возрастанию (created_at, id).
+func (p *PostgresStorage) GetCommentsAfter(ctx context.Context, postID string, after time.Time, afterID string) (_ []*model.Comment, err error) {
+	const query = `
+		SELECT ` + commentColumns + `
+		FROM comments
+		WHERE post_id = $1 AND (created_at, id) > ($2, $3::uuid)
+		ORDER BY created_at, id
+	`
+	ctx, span := startSpan(ctx, "GetCommentsAfter", query)
+	defer func() { endSpan(span, err) }()
+
+	comments := []*model.Comment{}
+	err = p.read(ctx, func(ctx context.Context, db querier) error {
+		rows, err := db.QueryContext(ctx, query, postID, after, afterID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		comments = comments[:0]
+		for rows.Next() {
+			comment, err := scanComment(rows)
+			if err != nil {
+				return err
+			}
+			comments = append(comments, comment)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// GetCommentByID возвращает комментарий по его ID.
+func (p *PostgresStorage) GetCommentByID(ctx context.Context, id string) (_ *model.Comment, err error) {
+	const query = `SELECT ` + commentColumns + ` FROM comments WHERE id = $1`
+	ctx, span := startSpan(ctx, "GetCommentByID", query)
+	defer func() { endSpan(span, err) }()
+
+	var comment *model.Comment
+	err = p.read(ctx, func(ctx context.Context, db querier) error {
+		comment, err = scanComment(db.QueryRowContext(ctx, query, id))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// UpdateComment обновляет текст комментария.
+func (p *PostgresStorage) UpdateComment(ctx context.Context, comment *model.Comment) (err error) {
+	const query = `UPDATE comments SET content = $1 WHERE id = $2`
+	ctx, span := startSpan(ctx, "UpdateComment", query)
+	defer func() { endSpan(span, err) }()
+	markWrite(ctx)
+
+	res, err := p.writer().ExecContext(ctx, query, comment.Content, comment.ID)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+// DeleteComment удаляет комментарий; ответы удаляются каскадно.
+func (p *PostgresStorage) DeleteComment(ctx context.Context, id string) (err error) {
+	const query = `DELETE FROM comments WHERE id = $1`
+	ctx, span := startSpan(ctx, "DeleteComment", query)
+	defer func() { endSpan(span, err) }()
+	markWrite(ctx)
+
+	res, err := p.writer().ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+// expectAffected возвращает sql.ErrNoRows, если запрос не затронул ни одной строки.
+func expectAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// postColumns — колонки поста в PostgreSQL и SQLite.
+const postColumns = `id, title, content, author, comments_allowed, created_at, status, publish_at`
+
+// scanPost читает строку с колонками postColumns.
+func scanPost(row interface{ Scan(...any) error }) (*model.Post, error) {
+	var post model.Post
+	if err := row.Scan(
+		&post.ID,
+		&post.Title,
+		&post.Content,
+		&post.Author,
+		&post.CommentsAllowed,
+		timeColumn{&post.CreatedAt},
+		&post.Status,
+		nullTimeColumn{&post.PublishAt},
+	); err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+// postStatus возвращает статус для записи: пост без статуса, созданный в
+// обход сервиса, считается опубликованным.
+func postStatus(post *model.Post) model.PostStatus {
+	if post.Status == "" {
+		return model.PostStatusPublished
+	}
+	return post.Status
+}
+
+// commentColumns — колонки комментария в PostgreSQL и SQLite.
+const commentColumns = `id, post_id, parent_id, content, author, created_at`
+
+// scanComment читает строку с колонками commentColumns.
+func scanComment(row interface{ Scan(...any) error }) (*model.Comment, error) {
+	var (
+		comment  model.Comment
+		parentID sql.NullString
+	)
+	if err := row.Scan(
+		&comment.ID,
+		&comment.PostID,
+		&parentID,
+		&comment.Content,
+		&comment.Author,
+		timeColumn{&comment.CreatedAt},
+	); err != nil {
+		return nil, err
+	}
+	if parentID.Valid {
+		comment.ParentID = &parentID.String
+	}
+	return &comment, nil
+}
+
+// timeColumn сканирует created_at в time.Time (UTC): PostgreSQL отдает
+// TIMESTAMPTZ как time.Time в поясе сессии, SQLite — текст RFC 3339.
+type timeColumn struct{ t *time.Time }
+
+func (c timeColumn) Scan(src any) error {
+	switch v := src.(type) {
+	case time.Time:
+		*c.t = v.UTC()
+		return nil
+	case string:
+		return c.parse(v)
+	case []byte:
+		return c.parse(string(v))
+	}
+	return fmt.Errorf("неподдерживаемый тип времени %T", src)
+}
+
+func (c timeColumn) parse(s string) error {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return fmt.Errorf("некорректное время %q: %w", s, err)
+	}
+	*c.t = t.UTC()
+	return nil
+}
+
+// nullTimeColumn сканирует необязательное время: NULL — nil.
+type nullTimeColumn struct{ t **time.Time }
+
+func (c nullTimeColumn) Scan(src any) error {
+	if src == nil {
+		*c.t = nil
+		return nil
+	}
+	var t time.Time
+	if err := (timeColumn{&t}).Scan(src); err != nil {
+		return err
+	}
+	*c.t = &t
+	return nil
+}
+
+// changeLogLockKey — ключ advisory-блокировки выдачи позиций ленты.
+const changeLogLockKey = 0x6f7a6f6f
+
+// LastChangeSeq возвращает позицию последнего выданного изменения на primary.
+func (p *PostgresStorage) LastChangeSeq(ctx context.Context) (_ int64, err error) {
+	ctx, span := startSpan(ctx, "LastChangeSeq", lastChangePosQuery)
+	defer func() { endSpan(span, err) }()
+
+	return lastChangePos(ctx, p.DB)
+}
+
+// Changes выдает позиции завершённым изменениям и читает ленту по ним
+// с primary: реплики могут отставать. Позиция, а не seq, задает порядок
+// ленты — см. migrations/0008_change_log_commit_order.sql.
+func (p *PostgresStorage) Changes(ctx context.Context, after int64, limit int) (_ []Change, err error) {
+	const query = `SELECT pos, entity, entity_id FROM change_log WHERE pos > $1 ORDER BY pos LIMIT $2`
+	ctx, span := startSpan(ctx, "Changes", query)
+	defer func() { endSpan(span, err) }()
+
+	tx, err := p.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if err := assignChangePositions(ctx, tx); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return readChanges(ctx, p.DB, query, after, limit)
+}
+
+// assignChangePositions выдает позиции строкам ленты, записанным
+// транзакциями ниже xmin текущего снимка: все они завершены, и новых строк
+// с таким xid уже не появится. Выдача идет под advisory-блокировкой до
+// фиксации, поэтому позиции видны потребителям в порядке возрастания.
+func assignChangePositions(ctx context.Context, tx *sql.Tx) error {
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, changeLogLockKey); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, `
+		UPDATE change_log SET pos = nextval('change_log_pos')
+		WHERE pos IS NULL AND xid < pg_snapshot_xmin(pg_current_snapshot())`)
+	return err
+}
+
+// TrackChanges регистрирует потребителя ленты. SHARE-блокировка таблиц
+// дожидается транзакций, начавших запись до регистрации, а следующие
+// записи уже видят потребителя, поэтому ни одно изменение после
+// возвращённой позиции не пропадает из ленты. Строки, которым позиция
+// еще не выдана, получат ее позже и будут применены повторно — это
+// безопасно, изменённая сущность читается из источника целиком.
+func (p *PostgresStorage) TrackChanges(ctx context.Context, consumer string) (seq int64, err error) {
+	const query = `
+		INSERT INTO change_log_consumers (name, seq) VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE SET seq = EXCLUDED.seq, updated_at = CURRENT_TIMESTAMP`
+	ctx, span := startSpan(ctx, "TrackChanges", query)
+	defer func() { endSpan(span, err) }()
+
+	tx, err := p.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, `LOCK TABLE posts, comments IN SHARE MODE`); err != nil {
+		return 0, err
+	}
+	if err = assignChangePositions(ctx, tx); err != nil {
+		return 0, err
+	}
+	if seq, err = lastChangePos(ctx, tx); err != nil {
+		return 0, err
+	}
+	if _, err = tx.ExecContext(ctx, query, consumer, seq); err != nil {
+		return 0, err
+	}
+	return seq, tx.Commit()
+}
+
+// AckChanges запоминает позицию потребителя и очищает ленту.
+func (p *PostgresStorage) AckChanges(ctx context.Context, consumer string, upTo int64) (err error) {
+	const query = `UPDATE change_log_consumers SET seq = $2, updated_at = CURRENT_TIMESTAMP WHERE name = $1`
+	ctx, span := startSpan(ctx, "AckChanges", query)
+	defer func() { endSpan(span, err) }()
+
+	if _, err := p.DB.ExecContext(ctx, query, consumer, upTo); err != nil {
+		return err
+	}
+	return trimChanges(ctx, p.DB, trimChangesPostgres)
+}
+
+// UntrackChanges снимает потребителя и очищает ленту.
+func (p *PostgresStorage) UntrackChanges(ctx context.Context, consumer string) (err error) {
+	const query = `DELETE FROM change_log_consumers WHERE name = $1`
+	ctx, span := startSpan(ctx, "UntrackChanges", query)
+	defer func() { endSpan(span, err) }()
+
+	if _, err := p.DB.ExecContext(ctx, query, consumer); err != nil {
+		return err
+	}
+	return trimChanges(ctx, p.DB, trimChangesPostgres)
+}
+
+// Запросы очистки ленты: удаляются изменения, которые применили все
+// потребители, а без потребителей — вся лента. В PostgreSQL строки без
+// позиции еще никому не выданы и остаются, пока есть потребители.
+const (
+	trimChangesPostgres = `
+		DELETE FROM change_log
+		WHERE NOT EXISTS (SELECT 1 FROM change_log_consumers c WHERE change_log.pos IS NULL OR c.seq < change_log.pos)`
+	trimChangesSQLite = `
+		DELETE FROM change_log
+		WHERE NOT EXISTS (SELECT 1 FROM change_log_consumers c WHERE c.seq < change_log.seq)`
+)
+
+func trimChanges(ctx context.Context, db querier, query string) error {
+	_, err := db.ExecContext(ctx, query)
+	return err
+}
+
+const lastChangePosQuery = `SELECT COALESCE(MAX(pos), 0) FROM change_log`
+
+func lastChangePos(ctx context.Context, db querier) (int64, error) {
+	var pos int64
+	err := db.QueryRowContext(ctx, lastChangePosQuery).Scan(&pos)
+	return pos, err
+}
+
+const lastChangeSeqQuery = `SELECT COALESCE(MAX(seq), 0) FROM change_log`
+
+func lastChangeSeq(ctx context.Context, db querier) (int64, error) {
+	var seq int64
+	err := db.QueryRowContext(ctx, lastChangeSeqQuery).Scan(&seq)
+	return seq, err
+}
+
+func readChanges(ctx context.Context, db querier, query string, after int64, limit int) ([]Change, error) {
+	rows, err := db.QueryContext(ctx, query, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []Change
+	for rows.Next() {
+		var c Change
+		if err := rows.Scan(&c.Seq, &c.Entity, &c.ID); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// ListBans читает блокировки с primary: только что добавленная блокировка
+// могла еще не дойти до реплик.
+func (p *PostgresStorage) ListBans(ctx context.Context) (_ []*model.BannedUser, err error) {
+	const query = `SELECT author, reason, banned_at FROM banned_users`
+	ctx, span := startSpan(ctx, "ListBans", query)
+	defer func() { endSpan(span, err) }()
+
+	return readBans(ctx, p.writer(), query)
+}
+
+// SaveBan добавляет или обновляет блокировку автора.
+func (p *PostgresStorage) SaveBan(ctx context.Context, key string, ban *model.BannedUser) (err error) {
+	const query = `
+		INSERT INTO banned_users (author_key, author, reason, banned_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (author_key) DO UPDATE
+		SET author = EXCLUDED.author, reason = EXCLUDED.reason, banned_at = EXCLUDED.banned_at
+	`
+	ctx, span := startSpan(ctx, "SaveBan", query)
+	defer func() { endSpan(span, err) }()
+	markWrite(ctx)
+
+	_, err = p.writer().ExecContext(ctx, query, key, ban.Author, ban.Reason, ban.BannedAt)
+	return err
+}
+
+// DeleteBan снимает блокировку автора.
+func (p *PostgresStorage) DeleteBan(ctx context.Context, key string) (err error) {
+	const query = `DELETE FROM banned_users WHERE author_key = $1`
+	ctx, span := startSpan(ctx, "DeleteBan", query)
+	defer func() { endSpan(span, err) }()
+	markWrite(ctx)
+
+	res, err := p.writer().ExecContext(ctx, query, key)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+// AddModerationRecord сохраняет запись журнала модерации и вытесняет
+// записи старше keep последних.
+func (p *PostgresStorage) AddModerationRecord(ctx context.Context, rec *model.ModerationRecord, keep int) (err error) {
+	const query = `
+		INSERT INTO moderation_log (id, target_id, post_id, kind, author, action, decisions, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	ctx, span := startSpan(ctx, "AddModerationRecord", query)
+	defer func() { endSpan(span, err) }()
+	markWrite(ctx)
+
+	decisions, err := json.Marshal(rec.Decisions)
+	if err != nil {
+		return err
+	}
+	if _, err = p.writer().ExecContext(ctx, query,
+		rec.ID, rec.TargetID, rec.PostID, rec.Kind, rec.Author, rec.Action, decisions, rec.CreatedAt); err != nil {
+		return err
+	}
+	_, err = p.writer().ExecContext(ctx, trimModerationPostgres, keep)
+	return err
+}
+
+// ListModerationRecords читает журнал модерации с primary, как и
+// блокировки: свежая запись могла еще не дойти до реплик.
+func (p *PostgresStorage) ListModerationRecords(ctx context.Context, limit, offset int) (_ []*model.ModerationRecord, err error) {
+	const query = `
+		SELECT ` + moderationColumns + `
+		FROM moderation_log
+		ORDER BY seq DESC
+		LIMIT $1 OFFSET $2
+	`
+	ctx, span := startSpan(ctx, "ListModerationRecords", query)
+	defer func() { endSpan(span, err) }()
+
+	// LIMIT NULL в PostgreSQL — без ограничения
+	var pageLimit any
+	if limit > 0 {
+		pageLimit = limit
+	}
+	return readModerationRecords(ctx, p.writer(), query, pageLimit, offset)
+}
+
+const moderationColumns = `id, target_id, post_id, kind, author, action, decisions, created_at`
+
+// trimModerationPostgres и trimModerationSQLite оставляют в журнале
+// модерации $1 последних записей.
+const (
+	trimModerationPostgres = `
+		DELETE FROM moderation_log
+		WHERE seq <= (SELECT seq FROM moderation_log ORDER BY seq DESC OFFSET $1 LIMIT 1)`
+	trimModerationSQLite = `
+		DELETE FROM moderation_log
+		WHERE seq <= (SELECT seq FROM moderation_log ORDER BY seq DESC LIMIT 1 OFFSET ?)`
+)
+
+func readModerationRecords(ctx context.Context, db querier, query string, args ...any) ([]*model.ModerationRecord, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []*model.ModerationRecord{}
+	for rows.Next() {
+		var (
+			rec       model.ModerationRecord
+			postID    sql.NullString
+			decisions []byte
+		)
+		if err := rows.Scan(&rec.ID, &rec.TargetID, &postID, &rec.Kind, &rec.Author, &rec.Action,
+			&decisions, timeColumn{&rec.CreatedAt}); err != nil {
+			return nil, err
+		}
+		if postID.Valid {
+			rec.PostID = &postID.String
+		}
+		if err := json.Unmarshal(decisions, &rec.Decisions); err != nil {
+			return nil, fmt.Errorf("решения записи журнала модерации %s: %w", rec.ID, err)
+		}
+		records = append(records, &rec)
+	}
+	return records, rows.Err()
+}
+
+func readBans(ctx context.Context, db querier, query string) ([]*model.BannedUser, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bans []*model.BannedUser
+	for rows.Next() {
+		var ban model.BannedUser
+		if err := rows.Scan(&ban.Author, &ban.Reason, timeColumn{&ban.BannedAt}); err != nil {
+			return nil, err
+		}
+		bans = append(bans, &ban)
+	}
+	return bans, rows.Err()
+}
@@ -1,99 +1,641 @@
-package storage
-
-import (
-	"database/sql"
-	"fmt"
-	"ozon_test/graph/model"
-	"sync"
-)
-
-// MemoryStorage реализует хранилище данных в оперативной памяти.
-type MemoryStorage struct {
-	mu       sync.RWMutex
-	posts    map[string]*model.Post
-	comments map[string][]*model.Comment
-}
-
-// NewMemoryStorage создает новое in-memory хранилище.
-func NewMemoryStorage() *MemoryStorage {
-	return &MemoryStorage{
-		posts:    make(map[string]*model.Post),
-		comments: make(map[string][]*model.Comment),
-	}
-}
-func (m *MemoryStorage) UpdatePost(post *model.Post) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	if _, exists := m.posts[post.ID]; !exists {
-		return fmt.Errorf("пост не найден")
-	}
-
-	m.posts[post.ID] = post
-	return nil
-}
-
-// GetPostByID возвращает пост по ID или ошибку, если не найден.
-func (m *MemoryStorage) GetPostByID(id string) (*model.Post, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	post, exists := m.posts[id]
-	if !exists {
-		return nil, sql.ErrNoRows
-	}
-	return post, nil
-}
-
-// GetAllPosts возвращает все посты.
-func (m *MemoryStorage) GetAllPosts() ([]*model.Post, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	posts := make([]*model.Post, 0, len(m.posts))
-	for _, post := range m.posts {
-		posts = append(posts, post)
-	}
-	return posts, nil
-}
-
-// CreatePost добавляет новый пост.
-func (m *MemoryStorage) CreatePost(post *model.Post) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	m.posts[post.ID] = post
-	return nil
-}
-
-// CreateComment добавляет комментарий к посту.
-func (m *MemoryStorage) CreateComment(comment *model.Comment) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	postID := comment.PostID
-	m.comments[postID] = append(m.comments[postID], comment)
-	return nil
-}
-
-// GetCommentsByPostID возвращает комментарии к посту с пагинацией.
-func (m *MemoryStorage) GetCommentsByPostID(postID string, limit, offset int) ([]*model.Comment, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	comments, exists := m.comments[postID]
-	if !exists {
-		return nil, sql.ErrNoRows
-	}
-
-	// Проверка границ
-	if offset >= len(comments) {
-		return nil, nil
-	}
-	end := offset + limit
-	if limit == 0 || end > len(comments) {
-		end = len(comments)
-	}
-
-	return comments[offset:end], nil
-}
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"ozon_test/graph/model"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStorage реализует хранилище данных в оперативной памяти.
+type MemoryStorage struct {
+	mu sync.RWMutex
+	memoryData
+
+	// log — журнал мутаций; nil — данные живут только в памяти.
+	log  *memoryLog
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// memoryData — данные in-memory хранилища. Методы не блокируют: блокировку
+// держит вызывающий (MemoryStorage или транзакция). Изменяющие методы
+// записывают обратные операции в undo, если он передан.
+type memoryData struct {
+	posts    map[string]*model.Post
+	comments map[string][]*model.Comment
+	// commentPost — индекс ID комментария → ID поста.
+	commentPost map[string]string
+	// bans — блокировки авторов по ключу.
+	bans map[string]*model.BannedUser
+	// moderation — журнал модерации от старых записей к новым.
+	moderation []*model.ModerationRecord
+}
+
+// NewMemoryStorage создает новое in-memory хранилище.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{memoryData: memoryData{
+		posts:       make(map[string]*model.Post),
+		comments:    make(map[string][]*model.Comment),
+		commentPost: make(map[string]string),
+		bans:        make(map[string]*model.BannedUser),
+	}}
+}
+
+// commit применяет мутацию и пишет её в журнал; если запись в журнал
+// не удалась, мутация откатывается. Вызывается под блокировкой.
+func (m *MemoryStorage) commit(op memoryOp, apply func(undo *undoLog) error) error {
+	if m.log == nil {
+		return apply(nil)
+	}
+	var undo undoLog
+	if err := apply(&undo); err != nil {
+		undo.rollback()
+		return err
+	}
+	if err := m.log.append([]memoryOp{op}); err != nil {
+		undo.rollback()
+		return fmt.Errorf("запись журнала: %w", err)
+	}
+	return nil
+}
+
+// UpdatePost заменяет пост с тем же ID.
+func (m *MemoryStorage) UpdatePost(ctx context.Context, post *model.Post) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.commit(memoryOp{Op: opUpdatePost, Post: post}, func(undo *undoLog) error {
+		return m.updatePost(post, undo)
+	})
+}
+
+// DeletePost удаляет пост вместе с комментариями.
+func (m *MemoryStorage) DeletePost(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.commit(memoryOp{Op: opDeletePost, ID: id}, func(undo *undoLog) error {
+		return m.deletePost(id, undo)
+	})
+}
+
+// GetPostByID возвращает пост по ID или ошибку, если не найден.
+func (m *MemoryStorage) GetPostByID(ctx context.Context, id string) (*model.Post, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.getPost(id)
+}
+
+// GetPostForUpdate возвращает пост для изменения; транзакции в памяти
+// выполняются под общей блокировкой, поэтому это обычное чтение.
+func (m *MemoryStorage) GetPostForUpdate(ctx context.Context, id string) (*model.Post, error) {
+	return m.GetPostByID(ctx, id)
+}
+
+// GetAllPosts возвращает все посты.
+func (m *MemoryStorage) GetAllPosts(ctx context.Context) ([]*model.Post, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.allPosts(), nil
+}
+
+// GetDuePosts возвращает запланированные посты со сроком не позже now.
+func (m *MemoryStorage) GetDuePosts(ctx context.Context, now time.Time) ([]*model.Post, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.duePosts(now), nil
+}
+
+// CreatePost добавляет новый пост.
+func (m *MemoryStorage) CreatePost(ctx context.Context, post *model.Post) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.commit(memoryOp{Op: opCreatePost, Post: post}, func(undo *undoLog) error {
+		m.createPost(post, undo)
+		return nil
+	})
+}
+
+// CreateComment добавляет комментарий к посту.
+func (m *MemoryStorage) CreateComment(ctx context.Context, comment *model.Comment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.commit(memoryOp{Op: opCreateComment, Comment: comment}, func(undo *undoLog) error {
+		return m.createComment(comment, undo)
+	})
+}
+
+// GetCommentByID возвращает комментарий по ID.
+func (m *MemoryStorage) GetCommentByID(ctx context.Context, id string) (*model.Comment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.getComment(id)
+}
+
+// UpdateComment обновляет текст комментария.
+func (m *MemoryStorage) UpdateComment(ctx context.Context, comment *model.Comment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.commit(memoryOp{Op: opUpdateComment, Comment: comment}, func(undo *undoLog) error {
+		return m.updateComment(comment, undo)
+	})
+}
+
+// DeleteComment удаляет комментарий вместе со всеми ответами на него,
+// как ON DELETE CASCADE в PostgreSQL.
+func (m *MemoryStorage) DeleteComment(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.commit(memoryOp{Op: opDeleteComment, ID: id}, func(undo *undoLog) error {
+		return m.deleteComment(id, undo)
+	})
+}
+
+// GetCommentsByPostID возвращает комментарии к посту с пагинацией.
+func (m *MemoryStorage) GetCommentsByPostID(ctx context.Context, postID string, limit, offset int) ([]*model.Comment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.commentsByPost(postID, limit, offset)
+}
+
+// GetCommentsAfter возвращает комментарии поста после позиции (after,
+// afterID) по возрастанию (created_at, id).
+func (m *MemoryStorage) GetCommentsAfter(ctx context.Context, postID string, after time.Time, afterID string) ([]*model.Comment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.commentsAfter(postID, after, afterID), nil
+}
+
+// ListBans возвращает блокировки авторов.
+func (m *MemoryStorage) ListBans(ctx context.Context) ([]*model.BannedUser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	bans := make([]*model.BannedUser, 0, len(m.bans))
+	for _, ban := range m.bans {
+		bans = append(bans, ban)
+	}
+	return bans, nil
+}
+
+// SaveBan добавляет или обновляет блокировку автора.
+func (m *MemoryStorage) SaveBan(ctx context.Context, key string, ban *model.BannedUser) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.commit(memoryOp{Op: opSaveBan, Key: key, Ban: ban}, func(undo *undoLog) error {
+		m.saveBan(key, ban, undo)
+		return nil
+	})
+}
+
+// AddModerationRecord сохраняет запись журнала модерации и вытесняет
+// записи старше keep последних.
+func (m *MemoryStorage) AddModerationRecord(ctx context.Context, rec *model.ModerationRecord, keep int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.commit(memoryOp{Op: opAddModeration, Moderation: rec, Keep: keep}, func(undo *undoLog) error {
+		m.addModeration(rec, keep, undo)
+		return nil
+	})
+}
+
+// ListModerationRecords возвращает записи журнала модерации от новых к
+// старым; limit <= 0 — без ограничения.
+func (m *MemoryStorage) ListModerationRecords(ctx context.Context, limit, offset int) ([]*model.ModerationRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	records := []*model.ModerationRecord{}
+	for i := len(m.moderation) - 1 - max(offset, 0); i >= 0 && (limit <= 0 || len(records) < limit); i-- {
+		records = append(records, m.moderation[i])
+	}
+	return records, nil
+}
+
+// DeleteBan снимает блокировку автора.
+func (m *MemoryStorage) DeleteBan(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.commit(memoryOp{Op: opDeleteBan, Key: key}, func(undo *undoLog) error {
+		return m.deleteBan(key, undo)
+	})
+}
+
+// WithTx выполняет fn под эксклюзивной блокировкой хранилища: изменения
+// fn видны остальным только целиком. Если fn возвращает ошибку или
+// паникует, изменения откатываются в обратном порядке. В журнал
+// транзакция пишется одной записью и восстанавливается целиком или никак.
+func (m *MemoryStorage) WithTx(ctx context.Context, fn func(tx Storage) error) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tx := &memoryTx{data: &m.memoryData}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.undo.rollback()
+			panic(r)
+		}
+		if err != nil {
+			tx.undo.rollback()
+		}
+	}()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if m.log != nil && len(tx.ops) > 0 {
+		if err := m.log.append(tx.ops); err != nil {
+			return fmt.Errorf("запись журнала: %w", err)
+		}
+	}
+	return nil
+}
+
+// Ping всегда успешен: in-memory хранилище доступно, пока жив процесс.
+func (m *MemoryStorage) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Close останавливает фоновые fsync и снимки, сворачивает журнал в снимок
+// и закрывает его. Без журнала ничего не делает.
+func (m *MemoryStorage) Close() error {
+	if m.log == nil {
+		return nil
+	}
+	close(m.stop)
+	m.wg.Wait()
+	return errors.Join(m.Snapshot(), m.log.close())
+}
+
+// undoLog — обратные операции транзакции.
+type undoLog []func()
+
+func (u *undoLog) add(f func()) {
+	if u != nil {
+		*u = append(*u, f)
+	}
+}
+
+// rollback выполняет обратные операции в обратном порядке.
+func (u *undoLog) rollback() {
+	for i := len(*u) - 1; i >= 0; i-- {
+		(*u)[i]()
+	}
+	*u = nil
+}
+
+func (d *memoryData) updatePost(post *model.Post, undo *undoLog) error {
+	prev, exists := d.posts[post.ID]
+	if !exists {
+		return sql.ErrNoRows
+	}
+
+	// Как в SQL-хранилищах, автор и время создания не меняются
+	updated := *prev
+	updated.Title = post.Title
+	updated.Content = post.Content
+	updated.CommentsAllowed = post.CommentsAllowed
+	updated.Status = postStatus(post)
+	updated.PublishAt = post.PublishAt
+	d.posts[post.ID] = &updated
+	undo.add(func() { d.posts[post.ID] = prev })
+	return nil
+}
+
+func (d *memoryData) deletePost(id string, undo *undoLog) error {
+	post, exists := d.posts[id]
+	if !exists {
+		return sql.ErrNoRows
+	}
+
+	comments, hasComments := d.comments[id]
+	delete(d.posts, id)
+	delete(d.comments, id)
+	for _, c := range comments {
+		delete(d.commentPost, c.ID)
+	}
+	undo.add(func() {
+		d.posts[id] = post
+		if hasComments {
+			d.comments[id] = comments
+		}
+		for _, c := range comments {
+			d.commentPost[c.ID] = id
+		}
+	})
+	return nil
+}
+
+func (d *memoryData) getPost(id string) (*model.Post, error) {
+	post, exists := d.posts[id]
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+	return post, nil
+}
+
+func (d *memoryData) allPosts() []*model.Post {
+	posts := make([]*model.Post, 0, len(d.posts))
+	for _, post := range d.posts {
+		posts = append(posts, post)
+	}
+	return posts
+}
+
+func (d *memoryData) duePosts(now time.Time) []*model.Post {
+	var posts []*model.Post
+	for _, post := range d.posts {
+		if post.Status == model.PostStatusScheduled && post.PublishAt != nil && !post.PublishAt.After(now) {
+			posts = append(posts, post)
+		}
+	}
+	sort.Slice(posts, func(i, j int) bool { return posts[i].PublishAt.Before(*posts[j].PublishAt) })
+	return posts
+}
+
+func (d *memoryData) createPost(post *model.Post, undo *undoLog) {
+	// Посты без статуса из журналов и снимков, записанных до его появления,
+	// считаются опубликованными в момент создания — как после миграции SQL
+	if post.Status == "" {
+		published := *post
+		published.Status = model.PostStatusPublished
+		if published.PublishAt == nil {
+			published.PublishAt = &published.CreatedAt
+		}
+		post = &published
+	}
+
+	prev, existed := d.posts[post.ID]
+	d.posts[post.ID] = post
+	undo.add(func() {
+		if existed {
+			d.posts[post.ID] = prev
+		} else {
+			delete(d.posts, post.ID)
+		}
+	})
+}
+
+// createComment вставляет комментарий с сохранением порядка хранения.
+// Как внешние ключи в SQL, требует существующих поста и родителя.
+func (d *memoryData) createComment(comment *model.Comment, undo *undoLog) error {
+	postID := comment.PostID
+	if _, exists := d.posts[postID]; !exists {
+		return fmt.Errorf("пост %s не найден", postID)
+	}
+	if comment.ParentID != nil {
+		if _, exists := d.commentPost[*comment.ParentID]; !exists {
+			return fmt.Errorf("родительский комментарий %s не найден", *comment.ParentID)
+		}
+	}
+
+	prev, existed := d.comments[postID]
+	i := sort.Search(len(prev), func(i int) bool { return commentStoredBefore(comment, prev[i]) })
+	if i == len(prev) {
+		d.comments[postID] = append(prev, comment)
+	} else {
+		// Вставка в середину копирует срез: prev нужен для отката
+		next := make([]*model.Comment, 0, len(prev)+1)
+		next = append(next, prev[:i]...)
+		next = append(next, comment)
+		d.comments[postID] = append(next, prev[i:]...)
+	}
+	d.commentPost[comment.ID] = postID
+	undo.add(func() {
+		if existed {
+			d.comments[postID] = prev
+		} else {
+			delete(d.comments, postID)
+		}
+		delete(d.commentPost, comment.ID)
+	})
+	return nil
+}
+
+// commentStoredBefore задает порядок хранения комментариев поста: по
+// возрастанию created_at, при равенстве — по убыванию ID. Чтение идет
+// с конца, что дает порядок SQL-хранилищ: created_at DESC, id. Обычно
+// новый комментарий самый поздний и просто дописывается в конец.
+func commentStoredBefore(a, b *model.Comment) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.ID > b.ID
+}
+
+func (d *memoryData) getComment(id string) (*model.Comment, error) {
+	postID, exists := d.commentPost[id]
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+	for _, c := range d.comments[postID] {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (d *memoryData) updateComment(comment *model.Comment, undo *undoLog) error {
+	postID, exists := d.commentPost[comment.ID]
+	if !exists {
+		return sql.ErrNoRows
+	}
+	comments := d.comments[postID]
+	for i, c := range comments {
+		if c.ID == comment.ID {
+			comments[i] = comment
+			undo.add(func() { comments[i] = c })
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (d *memoryData) deleteComment(id string, undo *undoLog) error {
+	postID, exists := d.commentPost[id]
+	if !exists {
+		return sql.ErrNoRows
+	}
+
+	removed := map[string]bool{id: true}
+	for changed := true; changed; {
+		changed = false
+		for _, c := range d.comments[postID] {
+			if c.ParentID != nil && removed[*c.ParentID] && !removed[c.ID] {
+				removed[c.ID] = true
+				changed = true
+			}
+		}
+	}
+
+	prev := d.comments[postID]
+	kept := make([]*model.Comment, 0, len(prev))
+	for _, c := range prev {
+		if removed[c.ID] {
+			delete(d.commentPost, c.ID)
+			continue
+		}
+		kept = append(kept, c)
+	}
+	d.comments[postID] = kept
+	undo.add(func() {
+		d.comments[postID] = prev
+		for commentID := range removed {
+			d.commentPost[commentID] = postID
+		}
+	})
+	return nil
+}
+
+func (d *memoryData) saveBan(key string, ban *model.BannedUser, undo *undoLog) {
+	prev, existed := d.bans[key]
+	d.bans[key] = ban
+	undo.add(func() {
+		if existed {
+			d.bans[key] = prev
+		} else {
+			delete(d.bans, key)
+		}
+	})
+}
+
+func (d *memoryData) addModeration(rec *model.ModerationRecord, keep int, undo *undoLog) {
+	prev := d.moderation
+	records := append(d.moderation[:len(d.moderation):len(d.moderation)], rec)
+	if keep > 0 && len(records) > keep {
+		records = records[len(records)-keep:]
+	}
+	d.moderation = records
+	undo.add(func() { d.moderation = prev })
+}
+
+func (d *memoryData) deleteBan(key string, undo *undoLog) error {
+	prev, exists := d.bans[key]
+	if !exists {
+		return sql.ErrNoRows
+	}
+	delete(d.bans, key)
+	undo.add(func() { d.bans[key] = prev })
+	return nil
+}
+
+// commentsByPost возвращает копию страницы комментариев от новых к старым;
+// limit <= 0 — без ограничения.
+func (d *memoryData) commentsByPost(postID string, limit, offset int) ([]*model.Comment, error) {
+	comments := d.comments[postID]
+	if offset < 0 {
+		offset = 0
+	}
+	n := len(comments) - offset
+	if n < 0 {
+		n = 0
+	}
+	if limit > 0 && limit < n {
+		n = limit
+	}
+
+	page := make([]*model.Comment, 0, n)
+	for i := len(comments) - 1 - offset; len(page) < n; i-- {
+		page = append(page, comments[i])
+	}
+	return page, nil
+}
+
+func (d *memoryData) commentsAfter(postID string, after time.Time, afterID string) []*model.Comment {
+	comments := []*model.Comment{}
+	for _, comment := range d.comments[postID] {
+		if comment.CreatedAt.After(after) || (comment.CreatedAt.Equal(after) && comment.ID > afterID) {
+			comments = append(comments, comment)
+		}
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		if !comments[i].CreatedAt.Equal(comments[j].CreatedAt) {
+			return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+		}
+		return comments[i].ID < comments[j].ID
+	})
+	return comments
+}
+
+// memoryTx — транзакция in-memory хранилища. Блокировку держит WithTx,
+// поэтому методы обращаются к данным напрямую, пишут undo-журнал
+// и копят операции для журнала на диске.
+type memoryTx struct {
+	data *memoryData
+	undo undoLog
+	ops  []memoryOp
+}
+
+// record добавляет операцию в пачку транзакции, если мутация удалась.
+func (t *memoryTx) record(op memoryOp, err error) error {
+	if err == nil {
+		t.ops = append(t.ops, op)
+	}
+	return err
+}
+
+func (t *memoryTx) GetPostByID(ctx context.Context, id string) (*model.Post, error) {
+	return t.data.getPost(id)
+}
+
+func (t *memoryTx) GetPostForUpdate(ctx context.Context, id string) (*model.Post, error) {
+	return t.data.getPost(id)
+}
+
+func (t *memoryTx) GetAllPosts(ctx context.Context) ([]*model.Post, error) {
+	return t.data.allPosts(), nil
+}
+
+func (t *memoryTx) GetDuePosts(ctx context.Context, now time.Time) ([]*model.Post, error) {
+	return t.data.duePosts(now), nil
+}
+
+func (t *memoryTx) CreatePost(ctx context.Context, post *model.Post) error {
+	t.data.createPost(post, &t.undo)
+	return t.record(memoryOp{Op: opCreatePost, Post: post}, nil)
+}
+
+func (t *memoryTx) CreateComment(ctx context.Context, comment *model.Comment) error {
+	return t.record(memoryOp{Op: opCreateComment, Comment: comment}, t.data.createComment(comment, &t.undo))
+}
+
+func (t *memoryTx) GetCommentsAfter(ctx context.Context, postID string, after time.Time, afterID string) ([]*model.Comment, error) {
+	return t.data.commentsAfter(postID, after, afterID), nil
+}
+
+func (t *memoryTx) GetCommentsByPostID(ctx context.Context, postID string, limit, offset int) ([]*model.Comment, error) {
+	return t.data.commentsByPost(postID, limit, offset)
+}
+
+func (t *memoryTx) UpdatePost(ctx context.Context, post *model.Post) error {
+	return t.record(memoryOp{Op: opUpdatePost, Post: post}, t.data.updatePost(post, &t.undo))
+}
+
+func (t *memoryTx) DeletePost(ctx context.Context, id string) error {
+	return t.record(memoryOp{Op: opDeletePost, ID: id}, t.data.deletePost(id, &t.undo))
+}
+
+func (t *memoryTx) GetCommentByID(ctx context.Context, id string) (*model.Comment, error) {
+	return t.data.getComment(id)
+}
+
+func (t *memoryTx) UpdateComment(ctx context.Context, comment *model.Comment) error {
+	return t.record(memoryOp{Op: opUpdateComment, Comment: comment}, t.data.updateComment(comment, &t.undo))
+}
+
+func (t *memoryTx) DeleteComment(ctx context.Context, id string) error {
+	return t.record(memoryOp{Op: opDeleteComment, ID: id}, t.data.deleteComment(id, &t.undo))
+}
+
+// WithTx внутри транзакции выполняет fn в ней же.
+func (t *memoryTx) WithTx(ctx context.Context, fn func(tx Storage) error) error {
+	return fn(t)
+}
+
+func (t *memoryTx) Ping(ctx context.Context) error { return nil }
+
+// Close внутри транзакции ничего не делает: хранилище закрывает владелец.
+func (t *memoryTx) Close() error { return nil }
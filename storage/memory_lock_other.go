@@ -0,0 +1,26 @@
+//go:build !unix
+
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// lockDir берет блокировку каталога данных, создавая файл LOCK
+// эксклюзивно. Без flock файл остается после сбоя процесса, и его нужно
+// удалить вручную; unlock удаляет его.
+func lockDir(dir string) (unlock func() error, err error) {
+	path := filepath.Join(dir, lockFile)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0o644)
+	if os.IsExist(err) {
+		return nil, fmt.Errorf("%s: %w", dir, ErrDirLocked)
+	} else if err != nil {
+		return nil, err
+	}
+	return func() error {
+		f.Close()
+		return os.Remove(path)
+	}, nil
+}
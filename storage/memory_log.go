@@ -0,0 +1,525 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ozon_test/graph/model"
+)
+
+// FsyncPolicy определяет, когда журнал in-memory хранилища сбрасывается на диск.
+type FsyncPolicy string
+
+const (
+	// FsyncAlways — fsync после каждой мутации: ничего не теряется, но запись медленнее.
+	FsyncAlways FsyncPolicy = "always"
+	// FsyncInterval — fsync в фоне раз в интервал: при сбое ОС теряется не больше интервала.
+	FsyncInterval FsyncPolicy = "interval"
+	// FsyncNever — сброс на диск остаётся операционной системе.
+	FsyncNever FsyncPolicy = "never"
+)
+
+// MemoryLogOptions — параметры сохранения in-memory хранилища на диск.
+type MemoryLogOptions struct {
+	Fsync         FsyncPolicy
+	FsyncInterval time.Duration
+	// SnapshotInterval — как часто журнал сворачивается в снимок; 0 — только при Close.
+	SnapshotInterval time.Duration
+}
+
+// Операции журнала.
+const (
+	opCreatePost    = "createPost"
+	opUpdatePost    = "updatePost"
+	opDeletePost    = "deletePost"
+	opCreateComment = "createComment"
+	opUpdateComment = "updateComment"
+	opDeleteComment = "deleteComment"
+	opSaveBan       = "saveBan"
+	opDeleteBan     = "deleteBan"
+	opAddModeration = "addModeration"
+)
+
+// memoryOp — одна мутация в журнале.
+type memoryOp struct {
+	Op      string         `json:"op"`
+	Post    *model.Post    `json:"post,omitempty"`
+	Comment *model.Comment `json:"comment,omitempty"`
+	ID      string         `json:"id,omitempty"`
+	// Key и Ban — блокировка автора для saveBan и deleteBan.
+	Key string            `json:"key,omitempty"`
+	Ban *model.BannedUser `json:"ban,omitempty"`
+	// Moderation и Keep — запись журнала модерации и сколько последних
+	// записей оставить для addModeration.
+	Moderation *model.ModerationRecord `json:"moderation,omitempty"`
+	Keep       int                     `json:"keep,omitempty"`
+}
+
+// apply повторяет мутацию при восстановлении.
+func (op memoryOp) apply(d *memoryData) error {
+	switch op.Op {
+	case opCreatePost:
+		d.createPost(op.Post, nil)
+	case opUpdatePost:
+		return d.updatePost(op.Post, nil)
+	case opDeletePost:
+		return d.deletePost(op.ID, nil)
+	case opCreateComment:
+		return d.createComment(op.Comment, nil)
+	case opUpdateComment:
+		return d.updateComment(op.Comment, nil)
+	case opDeleteComment:
+		return d.deleteComment(op.ID, nil)
+	case opSaveBan:
+		d.saveBan(op.Key, op.Ban, nil)
+	case opDeleteBan:
+		return d.deleteBan(op.Key, nil)
+	case opAddModeration:
+		d.addModeration(op.Moderation, op.Keep, nil)
+	default:
+		return fmt.Errorf("неизвестная операция журнала %q", op.Op)
+	}
+	return nil
+}
+
+// memorySnapshot — состояние хранилища на начало журнала Gen.
+type memorySnapshot struct {
+	Gen      uint64           `json:"gen"`
+	Posts    []*model.Post    `json:"posts"`
+	Comments []*model.Comment `json:"comments"`
+	// Bans — блокировки авторов по ключу.
+	Bans map[string]*model.BannedUser `json:"bans,omitempty"`
+	// Moderation — журнал модерации от старых записей к новым.
+	Moderation []*model.ModerationRecord `json:"moderation,omitempty"`
+}
+
+const (
+	snapshotFile = "snapshot.json"
+	lockFile     = "LOCK"
+	// Заголовок записи: длина и CRC32 полезной нагрузки.
+	recordHeaderSize = 8
+	maxRecordSize    = 64 << 20
+)
+
+// errTornRecord — запись журнала оборвана или повреждена.
+var errTornRecord = errors.New("повреждённая запись журнала")
+
+// ErrDirLocked — каталог данных уже открыт другим процессом.
+var ErrDirLocked = errors.New("каталог данных занят другим процессом")
+
+// memoryLog — журнал мутаций in-memory хранилища. Журнал разбит на
+// поколения wal-<gen>.log; снимок поколения G содержит состояние на начало
+// wal-G, поэтому при восстановлении читается снимок и журналы с gen >= G.
+// Каждая запись — пачка операций (одна мутация или транзакция целиком)
+// в кадре [длина][CRC32][JSON].
+type memoryLog struct {
+	dir  string
+	opts MemoryLogOptions
+
+	mu      sync.Mutex
+	file    *os.File
+	gen     uint64
+	size    int64
+	dirty   bool
+	records int
+	// unlock снимает блокировку каталога.
+	unlock func() error
+}
+
+func logName(gen uint64) string {
+	return fmt.Sprintf("wal-%08d.log", gen)
+}
+
+// append дописывает пачку операций. При ошибке записи хвост обрезается,
+// чтобы следующие записи не оказались за повреждённой.
+func (l *memoryLog) append(ops []memoryOp) error {
+	payload, err := json.Marshal(ops)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, recordHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(payload))
+	copy(buf[recordHeaderSize:], payload)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.file.Write(buf); err != nil {
+		if truncErr := l.file.Truncate(l.size); truncErr != nil {
+			return errors.Join(err, truncErr)
+		}
+		return err
+	}
+	l.size += int64(len(buf))
+	l.records++
+
+	if l.opts.Fsync == FsyncAlways {
+		return l.file.Sync()
+	}
+	l.dirty = true
+	return nil
+}
+
+// sync сбрасывает на диск записи, добавленные после прошлого fsync.
+func (l *memoryLog) sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.dirty || l.opts.Fsync == FsyncNever {
+		return nil
+	}
+	l.dirty = false
+	return l.file.Sync()
+}
+
+// pending возвращает число записей после последнего снимка.
+func (l *memoryLog) pending() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.records
+}
+
+// rotate закрывает текущий журнал и начинает следующее поколение.
+func (l *memoryLog) rotate() (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.file.Sync(); err != nil {
+		return 0, err
+	}
+	next, err := openLogFile(l.dir, l.gen+1)
+	if err != nil {
+		return 0, err
+	}
+	l.file.Close()
+	l.file, l.gen, l.size, l.dirty, l.records = next, l.gen+1, 0, false, 0
+	return l.gen, nil
+}
+
+func (l *memoryLog) close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.file.Sync(); err != nil {
+		l.file.Close()
+		return errors.Join(err, l.unlock())
+	}
+	return errors.Join(l.file.Close(), l.unlock())
+}
+
+func openLogFile(dir string, gen uint64) (*os.File, error) {
+	f, err := os.OpenFile(filepath.Join(dir, logName(gen)), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return f, syncDir(dir)
+}
+
+// syncDir фиксирует на диске создание и переименование файлов в каталоге.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// logGenerations возвращает поколения журналов в каталоге по возрастанию.
+func logGenerations(dir string) ([]uint64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var gens []uint64
+	for _, e := range entries {
+		var gen uint64
+		if _, err := fmt.Sscanf(e.Name(), "wal-%08d.log", &gen); err == nil && strings.HasSuffix(e.Name(), ".log") {
+			gens = append(gens, gen)
+		}
+	}
+	sort.Slice(gens, func(i, j int) bool { return gens[i] < gens[j] })
+	return gens, nil
+}
+
+// readRecords читает записи журнала и передает их в apply. Возвращает
+// размер корректной части файла и errTornRecord, если за ней есть
+// оборванная или повреждённая запись.
+func readRecords(path string, apply func([]memoryOp) error) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var valid int64
+	header := make([]byte, recordHeaderSize)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				return valid, nil
+			}
+			return valid, errTornRecord
+		}
+		size := binary.LittleEndian.Uint32(header[0:4])
+		if size > maxRecordSize {
+			return valid, errTornRecord
+		}
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return valid, errTornRecord
+		}
+		if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(header[4:8]) {
+			return valid, errTornRecord
+		}
+		var ops []memoryOp
+		if err := json.Unmarshal(payload, &ops); err != nil {
+			return valid, errTornRecord
+		}
+		if err := apply(ops); err != nil {
+			return valid, err
+		}
+		valid += int64(recordHeaderSize + len(payload))
+	}
+}
+
+// readSnapshot читает снимок; без снимка возвращает пустой снимок поколения 0.
+func readSnapshot(dir string) (*memorySnapshot, error) {
+	data, err := os.ReadFile(filepath.Join(dir, snapshotFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return &memorySnapshot{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snap memorySnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("%s: %w", snapshotFile, err)
+	}
+	return &snap, nil
+}
+
+// writeSnapshot атомарно заменяет снимок: запись во временный файл, fsync
+// и переименование.
+func writeSnapshot(dir string, snap *memorySnapshot) error {
+	tmp, err := os.CreateTemp(dir, snapshotFile+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	if err := json.NewEncoder(w).Encode(snap); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, snapshotFile)); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// NewDurableMemoryStorage создает in-memory хранилище, которое пишет каждую
+// мутацию в журнал в каталоге dir и периодически сворачивает журнал
+// в снимок. Состояние восстанавливается из снимка и журналов; оборванная
+// последняя запись (сбой посреди записи) обрезается. Каталог блокируется
+// до Close: второй процесс получит ErrDirLocked, а не обрежет и не удалит
+// журналы, в которые пишет первый.
+func NewDurableMemoryStorage(dir string, opts MemoryLogOptions) (_ *MemoryStorage, err error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	unlock, err := lockDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			unlock()
+		}
+	}()
+	m := NewMemoryStorage()
+
+	snap, err := readSnapshot(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, post := range snap.Posts {
+		m.createPost(post, nil)
+	}
+	// Комментарии в снимке уже в порядке хранения; ответ может идти раньше
+	// родителя, поэтому они кладутся напрямую, без проверок createComment.
+	for _, comment := range snap.Comments {
+		m.comments[comment.PostID] = append(m.comments[comment.PostID], comment)
+		m.commentPost[comment.ID] = comment.PostID
+	}
+	for key, ban := range snap.Bans {
+		m.bans[key] = ban
+	}
+	m.moderation = snap.Moderation
+
+	gens, err := logGenerations(dir)
+	if err != nil {
+		return nil, err
+	}
+	gen, records := snap.Gen, 0
+	for i, g := range gens {
+		if g < snap.Gen {
+			continue
+		}
+		path := filepath.Join(dir, logName(g))
+		valid, err := readRecords(path, func(ops []memoryOp) error {
+			records++
+			for _, op := range ops {
+				if err := op.apply(&m.memoryData); err != nil {
+					return fmt.Errorf("%s: %w", path, err)
+				}
+			}
+			return nil
+		})
+		if errors.Is(err, errTornRecord) && i == len(gens)-1 {
+			slog.Warn("Последняя запись журнала оборвана, хвост обрезан",
+				slog.String("file", path), slog.Int64("size", valid))
+			if err := os.Truncate(path, valid); err != nil {
+				return nil, err
+			}
+		} else if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		gen = g
+	}
+
+	file, err := openLogFile(dir, gen)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	m.log = &memoryLog{dir: dir, opts: opts, file: file, gen: gen, size: info.Size(), records: records, unlock: unlock}
+	m.stop = make(chan struct{})
+	m.wg.Add(1)
+	go m.background()
+
+	slog.Info("In-memory хранилище восстановлено с диска",
+		slog.String("dir", dir), slog.Int("posts", len(m.posts)), slog.Int("records", records))
+	return m, nil
+}
+
+// background сбрасывает журнал на диск и сворачивает его в снимок по расписанию.
+func (m *MemoryStorage) background() {
+	defer m.wg.Done()
+
+	var syncTick, snapshotTick <-chan time.Time
+	if m.log.opts.Fsync == FsyncInterval && m.log.opts.FsyncInterval > 0 {
+		t := time.NewTicker(m.log.opts.FsyncInterval)
+		defer t.Stop()
+		syncTick = t.C
+	}
+	if m.log.opts.SnapshotInterval > 0 {
+		t := time.NewTicker(m.log.opts.SnapshotInterval)
+		defer t.Stop()
+		snapshotTick = t.C
+	}
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-syncTick:
+			if err := m.log.sync(); err != nil {
+				slog.Error("Ошибка fsync журнала", slog.Any("error", err))
+			}
+		case <-snapshotTick:
+			if err := m.Snapshot(); err != nil {
+				slog.Error("Ошибка создания снимка", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// Snapshot сворачивает журнал в снимок: под блокировкой фиксирует
+// состояние и начинает новое поколение журнала, затем без блокировки
+// пишет снимок и удаляет старые журналы.
+func (m *MemoryStorage) Snapshot() error {
+	if m.log == nil || m.log.pending() == 0 {
+		return nil
+	}
+
+	m.mu.Lock()
+	snap := m.snapshot()
+	gen, err := m.log.rotate()
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	snap.Gen = gen
+	if err := writeSnapshot(m.log.dir, snap); err != nil {
+		return err
+	}
+	gens, err := logGenerations(m.log.dir)
+	if err != nil {
+		return err
+	}
+	for _, g := range gens {
+		if g < gen {
+			if err := os.Remove(filepath.Join(m.log.dir, logName(g))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// snapshot копирует состояние; вызывается под блокировкой.
+func (m *MemoryStorage) snapshot() *memorySnapshot {
+	snap := &memorySnapshot{Posts: make([]*model.Post, 0, len(m.posts))}
+	for _, post := range m.posts {
+		p := *post
+		p.Comments = nil
+		snap.Posts = append(snap.Posts, &p)
+	}
+
+	postIDs := make([]string, 0, len(m.comments))
+	for postID := range m.comments {
+		postIDs = append(postIDs, postID)
+	}
+	sort.Strings(postIDs)
+	for _, postID := range postIDs {
+		snap.Comments = append(snap.Comments, m.comments[postID]...)
+	}
+	if len(m.bans) > 0 {
+		snap.Bans = make(map[string]*model.BannedUser, len(m.bans))
+		for key, ban := range m.bans {
+			snap.Bans[key] = ban
+		}
+	}
+	snap.Moderation = append([]*model.ModerationRecord(nil), m.moderation...)
+	return snap
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"ozon_test/migrations"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// migration — файл встроенной миграции.
+type migration struct {
+	name    string
+	version int
+	script  string
+}
+
+// readMigrations читает миграции NNNN_описание.sql из каталога dir по
+// порядку номеров.
+func readMigrations(fsys fs.FS, dir string) ([]migration, error) {
+	files, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []migration
+	for _, f := range files {
+		if path.Ext(f.Name()) != ".sql" {
+			continue
+		}
+		prefix, _, _ := strings.Cut(f.Name(), "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("%s: некорректный номер миграции", f.Name())
+		}
+		script, err := fs.ReadFile(fsys, path.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, migration{name: f.Name(), version: version, script: string(script)})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].version < list[j].version })
+	return list, nil
+}
+
+// migrationsLockKey — ключ advisory-блокировки миграций PostgreSQL.
+const migrationsLockKey = 0x6f7a6f6e // "ozon"
+
+// migratePostgres применяет встроенные миграции с номером больше
+// последнего в schema_migrations, каждую в своей транзакции. Экземпляры,
+// стартующие одновременно, ждут друг друга на advisory-блокировке.
+// Миграции идемпотентны, поэтому база, созданная до появления
+// schema_migrations, доводится до текущей версии без потери данных.
+func migratePostgres(ctx context.Context, db *sql.DB) (err error) {
+	ctx, span := startSpan(ctx, "Migrate", `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`)
+	defer func() { endSpan(span, err) }()
+
+	list, err := readMigrations(migrations.FS, ".")
+	if err != nil {
+		return err
+	}
+
+	// Блокировка сессионная: все запросы идут через одно соединение
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationsLockKey); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, migrationsLockKey)
+
+	if _, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			applied_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		)`); err != nil {
+		return err
+	}
+	var current int
+	if err := conn.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return err
+	}
+
+	for _, m := range list {
+		if m.version <= current {
+			continue
+		}
+		if err := applyPostgresMigration(ctx, conn, m); err != nil {
+			return fmt.Errorf("%s: %w", m.name, err)
+		}
+		slog.InfoContext(ctx, "Применена миграция PostgreSQL", slog.String("file", m.name))
+	}
+	return nil
+}
+
+// applyPostgresMigration выполняет скрипт миграции и отмечает версию в одной транзакции.
+func applyPostgresMigration(ctx context.Context, conn *sql.Conn, m migration) (err error) {
+	ctx, span := startSpan(ctx, "ApplyMigration", m.script)
+	defer func() { endSpan(span, err) }()
+	setMigrationAttributes(span, m)
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, m.script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES ($1) ON CONFLICT (version) DO NOTHING`, m.version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// setMigrationAttributes отмечает в span миграции ее файл и номер.
+func setMigrationAttributes(span trace.Span, m migration) {
+	span.SetAttributes(
+		attribute.String("db.migration.file", m.name),
+		attribute.Int("db.migration.version", m.version),
+	)
+}
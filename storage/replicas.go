@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// replica — реплика PostgreSQL только для чтения.
+type replica struct {
+	name    string
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// replicaSet распределяет чтения по здоровым репликам по кругу. Реплика,
+// вернувшая временную ошибку, исключается, пока фоновая проверка не
+// убедится, что она снова отвечает.
+type replicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newReplicaSet(dbs []*sql.DB, checkInterval time.Duration) *replicaSet {
+	rs := &replicaSet{stop: make(chan struct{})}
+	for i, db := range dbs {
+		r := &replica{name: fmt.Sprintf("replica%d", i), db: db}
+		r.healthy.Store(true)
+		rs.replicas = append(rs.replicas, r)
+	}
+	if len(rs.replicas) > 0 && checkInterval > 0 {
+		rs.wg.Add(1)
+		go rs.checkLoop(checkInterval)
+	}
+	return rs
+}
+
+// pick возвращает следующую здоровую реплику или nil, если таких нет.
+func (rs *replicaSet) pick() *replica {
+	n := len(rs.replicas)
+	if n == 0 {
+		return nil
+	}
+	start := rs.next.Add(1)
+	for i := 0; i < n; i++ {
+		r := rs.replicas[(start+uint64(i))%uint64(n)]
+		if r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+// eject исключает реплику до следующей успешной проверки.
+func (rs *replicaSet) eject(r *replica, err error) {
+	if r.healthy.CompareAndSwap(true, false) {
+		slog.Warn("Реплика PostgreSQL исключена из балансировки",
+			slog.String("replica", r.name), slog.Any("error", err))
+	}
+}
+
+// checkLoop периодически пингует исключённые реплики и возвращает
+// ответившие в балансировку.
+func (rs *replicaSet) checkLoop(interval time.Duration) {
+	defer rs.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-rs.stop:
+			return
+		case <-ticker.C:
+		}
+		for _, r := range rs.replicas {
+			if r.healthy.Load() {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			err := r.db.PingContext(ctx)
+			cancel()
+			if err == nil {
+				r.healthy.Store(true)
+				slog.Info("Реплика PostgreSQL снова в балансировке", slog.String("replica", r.name))
+			}
+		}
+	}
+}
+
+// healthyCount возвращает число реплик, участвующих в балансировке.
+func (rs *replicaSet) healthyCount() int {
+	n := 0
+	for _, r := range rs.replicas {
+		if r.healthy.Load() {
+			n++
+		}
+	}
+	return n
+}
+
+// close останавливает проверки и закрывает пулы реплик.
+func (rs *replicaSet) close() error {
+	close(rs.stop)
+	rs.wg.Wait()
+
+	var firstErr error
+	for _, r := range rs.replicas {
+		if err := r.db.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+type readSessionKey struct{}
+
+// readSession отмечает, что в рамках запроса уже была запись.
+type readSession struct {
+	wrote atomic.Bool
+}
+
+type primaryKey struct{}
+
+// WithReadYourWrites включает для контекста (обычно одного HTTP-запроса)
+// чтение своих записей: после первой записи все последующие чтения
+// в этом контексте идут на primary, а не на отстающие реплики.
+func WithReadYourWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readSessionKey{}, &readSession{})
+}
+
+// WithPrimary направляет все чтения в контексте на primary.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// markWrite запоминает запись в сессии контекста, если она включена.
+func markWrite(ctx context.Context) {
+	if s, ok := ctx.Value(readSessionKey{}).(*readSession); ok {
+		s.wrote.Store(true)
+	}
+}
+
+// readFromPrimary сообщает, что чтение нужно выполнить на primary.
+func readFromPrimary(ctx context.Context) bool {
+	if ctx.Value(primaryKey{}) != nil {
+		return true
+	}
+	s, ok := ctx.Value(readSessionKey{}).(*readSession)
+	return ok && s.wrote.Load()
+}
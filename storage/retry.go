@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// IsTransient сообщает, что ошибка временная и операцию можно повторить:
+// обрыв или отказ соединения, таймаут сети, перезапуск PostgreSQL,
+// конфликт сериализации и взаимоблокировка. Отмена контекста временной
+// ошибкой не считается.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "40001", // serialization_failure
+			"40P01", // deadlock_detected
+			"53300", // too_many_connections
+			"57P01", // admin_shutdown
+			"57P02", // crash_shutdown
+			"57P03": // cannot_connect_now
+			return true
+		}
+		// Класс 08 — ошибки соединения
+		return pqErr.Code.Class() == "08"
+	}
+
+	if errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return netErr.Timeout()
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr)
+}
+
+// Backoff — экспоненциальная задержка между попытками со случайным
+// разбросом: Initial, затем вдвое больше, но не более Max.
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+}
+
+// delay возвращает паузу перед попыткой attempt (с нуля).
+func (b Backoff) delay(attempt int) time.Duration {
+	d := b.Initial
+	for i := 0; i < attempt && d < b.Max; i++ {
+		d *= 2
+	}
+	if d > b.Max {
+		d = b.Max
+	}
+	if d <= 0 {
+		return 0
+	}
+	// Разброс ±25%, чтобы инстансы не повторяли запросы синхронно
+	return d - d/4 + rand.N(d/2+1)
+}
+
+// Retry выполняет fn, повторяя её при временных ошибках, пока не исчерпано
+// attempts попыток (0 — без ограничения) или не истёк ctx. Возвращается
+// последняя ошибка fn.
+func (b Backoff) Retry(ctx context.Context, attempts int, fn func(ctx context.Context) error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || !IsTransient(err) || (attempts > 0 && attempt+1 >= attempts) {
+			return err
+		}
+
+		timer := time.NewTimer(b.delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,569 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"ozon_test/graph/model"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	_ "modernc.org/sqlite" // драйвер SQLite без cgo
+)
+
+//go:embed sqlite_migrations/*.sql
+var sqliteMigrations embed.FS
+
+// SQLiteStorage реализует хранилище на SQLite в режиме WAL — для небольших
+// инсталляций и CI, где PostgreSQL избыточен.
+type SQLiteStorage struct {
+	DB *sql.DB
+	// tx — текущая транзакция; задана у хранилища, переданного в WithTx.
+	tx *sql.Tx
+}
+
+// NewSQLiteStorage открывает (или создает) базу SQLite по пути path
+// и применяет встроенные миграции.
+func NewSQLiteStorage(ctx context.Context, path string) (*SQLiteStorage, error) {
+	// Транзакции берут блокировку записи сразу (BEGIN IMMEDIATE), поэтому
+	// проверка и запись внутри WithTx не конфликтуют с другими писателями;
+	// busy_timeout заставляет ждать блокировку вместо SQLITE_BUSY.
+	params := url.Values{}
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "synchronous(NORMAL)")
+	params.Set("_txlock", "immediate")
+
+	db, err := sql.Open("sqlite", "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	s := &SQLiteStorage{DB: db}
+	if err := s.migrate(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("миграции SQLite: %w", err)
+	}
+	return s, nil
+}
+
+// migrate применяет ещё не применённые миграции из sqlite_migrations
+// по порядку номеров, каждую в своей транзакции.
+func (s *SQLiteStorage) migrate(ctx context.Context) (err error) {
+	ctx, span := startSQLiteSpan(ctx, "Migrate", `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`)
+	defer func() { endSpan(span, err) }()
+
+	if _, err := s.DB.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			applied_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`); err != nil {
+		return err
+	}
+	current, err := s.MigrationVersion(ctx)
+	if err != nil {
+		return err
+	}
+	list, err := readMigrations(sqliteMigrations, "sqlite_migrations")
+	if err != nil {
+		return err
+	}
+
+	for _, m := range list {
+		if m.version <= current {
+			continue
+		}
+		if err := s.applyMigration(ctx, m); err != nil {
+			return fmt.Errorf("%s: %w", m.name, err)
+		}
+		slog.InfoContext(ctx, "Применена миграция SQLite", slog.String("file", m.name))
+	}
+	return nil
+}
+
+// applyMigration выполняет скрипт миграции и отмечает версию в одной транзакции.
+func (s *SQLiteStorage) applyMigration(ctx context.Context, m migration) (err error) {
+	ctx, span := startSQLiteSpan(ctx, "ApplyMigration", m.script)
+	defer func() { endSpan(span, err) }()
+	setMigrationAttributes(span, m)
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, m.script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES (?)`, m.version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// conn возвращает соединение для запросов: транзакцию или пул.
+func (s *SQLiteStorage) conn() querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.DB
+}
+
+// Ping проверяет доступность базы.
+func (s *SQLiteStorage) Ping(ctx context.Context) error {
+	return s.DB.PingContext(ctx)
+}
+
+// MigrationVersion возвращает номер последней применённой миграции.
+func (s *SQLiteStorage) MigrationVersion(ctx context.Context) (int, error) {
+	var version int
+	err := s.conn().QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	return version, err
+}
+
+// Close закрывает базу. У хранилища транзакции ничего не делает.
+func (s *SQLiteStorage) Close() error {
+	if s.tx != nil {
+		return nil
+	}
+	return s.DB.Close()
+}
+
+// WithTx выполняет fn в транзакции BEGIN IMMEDIATE: блокировка записи
+// берется в начале, поэтому прочитанные в fn данные не изменятся до
+// фиксации. Вложенный вызов выполняется в той же транзакции.
+func (s *SQLiteStorage) WithTx(ctx context.Context, fn func(tx Storage) error) (err error) {
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	ctx, span := startSQLiteSpan(ctx, "WithTx", "BEGIN IMMEDIATE")
+	defer func() { endSpan(span, err) }()
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+	if err := fn(&SQLiteStorage{DB: s.DB, tx: tx}); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+			slog.WarnContext(ctx, "Ошибка отката транзакции", slog.Any("error", rbErr))
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// startSQLiteSpan открывает span запроса к SQLite.
+func startSQLiteSpan(ctx context.Context, name, query string) (context.Context, trace.Span) {
+	return startDBSpan(ctx, "sqlite", "sqlite", name, query)
+}
+
+// sqliteTimeLayout — формат created_at в SQLite: UTC и всегда девять
+// знаков после запятой, чтобы сравнение строк в ORDER BY совпадало с
+// порядком времени.
+const sqliteTimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeLayout)
+}
+
+// sqliteNullTime — sqliteTime для необязательного времени: nil пишется как NULL.
+func sqliteNullTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return sqliteTime(*t)
+}
+
+// GetPostByID возвращает пост по его ID.
+func (s *SQLiteStorage) GetPostByID(ctx context.Context, id string) (_ *model.Post, err error) {
+	const query = `
+		SELECT ` + postColumns + `
+		FROM posts
+		WHERE id = ?
+	`
+	ctx, span := startSQLiteSpan(ctx, "GetPostByID", query)
+	defer func() { endSpan(span, err) }()
+
+	return scanPost(s.conn().QueryRowContext(ctx, query, id))
+}
+
+// GetPostForUpdate возвращает пост для изменения. Транзакция уже держит
+// блокировку записи (BEGIN IMMEDIATE), отдельная блокировка строки не нужна.
+func (s *SQLiteStorage) GetPostForUpdate(ctx context.Context, id string) (*model.Post, error) {
+	return s.GetPostByID(ctx, id)
+}
+
+// GetAllPosts возвращает все посты.
+func (s *SQLiteStorage) GetAllPosts(ctx context.Context) (_ []*model.Post, err error) {
+	const query = `
+		SELECT ` + postColumns + `
+		FROM posts
+	`
+	ctx, span := startSQLiteSpan(ctx, "GetAllPosts", query)
+	defer func() { endSpan(span, err) }()
+
+	rows, err := s.conn().QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []*model.Post
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+	return posts, rows.Err()
+}
+
+// GetDuePosts возвращает запланированные посты со сроком не позже now.
+func (s *SQLiteStorage) GetDuePosts(ctx context.Context, now time.Time) (_ []*model.Post, err error) {
+	const query = `
+		SELECT ` + postColumns + `
+		FROM posts
+		WHERE status = 'SCHEDULED' AND publish_at <= ?
+		ORDER BY publish_at
+	`
+	ctx, span := startSQLiteSpan(ctx, "GetDuePosts", query)
+	defer func() { endSpan(span, err) }()
+
+	rows, err := s.conn().QueryContext(ctx, query, sqliteTime(now))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []*model.Post
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+	return posts, rows.Err()
+}
+
+// CreatePost сохраняет новый пост.
+func (s *SQLiteStorage) CreatePost(ctx context.Context, post *model.Post) (err error) {
+	const query = `
+		INSERT INTO posts (id, title, content, author, comments_allowed, created_at, status, publish_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	ctx, span := startSQLiteSpan(ctx, "CreatePost", query)
+	defer func() { endSpan(span, err) }()
+
+	_, err = s.conn().ExecContext(ctx, query,
+		post.ID,
+		post.Title,
+		post.Content,
+		post.Author,
+		post.CommentsAllowed,
+		sqliteTime(post.CreatedAt),
+		postStatus(post),
+		sqliteNullTime(post.PublishAt),
+	)
+	return err
+}
+
+// UpdatePost обновляет заголовок, текст, режим комментирования, статус и
+// время публикации поста.
+func (s *SQLiteStorage) UpdatePost(ctx context.Context, post *model.Post) (err error) {
+	const query = `UPDATE posts SET title = ?, content = ?, comments_allowed = ?, status = ?, publish_at = ? WHERE id = ?`
+	ctx, span := startSQLiteSpan(ctx, "UpdatePost", query)
+	defer func() { endSpan(span, err) }()
+
+	res, err := s.conn().ExecContext(ctx, query, post.Title, post.Content, post.CommentsAllowed, postStatus(post), sqliteNullTime(post.PublishAt), post.ID)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+// DeletePost удаляет пост; комментарии удаляются каскадно.
+func (s *SQLiteStorage) DeletePost(ctx context.Context, id string) (err error) {
+	const query = `DELETE FROM posts WHERE id = ?`
+	ctx, span := startSQLiteSpan(ctx, "DeletePost", query)
+	defer func() { endSpan(span, err) }()
+
+	res, err := s.conn().ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+// CreateComment сохраняет комментарий вместе со ссылкой на родителя.
+func (s *SQLiteStorage) CreateComment(ctx context.Context, comment *model.Comment) (err error) {
+	const query = `
+		INSERT INTO comments (id, post_id, parent_id, content, author, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	ctx, span := startSQLiteSpan(ctx, "CreateComment", query)
+	defer func() { endSpan(span, err) }()
+
+	_, err = s.conn().ExecContext(ctx, query,
+		comment.ID,
+		comment.PostID,
+		comment.ParentID,
+		comment.Content,
+		comment.Author,
+		sqliteTime(comment.CreatedAt),
+	)
+	return err
+}
+
+// GetCommentsByPostID возвращает комментарии к посту с пагинацией.
+func (s *SQLiteStorage) GetCommentsByPostID(ctx context.Context, postID string, limit, offset int) (_ []*model.Comment, err error) {
+	const query = `
+		SELECT ` + commentColumns + `
+		FROM comments
+		WHERE post_id = ?
+		ORDER BY created_at DESC, id
+		LIMIT ? OFFSET ?
+	`
+	ctx, span := startSQLiteSpan(ctx, "GetCommentsByPostID", query)
+	defer func() { endSpan(span, err) }()
+
+	// LIMIT -1 в SQLite — без ограничения
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := s.conn().QueryContext(ctx, query, postID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []*model.Comment{}
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
+
+// GetCommentsAfter возвращает комментарии поста после позиции (after,
+// afterID) по возрастанию (created_at, id); время хранится строкой
+// фиксированной ширины в UTC, поэтому сравнивается как строка.
+func (s *SQLiteStorage) GetCommentsAfter(ctx context.Context, postID string, after time.Time, afterID string) (_ []*model.Comment, err error) {
+	const query = `
+		SELECT ` + commentColumns + `
+		FROM comments
+		WHERE post_id = ? AND (created_at, id) > (?, ?)
+		ORDER BY created_at, id
+	`
+	ctx, span := startSQLiteSpan(ctx, "GetCommentsAfter", query)
+	defer func() { endSpan(span, err) }()
+
+	rows, err := s.conn().QueryContext(ctx, query, postID, sqliteTime(after), afterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []*model.Comment{}
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
+
+// GetCommentByID возвращает комментарий по его ID.
+func (s *SQLiteStorage) GetCommentByID(ctx context.Context, id string) (_ *model.Comment, err error) {
+	const query = `SELECT ` + commentColumns + ` FROM comments WHERE id = ?`
+	ctx, span := startSQLiteSpan(ctx, "GetCommentByID", query)
+	defer func() { endSpan(span, err) }()
+
+	return scanComment(s.conn().QueryRowContext(ctx, query, id))
+}
+
+// UpdateComment обновляет текст комментария.
+func (s *SQLiteStorage) UpdateComment(ctx context.Context, comment *model.Comment) (err error) {
+	const query = `UPDATE comments SET content = ? WHERE id = ?`
+	ctx, span := startSQLiteSpan(ctx, "UpdateComment", query)
+	defer func() { endSpan(span, err) }()
+
+	res, err := s.conn().ExecContext(ctx, query, comment.Content, comment.ID)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+// DeleteComment удаляет комментарий; ответы удаляются каскадно.
+func (s *SQLiteStorage) DeleteComment(ctx context.Context, id string) (err error) {
+	const query = `DELETE FROM comments WHERE id = ?`
+	ctx, span := startSQLiteSpan(ctx, "DeleteComment", query)
+	defer func() { endSpan(span, err) }()
+
+	res, err := s.conn().ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+// LastChangeSeq возвращает номер последнего изменения в change_log.
+func (s *SQLiteStorage) LastChangeSeq(ctx context.Context) (_ int64, err error) {
+	ctx, span := startSQLiteSpan(ctx, "LastChangeSeq", lastChangeSeqQuery)
+	defer func() { endSpan(span, err) }()
+
+	return lastChangeSeq(ctx, s.conn())
+}
+
+// Changes читает ленту изменений по порядку номеров. Запись в SQLite идет
+// в одной транзакции за раз, поэтому порядок seq совпадает с порядком
+// фиксации.
+func (s *SQLiteStorage) Changes(ctx context.Context, after int64, limit int) (_ []Change, err error) {
+	const query = `SELECT seq, entity, entity_id FROM change_log WHERE seq > ? ORDER BY seq LIMIT ?`
+	ctx, span := startSQLiteSpan(ctx, "Changes", query)
+	defer func() { endSpan(span, err) }()
+
+	return readChanges(ctx, s.conn(), query, after, limit)
+}
+
+// TrackChanges регистрирует потребителя ленты. Транзакция берет
+// блокировку записи сразу, поэтому записи после нее видят потребителя.
+func (s *SQLiteStorage) TrackChanges(ctx context.Context, consumer string) (seq int64, err error) {
+	const query = `
+		INSERT INTO change_log_consumers (name, seq) VALUES (?, ?)
+		ON CONFLICT (name) DO UPDATE SET seq = excluded.seq, updated_at = CURRENT_TIMESTAMP`
+	ctx, span := startSQLiteSpan(ctx, "TrackChanges", query)
+	defer func() { endSpan(span, err) }()
+
+	err = s.WithTx(ctx, func(tx Storage) error {
+		conn := tx.(*SQLiteStorage).conn()
+		var err error
+		if seq, err = lastChangeSeq(ctx, conn); err != nil {
+			return err
+		}
+		_, err = conn.ExecContext(ctx, query, consumer, seq)
+		return err
+	})
+	return seq, err
+}
+
+// AckChanges запоминает позицию потребителя и очищает ленту.
+func (s *SQLiteStorage) AckChanges(ctx context.Context, consumer string, upTo int64) (err error) {
+	const query = `UPDATE change_log_consumers SET seq = ?, updated_at = CURRENT_TIMESTAMP WHERE name = ?`
+	ctx, span := startSQLiteSpan(ctx, "AckChanges", query)
+	defer func() { endSpan(span, err) }()
+
+	if _, err := s.conn().ExecContext(ctx, query, upTo, consumer); err != nil {
+		return err
+	}
+	return trimChanges(ctx, s.conn(), trimChangesSQLite)
+}
+
+// UntrackChanges снимает потребителя и очищает ленту.
+func (s *SQLiteStorage) UntrackChanges(ctx context.Context, consumer string) (err error) {
+	const query = `DELETE FROM change_log_consumers WHERE name = ?`
+	ctx, span := startSQLiteSpan(ctx, "UntrackChanges", query)
+	defer func() { endSpan(span, err) }()
+
+	if _, err := s.conn().ExecContext(ctx, query, consumer); err != nil {
+		return err
+	}
+	return trimChanges(ctx, s.conn(), trimChangesSQLite)
+}
+
+// AddModerationRecord сохраняет запись журнала модерации и вытесняет
+// записи старше keep последних.
+func (s *SQLiteStorage) AddModerationRecord(ctx context.Context, rec *model.ModerationRecord, keep int) (err error) {
+	const query = `
+		INSERT INTO moderation_log (id, target_id, post_id, kind, author, action, decisions, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	ctx, span := startSQLiteSpan(ctx, "AddModerationRecord", query)
+	defer func() { endSpan(span, err) }()
+
+	decisions, err := json.Marshal(rec.Decisions)
+	if err != nil {
+		return err
+	}
+	if _, err = s.conn().ExecContext(ctx, query,
+		rec.ID, rec.TargetID, rec.PostID, rec.Kind, rec.Author, rec.Action, string(decisions), sqliteTime(rec.CreatedAt)); err != nil {
+		return err
+	}
+	_, err = s.conn().ExecContext(ctx, trimModerationSQLite, keep)
+	return err
+}
+
+// ListModerationRecords возвращает записи журнала модерации от новых к старым.
+func (s *SQLiteStorage) ListModerationRecords(ctx context.Context, limit, offset int) (_ []*model.ModerationRecord, err error) {
+	const query = `
+		SELECT ` + moderationColumns + `
+		FROM moderation_log
+		ORDER BY seq DESC
+		LIMIT ? OFFSET ?
+	`
+	ctx, span := startSQLiteSpan(ctx, "ListModerationRecords", query)
+	defer func() { endSpan(span, err) }()
+
+	// LIMIT -1 в SQLite — без ограничения
+	if limit <= 0 {
+		limit = -1
+	}
+	return readModerationRecords(ctx, s.conn(), query, limit, offset)
+}
+
+// ListBans возвращает блокировки авторов.
+func (s *SQLiteStorage) ListBans(ctx context.Context) (_ []*model.BannedUser, err error) {
+	const query = `SELECT author, reason, banned_at FROM banned_users`
+	ctx, span := startSQLiteSpan(ctx, "ListBans", query)
+	defer func() { endSpan(span, err) }()
+
+	return readBans(ctx, s.conn(), query)
+}
+
+// SaveBan добавляет или обновляет блокировку автора.
+func (s *SQLiteStorage) SaveBan(ctx context.Context, key string, ban *model.BannedUser) (err error) {
+	const query = `
+		INSERT INTO banned_users (author_key, author, reason, banned_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (author_key) DO UPDATE
+		SET author = excluded.author, reason = excluded.reason, banned_at = excluded.banned_at
+	`
+	ctx, span := startSQLiteSpan(ctx, "SaveBan", query)
+	defer func() { endSpan(span, err) }()
+
+	_, err = s.conn().ExecContext(ctx, query, key, ban.Author, ban.Reason, sqliteTime(ban.BannedAt))
+	return err
+}
+
+// DeleteBan снимает блокировку автора.
+func (s *SQLiteStorage) DeleteBan(ctx context.Context, key string) (err error) {
+	const query = `DELETE FROM banned_users WHERE author_key = ?`
+	ctx, span := startSQLiteSpan(ctx, "DeleteBan", query)
+	defer func() { endSpan(span, err) }()
+
+	res, err := s.conn().ExecContext(ctx, query, key)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
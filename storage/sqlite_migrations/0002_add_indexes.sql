@@ -0,0 +1,2 @@
+CREATE INDEX idx_comments_post_id ON comments(post_id, created_at);
+CREATE INDEX idx_comments_parent_id ON comments(parent_id);
@@ -0,0 +1,32 @@
+CREATE TABLE change_log (
+    seq INTEGER PRIMARY KEY AUTOINCREMENT,
+    entity TEXT NOT NULL,
+    entity_id TEXT NOT NULL,
+    changed_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER posts_insert_change AFTER INSERT ON posts
+BEGIN
+    INSERT INTO change_log (entity, entity_id) VALUES ('post', NEW.id);
+END;
+CREATE TRIGGER posts_update_change AFTER UPDATE ON posts
+BEGIN
+    INSERT INTO change_log (entity, entity_id) VALUES ('post', NEW.id);
+END;
+CREATE TRIGGER posts_delete_change AFTER DELETE ON posts
+BEGIN
+    INSERT INTO change_log (entity, entity_id) VALUES ('post', OLD.id);
+END;
+
+CREATE TRIGGER comments_insert_change AFTER INSERT ON comments
+BEGIN
+    INSERT INTO change_log (entity, entity_id) VALUES ('comment', NEW.id);
+END;
+CREATE TRIGGER comments_update_change AFTER UPDATE ON comments
+BEGIN
+    INSERT INTO change_log (entity, entity_id) VALUES ('comment', NEW.id);
+END;
+CREATE TRIGGER comments_delete_change AFTER DELETE ON comments
+BEGIN
+    INSERT INTO change_log (entity, entity_id) VALUES ('comment', OLD.id);
+END;
@@ -0,0 +1,10 @@
+-- Время создания в едином формате sqliteTimeLayout: UTC и девять знаков
+-- после запятой, чтобы ORDER BY created_at совпадал с порядком времени.
+-- Прежние значения — RFC 3339 с точностью до секунды в поясе сервера.
+UPDATE posts
+SET created_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%f', created_at) || '000000Z', created_at)
+WHERE created_at NOT LIKE '____-__-__T__:__:__._________Z';
+
+UPDATE comments
+SET created_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%f', created_at) || '000000Z', created_at)
+WHERE created_at NOT LIKE '____-__-__T__:__:__._________Z';
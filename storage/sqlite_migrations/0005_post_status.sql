@@ -0,0 +1,9 @@
+-- Статус поста (DRAFT, SCHEDULED, PUBLISHED, ARCHIVED) и время публикации
+-- в формате sqliteTimeLayout. Существующие посты считаются опубликованными
+-- в момент создания.
+ALTER TABLE posts ADD COLUMN status TEXT NOT NULL DEFAULT 'PUBLISHED';
+ALTER TABLE posts ADD COLUMN publish_at TEXT;
+
+UPDATE posts SET publish_at = created_at;
+
+CREATE INDEX IF NOT EXISTS idx_posts_scheduled ON posts(publish_at) WHERE status = 'SCHEDULED';
@@ -0,0 +1,50 @@
+-- Потребители ленты изменений (migrate-data) и номер последнего
+-- применённого ими изменения. Триггеры пишут в change_log, только пока
+-- есть хотя бы один потребитель; без них запись не платит за ленту.
+CREATE TABLE change_log_consumers (
+    name TEXT PRIMARY KEY,
+    seq INTEGER NOT NULL DEFAULT 0,
+    updated_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+DROP TRIGGER IF EXISTS posts_insert_change;
+DROP TRIGGER IF EXISTS posts_update_change;
+DROP TRIGGER IF EXISTS posts_delete_change;
+DROP TRIGGER IF EXISTS comments_insert_change;
+DROP TRIGGER IF EXISTS comments_update_change;
+DROP TRIGGER IF EXISTS comments_delete_change;
+
+CREATE TRIGGER posts_insert_change AFTER INSERT ON posts
+WHEN EXISTS (SELECT 1 FROM change_log_consumers)
+BEGIN
+    INSERT INTO change_log (entity, entity_id) VALUES ('post', NEW.id);
+END;
+CREATE TRIGGER posts_update_change AFTER UPDATE ON posts
+WHEN EXISTS (SELECT 1 FROM change_log_consumers)
+BEGIN
+    INSERT INTO change_log (entity, entity_id) VALUES ('post', NEW.id);
+END;
+CREATE TRIGGER posts_delete_change AFTER DELETE ON posts
+WHEN EXISTS (SELECT 1 FROM change_log_consumers)
+BEGIN
+    INSERT INTO change_log (entity, entity_id) VALUES ('post', OLD.id);
+END;
+
+CREATE TRIGGER comments_insert_change AFTER INSERT ON comments
+WHEN EXISTS (SELECT 1 FROM change_log_consumers)
+BEGIN
+    INSERT INTO change_log (entity, entity_id) VALUES ('comment', NEW.id);
+END;
+CREATE TRIGGER comments_update_change AFTER UPDATE ON comments
+WHEN EXISTS (SELECT 1 FROM change_log_consumers)
+BEGIN
+    INSERT INTO change_log (entity, entity_id) VALUES ('comment', NEW.id);
+END;
+CREATE TRIGGER comments_delete_change AFTER DELETE ON comments
+WHEN EXISTS (SELECT 1 FROM change_log_consumers)
+BEGIN
+    INSERT INTO change_log (entity, entity_id) VALUES ('comment', OLD.id);
+END;
+
+-- Накопленная лента без потребителей никому не нужна
+DELETE FROM change_log;
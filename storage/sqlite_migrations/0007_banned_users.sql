@@ -0,0 +1,8 @@
+-- Блокировки авторов: переживают перезапуск и общие для всех процессов.
+-- author_key — имя без учета регистра и пробелов по краям.
+CREATE TABLE banned_users (
+    author_key TEXT PRIMARY KEY,
+    author TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    banned_at TEXT NOT NULL
+);
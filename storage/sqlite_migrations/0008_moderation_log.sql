@@ -0,0 +1,14 @@
+-- Журнал модерации: решения фильтров контента переживают перезапуск и
+-- видны всем процессам. seq задает порядок записей, decisions — решения
+-- отдельных фильтров в JSON.
+CREATE TABLE moderation_log (
+    seq INTEGER PRIMARY KEY AUTOINCREMENT,
+    id TEXT NOT NULL UNIQUE,
+    target_id TEXT NOT NULL DEFAULT '',
+    post_id TEXT,
+    kind TEXT NOT NULL,
+    author TEXT NOT NULL,
+    action TEXT NOT NULL,
+    decisions TEXT NOT NULL,
+    created_at TEXT NOT NULL
+);
@@ -1,40 +1,177 @@
-package storage
-
-import (
-	"log"
-	"ozon_test/config"
-	"ozon_test/graph/model"
-
-	_ "github.com/lib/pq" // импорт драйвера PostgreSQL
-)
-
-// Storage — интерфейс абстракции над типами хранилищ (PostgreSQL, Memory).
-type Storage interface {
-	GetPostByID(id string) (*model.Post, error)
-	GetAllPosts() ([]*model.Post, error)
-	CreatePost(post *model.Post) error
-	CreateComment(comment *model.Comment) error
-	GetCommentsByPostID(postID string, limit, offset int) ([]*model.Comment, error)
-	UpdatePost(post *model.Post) error
-}
-
-// DB — глобальное хранилище, инициализируемое при старте приложения.
-var DB Storage
-
-// InitStorage инициализирует глобальное хранилище на основе конфигурации.
-// Поддерживает два варианта: PostgreSQL и in-memory.
-func InitStorage(cfg *config.Config) {
-	switch cfg.StorageType {
-	case "postgres":
-		db, err := NewPostgresStorage(cfg.DSN)
-		if err != nil {
-			log.Fatalf("Ошибка подключения к PostgreSQL: %v", err)
-		}
-		DB = db
-		log.Println("Используется PostgreSQL для хранения данных")
-
-	default:
-		DB = NewMemoryStorage()
-		log.Println("Используется In-Memory хранилище")
-	}
-}
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"ozon_test/config"
+	"ozon_test/graph/model"
+	"time"
+
+	_ "github.com/lib/pq" // импорт драйвера PostgreSQL
+)
+
+// Storage — интерфейс абстракции над типами хранилищ (PostgreSQL, SQLite, Memory).
+type Storage interface {
+	GetPostByID(ctx context.Context, id string) (*model.Post, error)
+	// GetPostForUpdate читает пост, который затем будет изменен. В
+	// транзакции PostgreSQL строка блокируется FOR UPDATE, поэтому
+	// параллельные чтения-изменения одного поста идут по очереди.
+	GetPostForUpdate(ctx context.Context, id string) (*model.Post, error)
+	GetAllPosts(ctx context.Context) ([]*model.Post, error)
+	// GetDuePosts возвращает запланированные посты, срок публикации которых
+	// не позже now, по возрастанию срока.
+	GetDuePosts(ctx context.Context, now time.Time) ([]*model.Post, error)
+	CreatePost(ctx context.Context, post *model.Post) error
+	CreateComment(ctx context.Context, comment *model.Comment) error
+	GetCommentsByPostID(ctx context.Context, postID string, limit, offset int) ([]*model.Comment, error)
+	// GetCommentsAfter возвращает комментарии поста, которые идут после
+	// позиции (after, afterID), по возрастанию (created_at, id).
+	GetCommentsAfter(ctx context.Context, postID string, after time.Time, afterID string) ([]*model.Comment, error)
+	UpdatePost(ctx context.Context, post *model.Post) error
+	// DeletePost удаляет пост вместе со всеми комментариями;
+	// sql.ErrNoRows, если поста нет.
+	DeletePost(ctx context.Context, id string) error
+	GetCommentByID(ctx context.Context, id string) (*model.Comment, error)
+	UpdateComment(ctx context.Context, comment *model.Comment) error
+	DeleteComment(ctx context.Context, id string) error
+	// WithTx выполняет fn в транзакции: изменения, сделанные через tx,
+	// фиксируются вместе, если fn вернула nil, и откатываются при ошибке.
+	// Внутри fn нужно обращаться к хранилищу только через tx.
+	WithTx(ctx context.Context, fn func(tx Storage) error) error
+	// Ping проверяет доступность хранилища.
+	Ping(ctx context.Context) error
+	// Close освобождает ресурсы хранилища (например, пул соединений).
+	Close() error
+}
+
+// Versioned реализуют хранилища со схемой, управляемой миграциями.
+type Versioned interface {
+	// MigrationVersion возвращает номер последней применённой миграции.
+	MigrationVersion(ctx context.Context) (int, error)
+}
+
+// Сущности в ленте изменений.
+const (
+	EntityPost    = "post"
+	EntityComment = "comment"
+)
+
+// Change — запись ленты изменений: какая сущность изменилась (создана,
+// изменена или удалена). Содержимое не хранится, актуальное состояние
+// читается из хранилища.
+type Change struct {
+	// Seq — позиция в ленте в порядке фиксации изменений.
+	Seq    int64
+	Entity string
+	ID     string
+}
+
+// ChangeFeed реализуют хранилища, которые ведут ленту изменений
+// (таблица change_log, заполняемая триггерами). Лента видна и другим
+// процессам, поэтому по ней можно догонять изменения работающего сервиса.
+// Триггеры пишут в ленту, только пока зарегистрирован хотя бы один
+// потребитель (change_log_consumers), а изменения, применённые всеми
+// потребителями, удаляются.
+type ChangeFeed interface {
+	// LastChangeSeq возвращает номер последнего изменения, 0 — лента пуста.
+	LastChangeSeq(ctx context.Context) (int64, error)
+	// TrackChanges регистрирует потребителя и включает ленту; возвращает
+	// номер последнего изменения, с которого потребитель ее читает.
+	// Повторная регистрация с тем же именем начинает чтение заново.
+	TrackChanges(ctx context.Context, consumer string) (int64, error)
+	// Changes возвращает до limit изменений с номером больше after по порядку.
+	Changes(ctx context.Context, after int64, limit int) ([]Change, error)
+	// AckChanges отмечает, что потребитель применил изменения до upTo, и
+	// удаляет изменения, применённые всеми потребителями.
+	AckChanges(ctx context.Context, consumer string, upTo int64) error
+	// UntrackChanges снимает потребителя; без потребителей лента не
+	// ведется и очищается.
+	UntrackChanges(ctx context.Context, consumer string) error
+}
+
+// BanStore реализуют хранилища, которые хранят блокировки авторов: они
+// переживают перезапуск и общие для всех экземпляров сервиса. key — имя
+// автора в нормализованном виде, его задает вызывающий.
+type BanStore interface {
+	// ListBans возвращает все блокировки.
+	ListBans(ctx context.Context) ([]*model.BannedUser, error)
+	// SaveBan добавляет блокировку или заменяет блокировку с тем же key.
+	SaveBan(ctx context.Context, key string, ban *model.BannedUser) error
+	// DeleteBan снимает блокировку; sql.ErrNoRows — ее не было.
+	DeleteBan(ctx context.Context, key string) error
+}
+
+// ModerationLogStore реализуют хранилища, которые хранят журнал модерации:
+// записи переживают перезапуск и видны всем экземплярам сервиса.
+type ModerationLogStore interface {
+	// AddModerationRecord сохраняет запись и оставляет не больше keep
+	// последних записей.
+	AddModerationRecord(ctx context.Context, rec *model.ModerationRecord, keep int) error
+	// ListModerationRecords возвращает записи от новых к старым;
+	// limit <= 0 — без ограничения.
+	ListModerationRecords(ctx context.Context, limit, offset int) ([]*model.ModerationRecord, error)
+}
+
+// Wrapper реализуют декораторы хранилища (метрики, трассировка).
+type Wrapper interface {
+	Unwrap() Storage
+}
+
+// As ищет в цепочке декораторов хранилище, реализующее T.
+func As[T any](s Storage) (T, bool) {
+	for s != nil {
+		if t, ok := s.(T); ok {
+			return t, true
+		}
+		w, ok := s.(Wrapper)
+		if !ok {
+			break
+		}
+		s = w.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
+
+// Open открывает хранилище по конфигурации.
+// Поддерживает PostgreSQL, SQLite и in-memory.
+func Open(ctx context.Context, cfg config.StorageConfig) (Storage, error) {
+	switch cfg.Type {
+	case "postgres":
+		db, err := NewPostgresStorage(ctx, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка подключения к PostgreSQL: %w", err)
+		}
+		slog.Info("Используется PostgreSQL для хранения данных")
+		return db, nil
+
+	case "sqlite":
+		db, err := NewSQLiteStorage(ctx, cfg.SQLitePath)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка открытия SQLite: %w", err)
+		}
+		slog.Info("Используется SQLite для хранения данных", slog.String("path", cfg.SQLitePath))
+		return db, nil
+
+	case "memory":
+		if cfg.MemoryDataDir == "" {
+			slog.Info("Используется In-Memory хранилище")
+			return NewMemoryStorage(), nil
+		}
+		db, err := NewDurableMemoryStorage(cfg.MemoryDataDir, MemoryLogOptions{
+			Fsync:            FsyncPolicy(cfg.MemoryFsync),
+			FsyncInterval:    cfg.MemoryFsyncInterval,
+			SnapshotInterval: cfg.MemorySnapshotInterval,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("ошибка восстановления In-Memory хранилища: %w", err)
+		}
+		slog.Info("Используется In-Memory хранилище с журналом на диске",
+			slog.String("dir", cfg.MemoryDataDir), slog.String("fsync", cfg.MemoryFsync))
+		return db, nil
+
+	default:
+		return nil, fmt.Errorf("неизвестный тип хранилища %q", cfg.Type)
+	}
+}
@@ -0,0 +1,567 @@
+// Package storagetest — поведенческие тесты контракта storage.Storage.
+// Один и тот же набор сценариев прогоняется против любого хранилища,
+// чтобы memory, SQLite и PostgreSQL не расходились в поведении.
+package storagetest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"ozon_test/graph/model"
+	"ozon_test/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory возвращает пустое хранилище для одного сценария. Закрыть его
+// фабрика должна сама, например через t.Cleanup.
+type Factory func(t *testing.T) storage.Storage
+
+// Run прогоняет все сценарии контракта как подтесты t.
+func Run(t *testing.T, newStorage Factory) {
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.run(t, context.Background(), newStorage(t))
+		})
+	}
+}
+
+var cases = []struct {
+	name string
+	run  func(t *testing.T, ctx context.Context, s storage.Storage)
+}{
+	{"PostRoundTrip", testPostRoundTrip},
+	{"PostNotFound", testPostNotFound},
+	{"AllPosts", testAllPosts},
+	{"UpdatePost", testUpdatePost},
+	{"PostStatus", testPostStatus},
+	{"DuePosts", testDuePosts},
+	{"CommentRoundTrip", testCommentRoundTrip},
+	{"CommentNotFound", testCommentNotFound},
+	{"CommentForMissingPost", testCommentForMissingPost},
+	{"NoComments", testNoComments},
+	{"CommentsOrder", testCommentsOrder},
+	{"CommentsSubSecondOrder", testCommentsSubSecondOrder},
+	{"CommentsPagination", testCommentsPagination},
+	{"CommentsAfter", testCommentsAfter},
+	{"UpdateComment", testUpdateComment},
+	{"DeleteCommentCascade", testDeleteCommentCascade},
+	{"DeletePost", testDeletePost},
+	{"TxCommit", testTxCommit},
+	{"TxRollback", testTxRollback},
+	{"Bans", testBans},
+	{"ModerationLog", testModerationLog},
+}
+
+// base — фиксированное время сценариев в UTC.
+var base = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func at(offset time.Duration) time.Time {
+	return base.Add(offset)
+}
+
+// ID — UUID: в PostgreSQL колонки id имеют тип uuid.
+func newID() string { return uuid.NewString() }
+
+func createPost(t *testing.T, ctx context.Context, s storage.Storage) *model.Post {
+	t.Helper()
+	post := &model.Post{
+		ID:              newID(),
+		Title:           "Заголовок",
+		Content:         "Текст поста",
+		Author:          "Автор",
+		CommentsAllowed: true,
+		CreatedAt:       at(0),
+		Status:          model.PostStatusPublished,
+	}
+	post.PublishAt = &post.CreatedAt
+	require.NoError(t, s.CreatePost(ctx, post))
+	return post
+}
+
+func createComment(t *testing.T, ctx context.Context, s storage.Storage, postID string, parentID *string, createdAt time.Time) *model.Comment {
+	t.Helper()
+	comment := &model.Comment{
+		ID:        newID(),
+		PostID:    postID,
+		ParentID:  parentID,
+		Author:    "Комментатор",
+		Content:   "Комментарий",
+		CreatedAt: createdAt,
+	}
+	require.NoError(t, s.CreateComment(ctx, comment))
+	return comment
+}
+
+// assertTime проверяет, что хранилище вернуло тот же момент времени
+// в UTC, независимо от пояса сессии PostgreSQL.
+func assertTime(t *testing.T, expected, actual time.Time) {
+	t.Helper()
+	assert.True(t, expected.Equal(actual), "ожидалось %s, получено %s", expected, actual)
+	assert.Equal(t, time.UTC, actual.Location())
+}
+
+func assertPost(t *testing.T, expected, actual *model.Post) {
+	t.Helper()
+	assert.Equal(t, expected.ID, actual.ID)
+	assert.Equal(t, expected.Title, actual.Title)
+	assert.Equal(t, expected.Content, actual.Content)
+	assert.Equal(t, expected.Author, actual.Author)
+	assert.Equal(t, expected.CommentsAllowed, actual.CommentsAllowed)
+	assertTime(t, expected.CreatedAt, actual.CreatedAt)
+	assert.Equal(t, expected.Status, actual.Status)
+	if assert.Equal(t, expected.PublishAt == nil, actual.PublishAt == nil, "publishAt") && expected.PublishAt != nil {
+		assertTime(t, *expected.PublishAt, *actual.PublishAt)
+	}
+}
+
+func assertComment(t *testing.T, expected, actual *model.Comment) {
+	t.Helper()
+	assert.Equal(t, expected.ID, actual.ID)
+	assert.Equal(t, expected.PostID, actual.PostID)
+	assert.Equal(t, expected.ParentID, actual.ParentID)
+	assert.Equal(t, expected.Author, actual.Author)
+	assert.Equal(t, expected.Content, actual.Content)
+	assertTime(t, expected.CreatedAt, actual.CreatedAt)
+}
+
+func commentIDs(comments []*model.Comment) []string {
+	ids := make([]string, 0, len(comments))
+	for _, c := range comments {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+func testPostRoundTrip(t *testing.T, ctx context.Context, s storage.Storage) {
+	post := createPost(t, ctx, s)
+
+	got, err := s.GetPostByID(ctx, post.ID)
+	require.NoError(t, err)
+	assertPost(t, post, got)
+}
+
+func testPostNotFound(t *testing.T, ctx context.Context, s storage.Storage) {
+	_, err := s.GetPostByID(ctx, newID())
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.ErrorIs(t, s.UpdatePost(ctx, &model.Post{ID: newID()}), sql.ErrNoRows)
+}
+
+func testAllPosts(t *testing.T, ctx context.Context, s storage.Storage) {
+	posts, err := s.GetAllPosts(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, posts)
+
+	first := createPost(t, ctx, s)
+	second := createPost(t, ctx, s)
+
+	// Порядок постов контрактом не задан
+	posts, err = s.GetAllPosts(ctx)
+	require.NoError(t, err)
+	ids := make([]string, 0, len(posts))
+	for _, p := range posts {
+		ids = append(ids, p.ID)
+	}
+	assert.ElementsMatch(t, []string{first.ID, second.ID}, ids)
+}
+
+func testUpdatePost(t *testing.T, ctx context.Context, s storage.Storage) {
+	post := createPost(t, ctx, s)
+
+	// Автор и время создания не меняются
+	update := *post
+	update.Title = "Новый заголовок"
+	update.Content = "Новый текст"
+	update.CommentsAllowed = false
+	update.Status = model.PostStatusArchived
+	update.Author = "Другой автор"
+	update.CreatedAt = at(time.Hour)
+	require.NoError(t, s.UpdatePost(ctx, &update))
+
+	got, err := s.GetPostByID(ctx, post.ID)
+	require.NoError(t, err)
+	want := *post
+	want.Title = update.Title
+	want.Content = update.Content
+	want.CommentsAllowed = false
+	want.Status = model.PostStatusArchived
+	assertPost(t, &want, got)
+}
+
+func testPostStatus(t *testing.T, ctx context.Context, s storage.Storage) {
+	// Черновик хранится без времени публикации
+	draft := &model.Post{ID: newID(), Title: "Черновик", Author: "Автор", CreatedAt: at(0), Status: model.PostStatusDraft}
+	require.NoError(t, s.CreatePost(ctx, draft))
+	got, err := s.GetPostByID(ctx, draft.ID)
+	require.NoError(t, err)
+	assertPost(t, draft, got)
+
+	scheduled := *draft
+	publishAt := at(time.Hour + time.Microsecond)
+	scheduled.Status, scheduled.PublishAt = model.PostStatusScheduled, &publishAt
+	require.NoError(t, s.UpdatePost(ctx, &scheduled))
+	got, err = s.GetPostByID(ctx, draft.ID)
+	require.NoError(t, err)
+	assertPost(t, &scheduled, got)
+
+	// Возврат в черновики стирает время публикации
+	require.NoError(t, s.UpdatePost(ctx, draft))
+	got, err = s.GetPostByID(ctx, draft.ID)
+	require.NoError(t, err)
+	assertPost(t, draft, got)
+}
+
+func testDuePosts(t *testing.T, ctx context.Context, s storage.Storage) {
+	schedule := func(offset time.Duration) *model.Post {
+		publishAt := at(offset)
+		post := &model.Post{ID: newID(), Title: "Анонс", Author: "Автор", CreatedAt: at(0), Status: model.PostStatusScheduled, PublishAt: &publishAt}
+		require.NoError(t, s.CreatePost(ctx, post))
+		return post
+	}
+	later := schedule(2 * time.Hour)
+	onTime := schedule(time.Hour)
+	overdue := schedule(time.Minute)
+	createPost(t, ctx, s)
+	draft := &model.Post{ID: newID(), Title: "Черновик", Author: "Автор", CreatedAt: at(0), Status: model.PostStatusDraft}
+	require.NoError(t, s.CreatePost(ctx, draft))
+
+	// Срок сравнивается включительно, опубликованные и черновики не попадают
+	due, err := s.GetDuePosts(ctx, at(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, due, 2)
+	assertPost(t, overdue, due[0])
+	assertPost(t, onTime, due[1])
+
+	due, err = s.GetDuePosts(ctx, at(0))
+	require.NoError(t, err)
+	assert.Empty(t, due)
+
+	// Время в другом поясе сравнивается как момент
+	due, err = s.GetDuePosts(ctx, at(3*time.Hour).In(time.FixedZone("MSK", 3*60*60)))
+	require.NoError(t, err)
+	assert.Len(t, due, 3)
+	assert.Equal(t, later.ID, due[2].ID)
+}
+
+func testCommentRoundTrip(t *testing.T, ctx context.Context, s storage.Storage) {
+	post := createPost(t, ctx, s)
+	parent := createComment(t, ctx, s, post.ID, nil, at(time.Minute))
+	reply := createComment(t, ctx, s, post.ID, &parent.ID, at(2*time.Minute))
+
+	got, err := s.GetCommentByID(ctx, parent.ID)
+	require.NoError(t, err)
+	assertComment(t, parent, got)
+
+	got, err = s.GetCommentByID(ctx, reply.ID)
+	require.NoError(t, err)
+	assertComment(t, reply, got)
+
+	comments, err := s.GetCommentsByPostID(ctx, post.ID, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, comments, 2)
+	assertComment(t, reply, comments[0])
+	assertComment(t, parent, comments[1])
+}
+
+func testCommentNotFound(t *testing.T, ctx context.Context, s storage.Storage) {
+	_, err := s.GetCommentByID(ctx, newID())
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.ErrorIs(t, s.UpdateComment(ctx, &model.Comment{ID: newID(), Content: "x"}), sql.ErrNoRows)
+	assert.ErrorIs(t, s.DeleteComment(ctx, newID()), sql.ErrNoRows)
+}
+
+func testCommentForMissingPost(t *testing.T, ctx context.Context, s storage.Storage) {
+	err := s.CreateComment(ctx, &model.Comment{ID: newID(), PostID: newID(), CreatedAt: at(0)})
+	assert.Error(t, err)
+
+	post := createPost(t, ctx, s)
+	missing := newID()
+	err = s.CreateComment(ctx, &model.Comment{ID: newID(), PostID: post.ID, ParentID: &missing, CreatedAt: at(0)})
+	assert.Error(t, err)
+}
+
+func testNoComments(t *testing.T, ctx context.Context, s storage.Storage) {
+	post := createPost(t, ctx, s)
+
+	comments, err := s.GetCommentsByPostID(ctx, post.ID, 10, 0)
+	require.NoError(t, err)
+	assert.Empty(t, comments)
+
+	comments, err = s.GetCommentsByPostID(ctx, newID(), 10, 0)
+	require.NoError(t, err)
+	assert.Empty(t, comments)
+}
+
+// Комментарии — от новых к старым, при равном времени — по возрастанию ID
+func testCommentsOrder(t *testing.T, ctx context.Context, s storage.Storage) {
+	post := createPost(t, ctx, s)
+	oldest := createComment(t, ctx, s, post.ID, nil, at(time.Minute))
+	newest := createComment(t, ctx, s, post.ID, nil, at(3*time.Minute))
+	// Вставлен последним, но по времени посередине
+	middle := createComment(t, ctx, s, post.ID, nil, at(2*time.Minute))
+	tieA := createComment(t, ctx, s, post.ID, nil, at(4*time.Minute))
+	tieB := createComment(t, ctx, s, post.ID, nil, at(4*time.Minute))
+	if tieB.ID < tieA.ID {
+		tieA, tieB = tieB, tieA
+	}
+
+	comments, err := s.GetCommentsByPostID(ctx, post.ID, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{tieA.ID, tieB.ID, newest.ID, middle.ID, oldest.ID}, commentIDs(comments))
+}
+
+// Доли секунды сохраняются до микросекунды (точность TIMESTAMPTZ), поэтому
+// комментарии одной секунды упорядочены по времени, а не по ID.
+func testCommentsSubSecondOrder(t *testing.T, ctx context.Context, s storage.Storage) {
+	post := createPost(t, ctx, s)
+	var want []string
+	for _, offset := range []time.Duration{0, time.Microsecond, 2 * time.Microsecond, 100 * time.Millisecond, 999999 * time.Microsecond} {
+		want = append([]string{createComment(t, ctx, s, post.ID, nil, at(time.Second+offset)).ID}, want...)
+	}
+
+	comments, err := s.GetCommentsByPostID(ctx, post.ID, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, want, commentIDs(comments))
+	assertTime(t, at(time.Second+time.Microsecond), comments[3].CreatedAt)
+}
+
+// Комментарии после позиции — по возрастанию (created_at, id); при равном
+// времени позиция отсекает комментарии с ID не больше заданного
+func testCommentsAfter(t *testing.T, ctx context.Context, s storage.Storage) {
+	post := createPost(t, ctx, s)
+	other := createPost(t, ctx, s)
+	first := createComment(t, ctx, s, post.ID, nil, at(time.Minute))
+	tieA := createComment(t, ctx, s, post.ID, nil, at(2*time.Minute+time.Microsecond))
+	tieB := createComment(t, ctx, s, post.ID, nil, at(2*time.Minute+time.Microsecond))
+	if tieB.ID < tieA.ID {
+		tieA, tieB = tieB, tieA
+	}
+	last := createComment(t, ctx, s, post.ID, nil, at(3*time.Minute))
+	createComment(t, ctx, s, other.ID, nil, at(4*time.Minute))
+
+	comments, err := s.GetCommentsAfter(ctx, post.ID, first.CreatedAt, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{tieA.ID, tieB.ID, last.ID}, commentIDs(comments))
+
+	comments, err = s.GetCommentsAfter(ctx, post.ID, tieA.CreatedAt, tieA.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{tieB.ID, last.ID}, commentIDs(comments))
+
+	comments, err = s.GetCommentsAfter(ctx, post.ID, last.CreatedAt, last.ID)
+	require.NoError(t, err)
+	assert.Empty(t, comments)
+}
+
+func testCommentsPagination(t *testing.T, ctx context.Context, s storage.Storage) {
+	post := createPost(t, ctx, s)
+	var all []string
+	for i := 5; i > 0; i-- {
+		all = append(all, createComment(t, ctx, s, post.ID, nil, at(time.Duration(i)*time.Minute)).ID)
+	}
+
+	comments, err := s.GetCommentsByPostID(ctx, post.ID, 2, 1)
+	require.NoError(t, err)
+	assert.Equal(t, all[1:3], commentIDs(comments))
+
+	comments, err = s.GetCommentsByPostID(ctx, post.ID, 10, 3)
+	require.NoError(t, err)
+	assert.Equal(t, all[3:], commentIDs(comments))
+
+	// limit 0 — без ограничения
+	comments, err = s.GetCommentsByPostID(ctx, post.ID, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, all, commentIDs(comments))
+
+	comments, err = s.GetCommentsByPostID(ctx, post.ID, 10, 5)
+	require.NoError(t, err)
+	assert.Empty(t, comments)
+}
+
+func testUpdateComment(t *testing.T, ctx context.Context, s storage.Storage) {
+	post := createPost(t, ctx, s)
+	comment := createComment(t, ctx, s, post.ID, nil, at(time.Minute))
+
+	update := *comment
+	update.Content = "Исправлено"
+	require.NoError(t, s.UpdateComment(ctx, &update))
+
+	got, err := s.GetCommentByID(ctx, comment.ID)
+	require.NoError(t, err)
+	assertComment(t, &update, got)
+}
+
+func testDeleteCommentCascade(t *testing.T, ctx context.Context, s storage.Storage) {
+	post := createPost(t, ctx, s)
+	root := createComment(t, ctx, s, post.ID, nil, at(time.Minute))
+	reply := createComment(t, ctx, s, post.ID, &root.ID, at(2*time.Minute))
+	nested := createComment(t, ctx, s, post.ID, &reply.ID, at(3*time.Minute))
+	other := createComment(t, ctx, s, post.ID, nil, at(4*time.Minute))
+
+	require.NoError(t, s.DeleteComment(ctx, root.ID))
+
+	for _, id := range []string{root.ID, reply.ID, nested.ID} {
+		_, err := s.GetCommentByID(ctx, id)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	}
+	comments, err := s.GetCommentsByPostID(ctx, post.ID, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{other.ID}, commentIDs(comments))
+}
+
+func testDeletePost(t *testing.T, ctx context.Context, s storage.Storage) {
+	post := createPost(t, ctx, s)
+	root := createComment(t, ctx, s, post.ID, nil, at(time.Minute))
+	reply := createComment(t, ctx, s, post.ID, &root.ID, at(2*time.Minute))
+	other := createPost(t, ctx, s)
+	kept := createComment(t, ctx, s, other.ID, nil, at(time.Minute))
+
+	require.NoError(t, s.DeletePost(ctx, post.ID))
+
+	_, err := s.GetPostByID(ctx, post.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	for _, id := range []string{root.ID, reply.ID} {
+		_, err := s.GetCommentByID(ctx, id)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	}
+	comments, err := s.GetCommentsByPostID(ctx, post.ID, 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, comments)
+
+	_, err = s.GetCommentByID(ctx, kept.ID)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, s.DeletePost(ctx, post.ID), sql.ErrNoRows)
+}
+
+func testTxCommit(t *testing.T, ctx context.Context, s storage.Storage) {
+	post := createPost(t, ctx, s)
+
+	var comment *model.Comment
+	err := s.WithTx(ctx, func(tx storage.Storage) error {
+		got, err := tx.GetPostByID(ctx, post.ID)
+		require.NoError(t, err)
+		update := *got
+		update.CommentsAllowed = false
+		require.NoError(t, tx.UpdatePost(ctx, &update))
+		comment = createComment(t, ctx, tx, post.ID, nil, at(time.Minute))
+		return nil
+	})
+	require.NoError(t, err)
+
+	got, err := s.GetPostByID(ctx, post.ID)
+	require.NoError(t, err)
+	assert.False(t, got.CommentsAllowed)
+	_, err = s.GetCommentByID(ctx, comment.ID)
+	assert.NoError(t, err)
+}
+
+func testTxRollback(t *testing.T, ctx context.Context, s storage.Storage) {
+	post := createPost(t, ctx, s)
+	kept := createComment(t, ctx, s, post.ID, nil, at(time.Minute))
+
+	errAbort := errors.New("abort")
+	var added *model.Comment
+	err := s.WithTx(ctx, func(tx storage.Storage) error {
+		added = createComment(t, ctx, tx, post.ID, nil, at(2*time.Minute))
+		require.NoError(t, tx.DeleteComment(ctx, kept.ID))
+		update := *post
+		update.CommentsAllowed = false
+		require.NoError(t, tx.UpdatePost(ctx, &update))
+
+		// Внутри транзакции изменения видны
+		comments, err := tx.GetCommentsByPostID(ctx, post.ID, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{added.ID}, commentIDs(comments))
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+
+	got, err := s.GetPostByID(ctx, post.ID)
+	require.NoError(t, err)
+	assert.True(t, got.CommentsAllowed)
+	comments, err := s.GetCommentsByPostID(ctx, post.ID, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{kept.ID}, commentIDs(comments))
+}
+
+func testBans(t *testing.T, ctx context.Context, s storage.Storage) {
+	bans, ok := storage.As[storage.BanStore](s)
+	if !ok {
+		t.Skip("хранилище не хранит блокировки")
+	}
+
+	list, err := bans.ListBans(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+
+	require.NoError(t, bans.SaveBan(ctx, "спамер", &model.BannedUser{Author: "Спамер", Reason: "реклама", BannedAt: at(0)}))
+	require.NoError(t, bans.SaveBan(ctx, "спамер", &model.BannedUser{Author: "СПАМЕР", Reason: "снова", BannedAt: at(time.Minute)}))
+	list, err = bans.ListBans(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "СПАМЕР", list[0].Author)
+	assert.Equal(t, "снова", list[0].Reason)
+	assertTime(t, at(time.Minute), list[0].BannedAt)
+
+	require.NoError(t, bans.DeleteBan(ctx, "спамер"))
+	assert.ErrorIs(t, bans.DeleteBan(ctx, "спамер"), sql.ErrNoRows)
+	list, err = bans.ListBans(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+// Журнал модерации — от новых записей к старым, с пагинацией; остается не
+// больше keep последних записей
+func testModerationLog(t *testing.T, ctx context.Context, s storage.Storage) {
+	journal, ok := storage.As[storage.ModerationLogStore](s)
+	if !ok {
+		t.Skip("хранилище не хранит журнал модерации")
+	}
+
+	postID := newID()
+	var ids []string
+	for i := range 4 {
+		rec := &model.ModerationRecord{
+			ID:       newID(),
+			TargetID: newID(),
+			Kind:     "comment",
+			Author:   "Автор",
+			Action:   model.ModerationActionFlag,
+			Decisions: []*model.ModerationDecision{
+				{Filter: "banned_words", Action: model.ModerationActionFlag, Reason: "запрещённое слово"},
+				{Filter: "max_links", Action: model.ModerationActionAllow},
+			},
+			CreatedAt: at(time.Duration(i) * time.Minute),
+		}
+		if i%2 == 0 {
+			rec.PostID = &postID
+		}
+		require.NoError(t, journal.AddModerationRecord(ctx, rec, 3))
+		ids = append(ids, rec.ID)
+	}
+
+	list, err := journal.ListModerationRecords(ctx, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	assert.Equal(t, []string{ids[3], ids[2], ids[1]}, []string{list[0].ID, list[1].ID, list[2].ID})
+	assert.Nil(t, list[0].PostID)
+	require.NotNil(t, list[1].PostID)
+	assert.Equal(t, postID, *list[1].PostID)
+	assert.Equal(t, model.ModerationActionFlag, list[0].Action)
+	require.Len(t, list[0].Decisions, 2)
+	assert.Equal(t, "запрещённое слово", list[0].Decisions[0].Reason)
+	assertTime(t, at(3*time.Minute), list[0].CreatedAt)
+
+	list, err = journal.ListModerationRecords(ctx, 1, 1)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, ids[2], list[0].ID)
+
+	list, err = journal.ListModerationRecords(ctx, 10, 5)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
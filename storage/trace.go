@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer возвращает трейсер хранилища из глобального провайдера на момент
+// вызова, чтобы span попадали в провайдер, установленный после старта.
+func tracer() trace.Tracer {
+	return otel.Tracer("ozon_test/storage")
+}
+
+// startSpan открывает span запроса к PostgreSQL; name — имя SQL-инструкции
+// (совпадает с методом хранилища).
+func startSpan(ctx context.Context, name, query string) (context.Context, trace.Span) {
+	return startDBSpan(ctx, "postgres", "postgresql", name, query)
+}
+
+// startDBSpan открывает span запроса к SQL-базе system; имя span —
+// prefix.name.
+func startDBSpan(ctx context.Context, prefix, system, name, query string) (context.Context, trace.Span) {
+	return tracer().Start(ctx, prefix+"."+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", system),
+			attribute.String("db.operation.name", name),
+			attribute.String("db.query.text", strings.Join(strings.Fields(query), " ")),
+		))
+}
+
+// endSpan завершает span запроса; sql.ErrNoRows ошибкой не считается.
+func endSpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
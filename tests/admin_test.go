@@ -0,0 +1,182 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"ozon_test/auth"
+	"ozon_test/filter"
+	"ozon_test/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест проверки API-ключа: неверный ключ — 401, без ключа — анонимный
+// запрос, ключ в X-API-Key или Authorization дает права администратора
+func TestAuthMiddleware(t *testing.T) {
+	var gotErr error
+	handler := auth.Middleware(auth.Keys{Admin: []string{"key-1", "key-2"}}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotErr = auth.Require(r.Context())
+	}))
+
+	serve := func(header, value string) int {
+		req := httptest.NewRequest(http.MethodPost, "/query", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve(auth.HeaderAPIKey, "wrong"))
+
+	assert.Equal(t, http.StatusOK, serve("", ""))
+	assert.ErrorIs(t, gotErr, auth.ErrUnauthorized)
+
+	assert.Equal(t, http.StatusOK, serve(auth.HeaderAPIKey, "key-2"))
+	assert.NoError(t, gotErr)
+
+	assert.Equal(t, http.StatusOK, serve("Authorization", "Bearer key-1"))
+	assert.NoError(t, gotErr)
+
+	// Без настроенных ключей административные операции отключены
+	handler = auth.Middleware(auth.Keys{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotErr = auth.Require(r.Context())
+	}))
+	assert.Equal(t, http.StatusOK, serve("", ""))
+	assert.ErrorIs(t, gotErr, auth.ErrDisabled)
+
+	// Ключ автора задает имя автора запроса, но не права администратора
+	authors, err := auth.ParseAuthorKeys([]string{"Автор=author-key"})
+	require.NoError(t, err)
+	var gotAuthor string
+	handler = auth.Middleware(auth.Keys{Admin: []string{"key-1"}, Authors: authors}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotErr = auth.Require(r.Context())
+		gotAuthor, _ = auth.Author(r.Context())
+	}))
+	assert.Equal(t, http.StatusOK, serve(auth.HeaderAPIKey, "author-key"))
+	assert.ErrorIs(t, gotErr, auth.ErrUnauthorized)
+	assert.Equal(t, "Автор", gotAuthor)
+
+	assert.Equal(t, http.StatusOK, serve("", ""))
+	assert.Empty(t, gotAuthor)
+
+	_, err = auth.ParseAuthorKeys([]string{"без-ключа"})
+	assert.Error(t, err)
+}
+
+// Тест административных операций: без ключа отказ, блокировка отклоняет
+// новый контент автора, статистика и удаление поста с комментариями
+func TestAdminOperations(t *testing.T) {
+	pipeline := filter.NewPipeline(nil)
+	pipeline.UseBans(filter.NewBans())
+	resolver := newResolver(service.Deps{Filter: pipeline})
+	ctx := context.Background()
+	admin := auth.WithAdmin(ctx)
+
+	post, err := resolver.Mutation().CreatePost(ctx, "Пост", "Текст", "Автор", true)
+	require.NoError(t, err)
+	_, err = resolver.Mutation().AddComment(ctx, post.ID, nil, "Спамер", "купите")
+	require.NoError(t, err)
+
+	_, err = resolver.Mutation().BanUser(ctx, "Спамер", nil)
+	assert.ErrorContains(t, err, "API-ключом")
+	_, err = resolver.Query().Stats(ctx)
+	assert.Error(t, err)
+
+	reason := "спам"
+	ban, err := resolver.Mutation().BanUser(admin, "спамер", &reason)
+	require.NoError(t, err)
+	assert.Equal(t, "спам", ban.Reason)
+
+	_, err = resolver.Mutation().AddComment(ctx, post.ID, nil, "Спамер", "еще раз")
+	var rejected *filter.RejectedError
+	require.ErrorAs(t, err, &rejected)
+	assert.Equal(t, "banned_user", rejected.Decision.Filter)
+
+	_, err = resolver.Mutation().SetCommentsAllowed(ctx, post.ID, false)
+	assert.Error(t, err)
+	_, err = resolver.Mutation().SetCommentsAllowed(admin, post.ID, false)
+	require.NoError(t, err)
+	stats, err := resolver.Query().Stats(admin)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Posts)
+	assert.Equal(t, 1, stats.LockedPosts)
+	assert.Equal(t, 1, stats.Comments)
+	assert.Equal(t, 1, stats.BannedUsers)
+
+	_, err = resolver.Mutation().DeletePost(ctx, post.ID)
+	assert.Error(t, err)
+	ok, err := resolver.Mutation().DeletePost(admin, post.ID)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	_, err = resolver.Query().Post(ctx, post.ID)
+	assert.Error(t, err)
+	_, err = resolver.Mutation().DeletePost(admin, post.ID)
+	assert.ErrorContains(t, err, "не найден")
+
+	unbanned, err := resolver.Mutation().UnbanUser(admin, "Спамер")
+	require.NoError(t, err)
+	assert.True(t, unbanned)
+}
+
+// Тест блокировок в хранилище: переживают перезапуск и после Reload
+// действуют на другом экземпляре над той же базой
+func TestStoredBans(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "ozon.db")
+
+	first, err := filter.NewStoredBans(ctx, openSQLite(t, path))
+	require.NoError(t, err)
+	second, err := filter.NewStoredBans(ctx, openSQLite(t, path))
+	require.NoError(t, err)
+	spam := &filter.Content{Kind: filter.KindComment, Author: " спамер ", Body: "купите"}
+
+	_, err = first.Ban(ctx, "Спамер", "реклама")
+	require.NoError(t, err)
+	assert.Equal(t, filter.Reject, first.Check(spam).Action)
+	assert.Equal(t, filter.Allow, second.Check(spam).Action)
+	require.NoError(t, second.Reload(ctx))
+	assert.Equal(t, filter.Reject, second.Check(spam).Action)
+
+	restarted, err := filter.NewStoredBans(ctx, openSQLite(t, path))
+	require.NoError(t, err)
+	require.Len(t, restarted.List(), 1)
+	assert.Equal(t, "реклама", restarted.List()[0].Reason)
+
+	unbanned, err := second.Unban(ctx, "СПАМЕР")
+	require.NoError(t, err)
+	assert.True(t, unbanned)
+	require.NoError(t, first.Reload(ctx))
+	assert.Equal(t, filter.Allow, first.Check(spam).Action)
+	unbanned, err = first.Unban(ctx, "Спамер")
+	require.NoError(t, err)
+	assert.False(t, unbanned)
+}
+
+// Тест журнала модерации в хранилище: записи переживают перезапуск и видны
+// другому экземпляру над той же базой, старые записи вытесняются
+func TestStoredJournal(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "ozon.db")
+
+	first := filter.NewPipeline(filter.NewStoredJournal(openSQLite(t, path), 2), filter.NewMaxLinks(0))
+	for _, body := range []string{"http://a.ru", "http://b.ru", "http://c.ru"} {
+		_, err := first.Run(ctx, &filter.Content{Kind: filter.KindComment, Author: "Автор", Body: body})
+		require.Error(t, err)
+	}
+
+	restarted := filter.NewStoredJournal(openSQLite(t, path), 2)
+	records, err := restarted.List(ctx, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, filter.Reject, records[0].Action)
+	assert.Equal(t, "Автор", records[0].Author)
+	require.NotEmpty(t, records[0].Decisions)
+	assert.Equal(t, "max_links", records[0].Decisions[0].Filter)
+}
@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ozon_test/auth"
+	"ozon_test/client"
+	"ozon_test/client/codegen"
+	"ozon_test/filter"
+	"ozon_test/graph"
+	"ozon_test/service"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newClientServer поднимает GraphQL-сервер с HTTP и websocket, как в main.
+func newClientServer(t *testing.T, resolver *graph.Resolver, keys auth.Keys) *httptest.Server {
+	t.Helper()
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: resolver}))
+	srv.AddTransport(transport.Websocket{KeepAlivePingInterval: 10 * time.Millisecond})
+	srv.AddTransport(transport.POST{})
+	ts := httptest.NewServer(auth.Middleware(keys, srv))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// Тест сгенерированного клиента: мутации, итератор страниц, подписка по
+// websocket и коды ошибок GraphQL
+func TestClient(t *testing.T) {
+	pipeline := filter.NewPipeline(nil)
+	pipeline.UseBans(filter.NewBans())
+	ts := newClientServer(t, newResolver(service.Deps{Filter: pipeline}), auth.Keys{Admin: []string{"secret"}})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c := client.New(ts.URL)
+	post, err := c.CreatePost(ctx, "Клиент", "Текст", "Автор", true)
+	require.NoError(t, err)
+	assert.Equal(t, "Клиент", post.Title)
+
+	sub, err := c.CommentAdded(ctx, post.ID, nil)
+	require.NoError(t, err)
+	defer sub.Close()
+
+	for i := 0; i < 5; i++ {
+		_, err := c.AddComment(ctx, post.ID, nil, "Читатель", "Комментарий")
+		require.NoError(t, err)
+	}
+
+	select {
+	case comment := <-sub.Events():
+		assert.Equal(t, post.ID, comment.PostID)
+		assert.Equal(t, "Комментарий", comment.Content)
+	case <-ctx.Done():
+		t.Fatal("Подписка не получила комментарий")
+	}
+	require.NoError(t, sub.Close())
+	assert.ErrorIs(t, sub.Err(), client.ErrSubscriptionClosed)
+
+	var ids []string
+	for comment, err := range c.AllComments(ctx, post.ID, 2) {
+		require.NoError(t, err)
+		ids = append(ids, comment.ID)
+	}
+	assert.Len(t, ids, 5)
+
+	// Административные операции требуют ключа
+	_, err = c.Stats(ctx)
+	assert.Equal(t, "UNAUTHENTICATED", client.ErrorCode(err))
+
+	var httpErr *client.HTTPError
+	_, err = client.New(ts.URL, client.WithAPIKey("wrong")).Stats(ctx)
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusUnauthorized, httpErr.StatusCode)
+
+	admin := client.New(ts.URL, client.WithBearerToken("secret"))
+	stats, err := admin.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 5, stats.Comments)
+}
+
+// Тест повторов: запросы повторяются при 503, мутации — нет
+func TestClientRetry(t *testing.T) {
+	ts := newClientServer(t, newResolver(service.Deps{}), auth.Keys{})
+	var calls atomic.Int32
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1)%2 == 1 {
+			http.Error(w, "перегрузка", http.StatusServiceUnavailable)
+			return
+		}
+		proxy, _ := http.NewRequestWithContext(r.Context(), r.Method, ts.URL, r.Body)
+		proxy.Header = r.Header
+		resp, err := http.DefaultClient.Do(proxy)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+		_, _ = io.Copy(w, resp.Body)
+	}))
+	defer flaky.Close()
+
+	ctx := context.Background()
+	c := client.New(flaky.URL, client.WithRetry(3, time.Millisecond))
+
+	posts, err := c.Posts(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, posts)
+	assert.Equal(t, int32(2), calls.Load())
+
+	var httpErr *client.HTTPError
+	_, err = c.CreatePost(ctx, "Пост", "Текст", "Автор", true)
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusServiceUnavailable, httpErr.StatusCode)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+// Тест актуальности client/generated.go: после изменения схемы нужно
+// выполнить go generate ./client
+func TestClientGenerated(t *testing.T) {
+	schema, err := os.ReadFile("../graph/schema.graphql")
+	require.NoError(t, err)
+	want, err := codegen.Generate("schema.graphql", string(schema))
+	require.NoError(t, err)
+	got, err := os.ReadFile("../client/generated.go")
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got), "client/generated.go устарел: выполните go generate ./client")
+}
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ozon_test/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+// Тест приоритета источников: файл < окружение < флаги
+func TestConfigPrecedence(t *testing.T) {
+	path := writeConfig(t, "config.yaml", `
+server:
+  addr: ":9000"
+  shutdownTimeout: 30s
+storage:
+  type: postgres
+  dsn: postgres://app:secret@db/app
+log:
+  level: debug
+`)
+	t.Setenv("LOG_LEVEL", "warn")
+	t.Setenv("FILTER_BANNED_WORDS", "спам, реклама")
+
+	cfg, err := config.Load("test", []string{"-config", path, "-log.level", "error", "-server.addr", ":9100"})
+	require.NoError(t, err)
+	assert.Equal(t, ":9100", cfg.Server.Addr)
+	assert.Equal(t, 30*time.Second, cfg.Server.ShutdownTimeout)
+	assert.Equal(t, "postgres", cfg.Storage.Type)
+	assert.Equal(t, "error", cfg.Log.Level)
+	assert.Equal(t, []string{"спам", "реклама"}, cfg.Filter.BannedWords)
+	assert.Equal(t, 2000, cfg.Limits.CommentMaxLength)
+
+	toml := writeConfig(t, "config.toml", "[tracing]\nexporter = \"stdout\"\nsampleRatio = 0.5\n")
+	cfg, err = config.Load("test", []string{"-config", toml})
+	require.NoError(t, err)
+	assert.Equal(t, "stdout", cfg.Tracing.Exporter)
+	assert.Equal(t, 0.5, cfg.Tracing.SampleRatio)
+}
+
+// Тест ошибок конфигурации при запуске
+func TestConfigValidation(t *testing.T) {
+	_, err := config.Load("test", []string{"-storage.type", "postgress"})
+	assert.ErrorContains(t, err, "postgress")
+
+	_, err = config.Load("test", []string{"-storage.type", "postgres"})
+	assert.ErrorContains(t, err, "storage.dsn")
+
+	_, err = config.Load("test", []string{"-config", writeConfig(t, "bad.yaml", "storage:\n  typ: memory\n")})
+	assert.ErrorContains(t, err, "typ")
+
+	_, err = config.Load("test", []string{"-limits.commentMaxLength", "5000"})
+	assert.ErrorContains(t, err, "limits.commentMaxLength")
+	_, err = config.Load("test", []string{"-storage.retryBackoff", "0s"})
+	assert.ErrorContains(t, err, "storage.retryBackoff")
+	_, err = config.Load("test", []string{"-storage.replicaCheckInterval", "-1s"})
+	assert.ErrorContains(t, err, "storage.replicaCheckInterval")
+	_, err = config.Load("test", []string{"-auth.authorKeys", "Автор"})
+	assert.ErrorContains(t, err, "auth.authorKeys")
+	_, err = config.Load("test", []string{"-auth.apiKeys", "k", "-auth.authorKeys", "Автор=k"})
+	assert.ErrorContains(t, err, "совпадает с ключом администратора")
+
+	t.Setenv("SHUTDOWN_TIMEOUT", "15")
+	_, err = config.Load("test", nil)
+	assert.ErrorContains(t, err, "SHUTDOWN_TIMEOUT")
+}
+
+// Тест вывода конфигурации: секреты скрыты, результат читается обратно
+func TestConfigPrint(t *testing.T) {
+	t.Setenv("API_KEYS", "key-1,key-2")
+	cfg, err := config.Load("test", []string{"-storage.type", "postgres", "-storage.dsn", "host=db user=app password=secret"})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, config.Print(&buf, cfg))
+	assert.NotContains(t, buf.String(), "secret")
+	assert.NotContains(t, buf.String(), "key-1")
+	assert.Contains(t, buf.String(), "password=xxxxx")
+
+	printed, err := config.Load("test", []string{"-config", writeConfig(t, "printed.yaml", buf.String())})
+	require.NoError(t, err)
+	assert.Equal(t, cfg.Server, printed.Server)
+	assert.Equal(t, "postgres://app:xxxxx@db/app", config.MaskDSN("postgres://app:secret@db/app"))
+}
@@ -0,0 +1,258 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ozon_test/auth"
+	"ozon_test/graph"
+	"ozon_test/graph/model"
+	"ozon_test/service"
+	"ozon_test/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock — часы, которые тест переводит вручную.
+type fakeClock struct{ now atomic.Pointer[time.Time] }
+
+func newFakeClock(t time.Time) *fakeClock {
+	c := &fakeClock{}
+	c.Set(t)
+	return c
+}
+
+func (c *fakeClock) Now() time.Time  { return *c.now.Load() }
+func (c *fakeClock) Set(t time.Time) { c.now.Store(&t) }
+
+func postIDs(posts []*model.Post) []string {
+	ids := make([]string, 0, len(posts))
+	for _, p := range posts {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+// Тест GraphQL-операций с черновиками: автор берется из ключа запроса, без
+// ключа автора — отказ, чужому автору черновик не виден и не публикуется
+func TestPostDraftsRequireAuthorKey(t *testing.T) {
+	resolver := newResolver(service.Deps{})
+	ctx := context.Background()
+	authorCtx := auth.WithAuthor(ctx, "Автор")
+	otherCtx := auth.WithAuthor(ctx, "Читатель")
+
+	_, err := resolver.Mutation().SavePostDraft(ctx, nil, "Черновик", "Текст", true)
+	assert.Equal(t, service.CodeUnauthenticated, graph.ErrorCode(err))
+
+	draft, err := resolver.Mutation().SavePostDraft(authorCtx, nil, "Черновик", "Текст", true)
+	require.NoError(t, err)
+	assert.Equal(t, "Автор", draft.Author)
+
+	for _, c := range []context.Context{ctx, otherCtx} {
+		_, err = resolver.Query().Post(c, draft.ID)
+		assert.ErrorIs(t, err, service.ErrPostNotFound)
+		posts, err := resolver.Query().Posts(c)
+		require.NoError(t, err)
+		assert.Empty(t, posts)
+	}
+	got, err := resolver.Query().Post(authorCtx, draft.ID)
+	require.NoError(t, err)
+	assert.Equal(t, draft.ID, got.ID)
+
+	_, err = resolver.Mutation().PublishPost(ctx, draft.ID)
+	assert.Equal(t, service.CodeUnauthenticated, graph.ErrorCode(err))
+	_, err = resolver.Mutation().PublishPost(otherCtx, draft.ID)
+	assert.ErrorIs(t, err, service.ErrPostNotFound)
+	_, err = resolver.Mutation().SchedulePost(otherCtx, draft.ID, time.Now().Add(time.Hour))
+	assert.ErrorIs(t, err, service.ErrPostNotFound)
+
+	published, err := resolver.Mutation().PublishPost(authorCtx, draft.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.PostStatusPublished, published.Status)
+
+	_, err = resolver.Mutation().ArchivePost(otherCtx, draft.ID)
+	assert.ErrorIs(t, err, service.ErrPostNotFound)
+	archived, err := resolver.Mutation().ArchivePost(authorCtx, draft.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.PostStatusArchived, archived.Status)
+}
+
+// Тест жизненного цикла поста: черновик виден только автору, публикация
+// проходит фильтры и рассылает postCreated, архивный пост закрыт для
+// комментариев и пропадает из общего списка
+func TestPostDrafts(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := newFakeClock(timeAt("2024-03-01T12:00:00Z"))
+	posts, comments := service.New(service.Deps{
+		Store: storage.NewMemoryStorage(),
+		Clock: clock,
+		IDs:   sequentialIDs("d"),
+	})
+	created := posts.WatchCreated(ctx, nil)
+	author, other := "Автор", "Читатель"
+
+	draft, err := posts.SaveDraft(ctx, nil, "Черновик", "Текст", author, true)
+	require.NoError(t, err)
+	assert.Equal(t, model.PostStatusDraft, draft.Status)
+	assert.Nil(t, draft.PublishAt)
+
+	list, err := posts.List(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+	list, err = posts.List(ctx, &other)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+	list, err = posts.List(ctx, &author)
+	require.NoError(t, err)
+	assert.Equal(t, []string{draft.ID}, postIDs(list))
+
+	_, err = posts.Get(ctx, draft.ID, &other)
+	assert.ErrorIs(t, err, service.ErrPostNotFound)
+	_, err = posts.Get(ctx, draft.ID, &author)
+	require.NoError(t, err)
+
+	// Чужой черновик недоступен ни для правки, ни для комментариев
+	_, err = posts.SaveDraft(ctx, &draft.ID, "Чужая правка", "", other, true)
+	assert.ErrorIs(t, err, service.ErrPostNotFound)
+	_, err = posts.Publish(ctx, draft.ID, other)
+	assert.ErrorIs(t, err, service.ErrPostNotFound)
+	_, err = comments.Add(ctx, draft.ID, nil, other, "Привет")
+	assert.ErrorIs(t, err, service.ErrPostNotFound)
+
+	draft, err = posts.SaveDraft(ctx, &draft.ID, "Заголовок", "Итоговый текст", author, true)
+	require.NoError(t, err)
+	assert.Equal(t, "Заголовок", draft.Title)
+
+	clock.Set(timeAt("2024-03-01T13:00:00Z"))
+	published, err := posts.Publish(ctx, draft.ID, author)
+	require.NoError(t, err)
+	assert.Equal(t, model.PostStatusPublished, published.Status)
+	require.NotNil(t, published.PublishAt)
+	assert.Equal(t, timeAt("2024-03-01T13:00:00Z"), *published.PublishAt)
+	assert.Equal(t, timeAt("2024-03-01T12:00:00Z"), published.CreatedAt)
+
+	select {
+	case post := <-created:
+		assert.Equal(t, draft.ID, post.ID)
+	case <-time.After(time.Second):
+		t.Fatal("нет события postCreated при публикации")
+	}
+
+	list, err = posts.List(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{draft.ID}, postIDs(list))
+	_, err = posts.Publish(ctx, draft.ID, author)
+	assert.Equal(t, service.CodeBadUserInput, service.ErrorCode(err))
+	_, err = posts.SaveDraft(ctx, &draft.ID, "Снова черновик", "", author, true)
+	assert.Equal(t, service.CodeBadUserInput, service.ErrorCode(err))
+
+	archived, err := posts.Archive(ctx, draft.ID, author)
+	require.NoError(t, err)
+	assert.Equal(t, model.PostStatusArchived, archived.Status)
+	list, err = posts.List(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+	_, err = posts.Get(ctx, draft.ID, nil)
+	require.NoError(t, err)
+	_, err = comments.Add(ctx, draft.ID, nil, other, "Привет")
+	assert.ErrorIs(t, err, service.ErrCommentsDisabled)
+}
+
+// Тест отложенной публикации: расписание хранится в посте, поэтому пост,
+// срок которого прошел, пока сервис был остановлен, публикует новый
+// экземпляр сервиса с рассылкой postCreated
+func TestScheduledPosts(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "ozon.db")
+	clock := newFakeClock(timeAt("2024-03-01T12:00:00Z"))
+	deps := service.Deps{Store: openSQLite(t, path), Clock: clock, IDs: sequentialIDs("s")}
+	posts, _ := service.New(deps)
+	author := "Автор"
+
+	draft, err := posts.SaveDraft(ctx, nil, "Анонс", "Текст", author, true)
+	require.NoError(t, err)
+	_, err = posts.Schedule(ctx, draft.ID, author, timeAt("2024-03-01T11:00:00Z"))
+	assert.Equal(t, service.CodeBadUserInput, service.ErrorCode(err))
+
+	publishAt := timeAt("2024-03-01T16:00:00+03:00")
+	scheduled, err := posts.Schedule(ctx, draft.ID, author, publishAt)
+	require.NoError(t, err)
+	assert.Equal(t, model.PostStatusScheduled, scheduled.Status)
+	require.NotNil(t, scheduled.PublishAt)
+	assert.True(t, publishAt.Equal(*scheduled.PublishAt))
+
+	// До срока пост не публикуется и виден только автору
+	clock.Set(timeAt("2024-03-01T12:59:59Z"))
+	n, err := posts.PublishDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	_, err = posts.Get(ctx, draft.ID, nil)
+	assert.ErrorIs(t, err, service.ErrPostNotFound)
+
+	// Перезапуск после наступления срока: новое хранилище над тем же файлом
+	require.NoError(t, deps.Store.Close())
+	clock.Set(timeAt("2024-03-01T13:30:00Z"))
+	deps.Store = openSQLite(t, path)
+	posts, _ = service.New(deps)
+	created := posts.WatchCreated(ctx, &author)
+
+	schedulerCtx, stopScheduler := context.WithCancel(ctx)
+	defer stopScheduler()
+	go service.NewScheduler(posts, 10*time.Millisecond).Run(schedulerCtx)
+
+	select {
+	case post := <-created:
+		assert.Equal(t, draft.ID, post.ID)
+		assert.Equal(t, model.PostStatusPublished, post.Status)
+		assert.True(t, publishAt.Equal(*post.PublishAt))
+	case <-time.After(5 * time.Second):
+		t.Fatal("планировщик не опубликовал пост")
+	}
+
+	got, err := posts.Get(ctx, draft.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, model.PostStatusPublished, got.Status)
+	n, err = posts.PublishDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n, "пост публикуется один раз")
+}
+
+// Тест ошибки публикации одного запланированного поста: остальные посты
+// публикуются, неудачный — при следующей проверке
+func TestPublishDueContinuesAfterError(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	clock := newFakeClock(timeAt("2024-03-01T12:00:00Z"))
+	mem := storage.NewMemoryStorage()
+	publishAt := timeAt("2024-03-01T11:00:00Z")
+	for _, id := range []string{"p1", "p2", "p3"} {
+		require.NoError(t, mem.CreatePost(ctx, &model.Post{ID: id, Author: "Автор", Status: model.PostStatusScheduled, PublishAt: &publishAt}))
+	}
+
+	store := &failingReads{Storage: mem, err: errors.New("соединение потеряно"), postID: "p1"}
+	posts, _ := service.New(service.Deps{Store: store, Clock: clock})
+	n, err := posts.PublishDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	for id, status := range map[string]model.PostStatus{"p1": model.PostStatusScheduled, "p2": model.PostStatusPublished, "p3": model.PostStatusPublished} {
+		post, err := mem.GetPostByID(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, status, post.Status, id)
+	}
+
+	posts, _ = service.New(service.Deps{Store: mem, Clock: clock})
+	n, err = posts.PublishDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
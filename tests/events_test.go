@@ -0,0 +1,238 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ozon_test/auth"
+	"ozon_test/events"
+	"ozon_test/graph"
+	"ozon_test/graph/model"
+	"ozon_test/service"
+	"ozon_test/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receive[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(time.Second):
+		t.Fatal("Не получили событие через подписку")
+	}
+	var zero T
+	return zero
+}
+
+// Тест подписки на события поста
+func TestPostEventsSubscription(t *testing.T) {
+	resolver := newResolver(service.Deps{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	post, _ := resolver.Mutation().CreatePost(ctx, "События", "Контент", "Автор", true)
+	other, _ := resolver.Mutation().CreatePost(ctx, "Другой", "Контент", "Автор", true)
+
+	eventChan, err := resolver.Subscription().PostEvents(ctx, post.ID, nil)
+	assert.NoError(t, err)
+
+	_, _ = resolver.Mutation().AddComment(ctx, other.ID, nil, "User", "Не тот пост")
+	comment, _ := resolver.Mutation().AddComment(ctx, post.ID, nil, "User", "Комментарий")
+	added := receive(t, eventChan).(*model.CommentAddedEvent)
+	assert.Equal(t, comment.ID, added.Comment.ID)
+
+	// Правка, удаление и блокировка комментариев — административные операции
+	_, err = resolver.Mutation().UpdateComment(ctx, comment.ID, "Исправлено")
+	assert.Equal(t, service.CodeUnauthenticated, graph.ErrorCode(err))
+	admin := auth.WithAdmin(ctx)
+	_, err = resolver.Mutation().UpdateComment(admin, comment.ID, "Исправлено")
+	assert.NoError(t, err)
+	updated := receive(t, eventChan).(*model.CommentUpdatedEvent)
+	assert.Equal(t, "Исправлено", updated.Comment.Content)
+
+	ok, err := resolver.Mutation().DeleteComment(admin, comment.ID)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	deleted := receive(t, eventChan).(*model.CommentDeletedEvent)
+	assert.Equal(t, comment.ID, deleted.CommentID)
+
+	_, err = resolver.Mutation().SetCommentsAllowed(admin, post.ID, false)
+	assert.NoError(t, err)
+	toggled := receive(t, eventChan).(*model.PostCommentsToggledEvent)
+	assert.False(t, toggled.CommentsAllowed)
+
+	comments, _ := resolver.Query().Comments(ctx, post.ID, 10, 0)
+	assert.Empty(t, comments)
+}
+
+// Тест подписки на новые посты с фильтром по автору
+func TestPostCreatedSubscription(t *testing.T) {
+	resolver := newResolver(service.Deps{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	author := "Автор"
+	postChan, err := resolver.Subscription().PostCreated(ctx, &author)
+	assert.NoError(t, err)
+
+	_, _ = resolver.Mutation().CreatePost(ctx, "Чужой", "Контент", "Другой", true)
+	post, _ := resolver.Mutation().CreatePost(ctx, "Свой", "Контент", author, true)
+
+	assert.Equal(t, post.ID, receive(t, postChan).ID)
+}
+
+// Тест досылки пропущенных комментариев после переподключения
+func TestCommentAddedReplay(t *testing.T) {
+	resolver := newResolver(service.Deps{})
+	ctx := context.Background()
+
+	post, _ := resolver.Mutation().CreatePost(ctx, "Переподключение", "Контент", "Автор", true)
+
+	subCtx, disconnect := context.WithCancel(ctx)
+	commentChan, err := resolver.Subscription().CommentAdded(subCtx, post.ID, nil)
+	assert.NoError(t, err)
+
+	_, _ = resolver.Mutation().AddComment(ctx, post.ID, nil, "User", "Первый")
+	first := receive(t, commentChan)
+	assert.NotNil(t, first.Cursor)
+	disconnect()
+
+	// Комментарии, добавленные, пока клиент был отключён
+	second, _ := resolver.Mutation().AddComment(ctx, post.ID, nil, "User", "Второй")
+	third, _ := resolver.Mutation().AddComment(ctx, post.ID, nil, "User", "Третий")
+
+	subCtx, disconnect = context.WithCancel(ctx)
+	defer disconnect()
+	commentChan, err = resolver.Subscription().CommentAdded(subCtx, post.ID, first.Cursor)
+	assert.NoError(t, err)
+
+	assert.Equal(t, second.ID, receive(t, commentChan).ID)
+	assert.Equal(t, third.ID, receive(t, commentChan).ID)
+
+	live, _ := resolver.Mutation().AddComment(ctx, post.ID, nil, "User", "Четвёртый")
+	assert.Equal(t, live.ID, receive(t, commentChan).ID)
+}
+
+// Тест курсора за пределами окна хранения
+func TestCommentAddedExpiredCursor(t *testing.T) {
+	resolver := newResolver(service.Deps{Events: events.NewBroker(events.Retention{Size: 2})})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	post, _ := resolver.Mutation().CreatePost(ctx, "Окно", "Контент", "Автор", true)
+	commentChan, _ := resolver.Subscription().CommentAdded(ctx, post.ID, nil)
+
+	_, _ = resolver.Mutation().AddComment(ctx, post.ID, nil, "User", "Первый")
+	first := receive(t, commentChan)
+	for i := 0; i < 3; i++ {
+		_, _ = resolver.Mutation().AddComment(ctx, post.ID, nil, "User", "Ещё")
+	}
+
+	// Курсор комментария вышел за окно брокера: пропущенное досылается из
+	// хранилища по позиции комментария
+	resumed, err := resolver.Subscription().CommentAdded(ctx, post.ID, first.Cursor)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, "Ещё", receive(t, resumed).Content)
+	}
+
+	// Курсор без позиции комментария дочитать неоткуда
+	_, err = resolver.Subscription().CommentAdded(ctx, post.ID, &model.Cursor{Epoch: 1, Seq: 1})
+	assert.ErrorContains(t, err, "курсор устарел")
+}
+
+// Тест возобновления после перезапуска: новый брокер не знает старых
+// курсоров, комментарии досылаются из хранилища без повторов, затем идут
+// живые события
+func TestCommentAddedResumeAfterRestart(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	before := newResolver(service.Deps{Store: store, Events: events.NewBroker(events.DefaultRetention)})
+	post, _ := before.Mutation().CreatePost(ctx, "Перезапуск", "Контент", "Автор", true)
+	commentChan, err := before.Subscription().CommentAdded(ctx, post.ID, nil)
+	require.NoError(t, err)
+	_, _ = before.Mutation().AddComment(ctx, post.ID, nil, "User", "Первый")
+	first := receive(t, commentChan)
+	missed, _ := before.Mutation().AddComment(ctx, post.ID, nil, "User", "Пропущенный")
+
+	cursor, err := model.ParseCursor(first.Cursor.String())
+	require.NoError(t, err)
+	assert.Equal(t, *first.Cursor, cursor)
+
+	after := newResolver(service.Deps{Store: store, Events: events.NewBroker(events.DefaultRetention)})
+	resumed, err := after.Subscription().CommentAdded(ctx, post.ID, &cursor)
+	require.NoError(t, err)
+	replayed := receive(t, resumed)
+	assert.Equal(t, missed.ID, replayed.ID)
+
+	live, _ := after.Mutation().AddComment(ctx, post.ID, nil, "User", "Новый")
+	assert.Equal(t, live.ID, receive(t, resumed).ID)
+
+	// С курсора досланного комментария возобновление тоже идет из хранилища
+	again, err := after.Subscription().CommentAdded(ctx, post.ID, replayed.Cursor)
+	require.NoError(t, err)
+	assert.Equal(t, live.ID, receive(t, again).ID)
+}
+
+// Тест завершения подписок при остановке брокера
+func TestBrokerCloseCompletesSubscriptions(t *testing.T) {
+	broker := events.NewBroker(events.DefaultRetention)
+	resolver := newResolver(service.Deps{Events: broker})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	post, _ := resolver.Mutation().CreatePost(ctx, "Остановка", "Контент", "Автор", true)
+	commentChan, err := resolver.Subscription().CommentAdded(ctx, post.ID, nil)
+	assert.NoError(t, err)
+
+	broker.Close()
+
+	select {
+	case _, ok := <-commentChan:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("Подписка не завершилась после остановки брокера")
+	}
+
+	_, _ = resolver.Mutation().AddComment(ctx, post.ID, nil, "User", "После остановки")
+	lateChan, err := resolver.Subscription().CommentAdded(ctx, post.ID, nil)
+	assert.NoError(t, err)
+	_, ok := <-lateChan
+	assert.False(t, ok)
+}
+
+// Тест медленного подписчика: вместо молчаливой потери событий подписка
+// завершается, и переподключение с последним курсором досылает пропущенное
+func TestBrokerSlowSubscriber(t *testing.T) {
+	broker := events.NewBroker(events.DefaultRetention)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	slow := broker.Subscribe(ctx, nil)
+	const total = 100
+	for i := 0; i < total; i++ {
+		broker.Publish(events.Event{Type: events.CommentDeleted, PostID: "p1"})
+	}
+
+	var last *model.Cursor
+	received := 0
+	for e := range slow {
+		received++
+		last = &e.Cursor
+	}
+	require.NotNil(t, last)
+	assert.Less(t, received, total)
+	assert.Zero(t, broker.Subscribers())
+
+	resumed, err := broker.SubscribeSince(ctx, last, nil)
+	require.NoError(t, err)
+	for i := received; i < total; i++ {
+		receive(t, resumed)
+	}
+}
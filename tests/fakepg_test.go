@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer — сервер PostgreSQL в памяти для проверки маршрутизации запросов.
+type fakeServer struct {
+	reads     atomic.Int64
+	writes    atomic.Int64
+	commits   atomic.Int64
+	rollbacks atomic.Int64
+	down      atomic.Bool
+
+	mu      sync.Mutex
+	queries []string
+}
+
+func (s *fakeServer) log(query string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queries = append(s.queries, strings.Join(strings.Fields(query), " "))
+}
+
+var fakeServers sync.Map
+
+func init() {
+	sql.Register("fakepg", fakeDriver{})
+}
+
+func openFake(t *testing.T, name string) (*sql.DB, *fakeServer) {
+	srv := &fakeServer{}
+	dsn := t.Name() + "/" + name
+	fakeServers.Store(dsn, srv)
+	db, err := sql.Open("fakepg", dsn)
+	require.NoError(t, err)
+	return db, srv
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(dsn string) (driver.Conn, error) {
+	srv, _ := fakeServers.Load(dsn)
+	if srv.(*fakeServer).down.Load() {
+		return nil, fmt.Errorf("dial: %w", syscall.ECONNREFUSED)
+	}
+	return &fakeConn{srv: srv.(*fakeServer)}, nil
+}
+
+type fakeConn struct{ srv *fakeServer }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *fakeConn) Close() error                        { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)           { return fakeTx{c.srv}, nil }
+
+type fakeTx struct{ srv *fakeServer }
+
+func (tx fakeTx) Commit() error   { tx.srv.commits.Add(1); return nil }
+func (tx fakeTx) Rollback() error { tx.srv.rollbacks.Add(1); return nil }
+
+func (c *fakeConn) Ping(context.Context) error {
+	if c.srv.down.Load() {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if c.srv.down.Load() {
+		return nil, driver.ErrBadConn
+	}
+	c.srv.reads.Add(1)
+	c.srv.log(query)
+	if strings.Contains(query, "schema_migrations") {
+		// Таблиц в сервере нет; posts и comments отвечают фикстурами
+		return nil, &pq.Error{Code: "42P01", Message: `relation "schema_migrations" does not exist`}
+	}
+	if strings.Contains(query, "FROM posts") {
+		return &fakeRows{
+			columns: []string{"id", "title", "content", "author", "comments_allowed", "created_at", "status", "publish_at"},
+			values:  [][]driver.Value{{"1", "Пост", "Текст", "Автор", true, "2024-01-01T00:00:00Z", "PUBLISHED", "2024-01-01T00:00:00Z"}},
+		}, nil
+	}
+	return &fakeRows{columns: []string{"id", "post_id", "parent_id", "content", "author", "created_at"}}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if c.srv.down.Load() {
+		return nil, driver.ErrBadConn
+	}
+	c.srv.writes.Add(1)
+	c.srv.log(query)
+	return driver.RowsAffected(1), nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
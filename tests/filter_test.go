@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ozon_test/auth"
+	"ozon_test/filter"
+	"ozon_test/graph"
+	"ozon_test/graph/model"
+	"ozon_test/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Тест нормализации кириллицы и маскирования запрещённых слов
+func TestBannedWordsRewrite(t *testing.T) {
+	resolver := newResolver(service.Deps{Filter: filter.NewPipeline(filter.NewJournal(0),
+		filter.NewBannedWords([]string{"дурак"}, filter.Rewrite))})
+	ctx := context.Background()
+
+	post, err := resolver.Mutation().CreatePost(ctx, "Тест", "Контент", "Автор", true)
+	assert.NoError(t, err)
+
+	comment, err := resolver.Mutation().AddComment(ctx, post.ID, nil, "Автор", "сам ДУРРАК, и дypaки тоже")
+	assert.NoError(t, err)
+	assert.Equal(t, "сам ******, и ****** тоже", comment.Content)
+
+	// Журнал с авторами и решениями доступен только администраторам
+	_, err = resolver.Query().ModerationLog(ctx, 10, 0)
+	assert.Equal(t, service.CodeUnauthenticated, graph.ErrorCode(err))
+
+	admin := auth.WithAdmin(ctx)
+	log, err := resolver.Query().ModerationLog(admin, 10, 0)
+	assert.NoError(t, err)
+	assert.Len(t, log, 1)
+	assert.Equal(t, comment.ID, log[0].TargetID)
+	assert.Equal(t, model.ModerationActionRewrite, log[0].Action)
+
+	// Отрицательная пагинация — ошибка ввода, а не паника
+	_, err = resolver.Query().ModerationLog(admin, -1, 0)
+	assert.Equal(t, service.CodeBadUserInput, service.ErrorCode(err))
+	records, err := resolver.Filter.Journal().List(ctx, -1, -5)
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+}
+
+// Тест отклонения поста со слишком большим числом ссылок
+func TestMaxLinksReject(t *testing.T) {
+	resolver := newResolver(service.Deps{Filter: filter.NewPipeline(filter.NewJournal(0), filter.NewMaxLinks(1))})
+	ctx := context.Background()
+
+	_, err := resolver.Mutation().CreatePost(ctx, "Ссылки", "http://a.ru и www.b.ru", "Автор", true)
+	var rejected *filter.RejectedError
+	assert.True(t, errors.As(err, &rejected))
+	assert.Equal(t, "max_links", rejected.Decision.Filter)
+
+	posts, _ := resolver.Query().Posts(ctx)
+	assert.Empty(t, posts)
+
+	log, _ := resolver.Query().ModerationLog(auth.WithAdmin(ctx), 10, 0)
+	assert.Len(t, log, 1)
+	assert.Equal(t, model.ModerationActionReject, log[0].Action)
+}
+
+// Тест повторной публикации одного и того же комментария
+func TestDuplicateComment(t *testing.T) {
+	resolver := newResolver(service.Deps{Filter: filter.NewPipeline(nil, filter.NewDuplicate(time.Minute))})
+	ctx := context.Background()
+
+	post, _ := resolver.Mutation().CreatePost(ctx, "Тест", "Контент", "Автор", true)
+
+	_, err := resolver.Mutation().AddComment(ctx, post.ID, nil, "Спамер", "Купите слона")
+	assert.NoError(t, err)
+	_, err = resolver.Mutation().AddComment(ctx, post.ID, nil, "Спамер", "купите   слона")
+	assert.Error(t, err)
+	_, err = resolver.Mutation().AddComment(ctx, post.ID, nil, "Другой", "Купите слона")
+	assert.NoError(t, err)
+
+	// Незаписанный комментарий (ответ на несуществующий) не считается
+	// опубликованным и не мешает повторной попытке
+	missing := "missing"
+	_, err = resolver.Mutation().AddComment(ctx, post.ID, &missing, "Автор", "Спасибо за пост")
+	assert.Error(t, err)
+	_, err = resolver.Mutation().AddComment(ctx, post.ID, nil, "Автор", "Спасибо за пост")
+	assert.NoError(t, err)
+}
+
+// Тест правил из файла регулярных выражений
+func TestRegexRulesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.txt")
+	rules := "# правила\nflag (?i)казино\nreject \\d{4}-\\d{4}-\\d{4}-\\d{4}\n"
+	assert.NoError(t, os.WriteFile(path, []byte(rules), 0o644))
+
+	f, err := filter.LoadRegexRules(path)
+	assert.NoError(t, err)
+	p := filter.NewPipeline(nil, f)
+
+	res, err := p.Run(context.Background(), &filter.Content{Kind: filter.KindComment, Body: "Лучшее КАЗИНО"})
+	assert.NoError(t, err)
+	assert.True(t, res.Flagged())
+
+	_, err = p.Run(context.Background(), &filter.Content{Kind: filter.KindComment, Body: "карта 1234-5678-9012-3456"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,168 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"ozon_test/filter"
+	"ozon_test/grpcapi"
+	"ozon_test/grpcapi/postpb"
+	"ozon_test/service"
+	"ozon_test/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newGRPCClient запускает PostService на bufconn и возвращает клиента.
+func newGRPCClient(t *testing.T, d service.Deps) postpb.PostServiceClient {
+	t.Helper()
+	if d.Store == nil {
+		d.Store = storage.NewMemoryStorage()
+	}
+	lis := bufconn.Listen(1 << 20)
+	srv := grpcapi.New(service.New(d))
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return postpb.NewPostServiceClient(conn)
+}
+
+// Тест gRPC PostService: посты, комментарии и коды ошибок
+func TestGRPCPostService(t *testing.T) {
+	pipeline := filter.NewPipeline(nil)
+	pipeline.UseBans(filter.NewBans())
+	client := newGRPCClient(t, service.Deps{Filter: pipeline})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := pipeline.Bans().Ban(ctx, "Спамер", "реклама")
+	require.NoError(t, err)
+
+	post, err := client.CreatePost(ctx, &postpb.CreatePostRequest{Title: "gRPC", Content: "Текст", Author: "Автор", CommentsAllowed: true})
+	require.NoError(t, err)
+	assert.NotEmpty(t, post.GetId())
+
+	first, err := client.AddComment(ctx, &postpb.AddCommentRequest{PostId: post.GetId(), Author: "Читатель", Content: "Первый"})
+	require.NoError(t, err)
+	assert.Nil(t, first.ParentId)
+	reply, err := client.AddComment(ctx, &postpb.AddCommentRequest{PostId: post.GetId(), ParentId: &first.Id, Author: "Автор", Content: "Ответ"})
+	require.NoError(t, err)
+	assert.Equal(t, first.GetId(), reply.GetParentId())
+
+	got, err := client.GetPost(ctx, &postpb.GetPostRequest{Id: post.GetId()})
+	require.NoError(t, err)
+	assert.Equal(t, "gRPC", got.GetTitle())
+	assert.Len(t, got.GetComments(), 2)
+
+	posts, err := client.ListPosts(ctx, &postpb.ListPostsRequest{})
+	require.NoError(t, err)
+	assert.Len(t, posts.GetPosts(), 1)
+
+	comments, err := client.ListComments(ctx, &postpb.ListCommentsRequest{PostId: post.GetId(), Limit: 1, Offset: 1})
+	require.NoError(t, err)
+	assert.Len(t, comments.GetComments(), 1)
+	comments, err = client.ListComments(ctx, &postpb.ListCommentsRequest{PostId: post.GetId()})
+	require.NoError(t, err)
+	assert.Len(t, comments.GetComments(), 2)
+
+	locked, err := client.CreatePost(ctx, &postpb.CreatePostRequest{Title: "Закрыт", Content: "c", Author: "a"})
+	require.NoError(t, err)
+
+	cases := []struct {
+		name string
+		call func() error
+		code codes.Code
+		// reason — код ошибки в ErrorInfo, тот же, что в GraphQL и REST
+		reason string
+	}{
+		{"нет поста", func() error {
+			_, err := client.GetPost(ctx, &postpb.GetPostRequest{Id: "missing"})
+			return err
+		}, codes.NotFound, service.CodeNotFound},
+		{"комментарии к несуществующему посту", func() error {
+			_, err := client.ListComments(ctx, &postpb.ListCommentsRequest{PostId: "missing"})
+			return err
+		}, codes.NotFound, service.CodeNotFound},
+		{"отрицательный offset", func() error {
+			_, err := client.ListComments(ctx, &postpb.ListCommentsRequest{PostId: post.GetId(), Offset: -1})
+			return err
+		}, codes.InvalidArgument, service.CodeBadUserInput},
+		{"комментарии запрещены", func() error {
+			_, err := client.AddComment(ctx, &postpb.AddCommentRequest{PostId: locked.GetId(), Author: "a", Content: "c"})
+			return err
+		}, codes.FailedPrecondition, service.CodeCommentsDisabled},
+		{"автор заблокирован", func() error {
+			_, err := client.CreatePost(ctx, &postpb.CreatePostRequest{Title: "t", Content: "c", Author: "Спамер"})
+			return err
+		}, codes.InvalidArgument, service.CodeContentRejected},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.call()
+			assert.Equal(t, tc.code, status.Code(err))
+			assert.Equal(t, tc.reason, grpcapi.ErrorCode(err))
+		})
+	}
+}
+
+// Тест WatchComments: новые комментарии приходят в поток, since досылает
+// пропущенные
+func TestGRPCWatchComments(t *testing.T) {
+	client := newGRPCClient(t, service.Deps{})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	post, err := client.CreatePost(ctx, &postpb.CreatePostRequest{Title: "Поток", Content: "c", Author: "a", CommentsAllowed: true})
+	require.NoError(t, err)
+
+	watchCtx, stopWatch := context.WithCancel(ctx)
+	stream, err := client.WatchComments(watchCtx, &postpb.WatchCommentsRequest{PostId: post.GetId()})
+	require.NoError(t, err)
+	// Заголовки приходят, когда сервер подписался на брокер
+	_, err = stream.Header()
+	require.NoError(t, err)
+
+	_, err = client.AddComment(ctx, &postpb.AddCommentRequest{PostId: post.GetId(), Author: "Читатель", Content: "Первый"})
+	require.NoError(t, err)
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "Первый", event.GetComment().GetContent())
+	assert.NotEmpty(t, event.GetCursor())
+
+	stopWatch()
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Canceled, status.Code(err))
+
+	_, err = client.AddComment(ctx, &postpb.AddCommentRequest{PostId: post.GetId(), Author: "Читатель", Content: "Второй"})
+	require.NoError(t, err)
+	resumed, err := client.WatchComments(ctx, &postpb.WatchCommentsRequest{PostId: post.GetId(), Since: event.GetCursor()})
+	require.NoError(t, err)
+	event, err = resumed.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "Второй", event.GetComment().GetContent())
+
+	invalid, err := client.WatchComments(ctx, &postpb.WatchCommentsRequest{PostId: post.GetId(), Since: "bad"})
+	require.NoError(t, err)
+	_, err = invalid.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	missing, err := client.WatchComments(ctx, &postpb.WatchCommentsRequest{PostId: "missing"})
+	require.NoError(t, err)
+	_, err = missing.Recv()
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
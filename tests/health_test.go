@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ozon_test/config"
+	"ozon_test/health"
+	"ozon_test/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Тест проб живости и готовности
+func TestHealthEndpoints(t *testing.T) {
+	probes := health.New(time.Second, health.StorageCheck(storage.NewMemoryStorage()))
+
+	rec := httptest.NewRecorder()
+	probes.Liveness().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	probes.Readiness().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ok", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	probes.Readiness().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz?verbose=1", nil))
+	var report health.Report
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, health.StatusOK, report.Status)
+	assert.Len(t, report.Checks, 1)
+	assert.Equal(t, "storage", report.Checks[0].Name)
+
+	probes.SetShuttingDown()
+	rec = httptest.NewRecorder()
+	probes.Readiness().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, health.StatusShuttingDown, rec.Body.String())
+}
+
+// Тест недоступной зависимости и таймаута проверки
+func TestReadinessFailingDependency(t *testing.T) {
+	probes := health.New(50*time.Millisecond,
+		health.Check{Name: "broken", Check: func(ctx context.Context) (map[string]any, error) {
+			return nil, errors.New("connection refused")
+		}},
+		health.Check{Name: "slow", Check: func(ctx context.Context) (map[string]any, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}},
+	)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	req.Header.Set("Accept", "application/json")
+	probes.Readiness().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	var report health.Report
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, health.StatusUnavailable, report.Status)
+	assert.Equal(t, "connection refused", report.Checks[0].Error)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks[1].Error)
+}
+
+// Тест готовности PostgreSQL без schema_migrations: версия схемы 0,
+// сервис остается готовым
+func TestReadinessWithoutSchemaMigrations(t *testing.T) {
+	db, _ := openFake(t, "primary")
+	pg := storage.NewPostgresStorageFromDB(db, nil, config.Default().Storage)
+	defer pg.Close()
+
+	version, err := pg.MigrationVersion(context.Background())
+	assert.NoError(t, err)
+	assert.Zero(t, version)
+
+	probes := health.New(time.Second, health.StorageCheck(pg))
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	req.Header.Set("Accept", "application/json")
+	probes.Readiness().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var report health.Report
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, float64(0), report.Checks[0].Details["migrationVersion"])
+}
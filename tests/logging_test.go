@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"ozon_test/graph"
+	"ozon_test/logging"
+	"ozon_test/service"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест ID запроса в логах и ошибках GraphQL и скрытия значений переменных
+func TestRequestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New("info", "json", &buf)
+	require.NoError(t, err)
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: newResolver(service.Deps{})}))
+	srv.AddTransport(transport.POST{})
+	srv.SetErrorPresenter(logging.ErrorPresenter)
+	srv.Use(logging.Extension{Logger: logger})
+	h := logging.Middleware(srv)
+
+	body := `{"query":"query Missing($id: ID!) { post(id: $id) { id } }","variables":{"id":"secret-id"}}`
+	req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(logging.RequestIDHeader, "req-42")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, "req-42", rec.Header().Get(logging.RequestIDHeader))
+
+	var resp struct {
+		Errors []struct {
+			Extensions map[string]any `json:"extensions"`
+		} `json:"errors"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.NotEmpty(t, resp.Errors)
+	assert.Equal(t, "req-42", resp.Errors[0].Extensions["requestId"])
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "req-42", entry["request_id"])
+	assert.Equal(t, "Missing", entry["operation"])
+	assert.Equal(t, "error", entry["outcome"])
+	assert.Contains(t, entry, "duration")
+	assert.NotContains(t, buf.String(), "secret-id")
+
+	// Без заголовка ID генерируется
+	req = httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"{ posts { id } }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Len(t, rec.Header().Get(logging.RequestIDHeader), 36)
+
+	_, err = logging.New("verbose", "json", &buf)
+	assert.Error(t, err)
+}
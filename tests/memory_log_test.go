@@ -0,0 +1,181 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ozon_test/graph/model"
+	"ozon_test/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openDurable открывает in-memory хранилище с журналом.
+func openDurable(t *testing.T, dir string) *storage.MemoryStorage {
+	t.Helper()
+	s, err := storage.NewDurableMemoryStorage(dir, storage.MemoryLogOptions{Fsync: storage.FsyncAlways})
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// crashCopy имитирует сбой: копирует файлы каталога без Close и без
+// файла блокировки, как их оставил бы упавший процесс.
+func crashCopy(t *testing.T, dir string) string {
+	t.Helper()
+	dst := t.TempDir()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	for _, e := range entries {
+		if e.Name() == "LOCK" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dst, e.Name()), data, 0o644))
+	}
+	return dst
+}
+
+func walFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "wal-*.log"))
+	require.NoError(t, err)
+	return files
+}
+
+// Тест восстановления из журнала после сбоя: мутации и транзакции на месте
+func TestDurableMemoryReplay(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	parentID := "c1"
+	s := openDurable(t, dir)
+	require.NoError(t, s.CreatePost(ctx, &model.Post{ID: "p1", Title: "Пост", CommentsAllowed: true}))
+	require.NoError(t, s.CreateComment(ctx, &model.Comment{ID: "c1", PostID: "p1", Content: "первый"}))
+	require.NoError(t, s.CreateComment(ctx, &model.Comment{ID: "c2", PostID: "p1", ParentID: &parentID}))
+	require.NoError(t, s.UpdateComment(ctx, &model.Comment{ID: "c1", PostID: "p1", Content: "изменён"}))
+	require.NoError(t, s.WithTx(ctx, func(tx storage.Storage) error {
+		require.NoError(t, tx.CreateComment(ctx, &model.Comment{ID: "c3", PostID: "p1"}))
+		return tx.UpdatePost(ctx, &model.Post{ID: "p1", Title: "Пост", CommentsAllowed: false})
+	}))
+	require.NoError(t, s.DeleteComment(ctx, "c2"))
+	require.NoError(t, s.SaveBan(ctx, "спамер", &model.BannedUser{Author: "Спамер", Reason: "реклама"}))
+	require.NoError(t, s.AddModerationRecord(ctx, &model.ModerationRecord{ID: "m1", Author: "Спамер", Action: model.ModerationActionReject}, 10))
+	// Неудачная мутация в журнал не попадает
+	assert.Error(t, s.UpdatePost(ctx, &model.Post{ID: "missing"}))
+
+	restored := openDurable(t, crashCopy(t, dir))
+	post, err := restored.GetPostByID(ctx, "p1")
+	require.NoError(t, err)
+	assert.False(t, post.CommentsAllowed)
+	comments, err := restored.GetCommentsByPostID(ctx, "p1", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, comments, 2)
+	assert.Equal(t, "изменён", comments[0].Content)
+	assert.Equal(t, "c3", comments[1].ID)
+	bans, err := restored.ListBans(ctx)
+	require.NoError(t, err)
+	require.Len(t, bans, 1)
+	assert.Equal(t, "реклама", bans[0].Reason)
+	records, err := restored.ListModerationRecords(ctx, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "m1", records[0].ID)
+}
+
+// Тест оборванной последней записи: хвост обрезается, журнал пригоден для записи
+func TestDurableMemoryTornRecord(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	s := openDurable(t, dir)
+	require.NoError(t, s.CreatePost(ctx, &model.Post{ID: "p1"}))
+	require.NoError(t, s.CreatePost(ctx, &model.Post{ID: "p2"}))
+
+	files := walFiles(t, dir)
+	require.Len(t, files, 1)
+	info, err := os.Stat(files[0])
+	require.NoError(t, err)
+
+	// Сбой посреди записи: заголовок обещает больше данных, чем записано
+	f, err := os.OpenFile(files[0], os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{200, 0, 0, 0, 1, 2, 3, 4, '[', '{'})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	dir = crashCopy(t, dir)
+	restored := openDurable(t, dir)
+	posts, err := restored.GetAllPosts(ctx)
+	require.NoError(t, err)
+	assert.Len(t, posts, 2)
+	truncated, err := os.Stat(filepath.Join(dir, filepath.Base(files[0])))
+	require.NoError(t, err)
+	assert.Equal(t, info.Size(), truncated.Size())
+
+	require.NoError(t, restored.CreatePost(ctx, &model.Post{ID: "p3"}))
+	again := openDurable(t, crashCopy(t, dir))
+	posts, err = again.GetAllPosts(ctx)
+	require.NoError(t, err)
+	assert.Len(t, posts, 3)
+}
+
+// Тест снимка: журнал сворачивается, старые поколения удаляются
+func TestDurableMemorySnapshot(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	s, err := storage.NewDurableMemoryStorage(dir, storage.MemoryLogOptions{Fsync: storage.FsyncNever})
+	require.NoError(t, err)
+	require.NoError(t, s.CreatePost(ctx, &model.Post{ID: "p1"}))
+	require.NoError(t, s.CreateComment(ctx, &model.Comment{ID: "c1", PostID: "p1"}))
+	require.NoError(t, s.CreateComment(ctx, &model.Comment{ID: "c2", PostID: "p1"}))
+	require.NoError(t, s.SaveBan(ctx, "спамер", &model.BannedUser{Author: "Спамер"}))
+	require.NoError(t, s.AddModerationRecord(ctx, &model.ModerationRecord{ID: "m1", Author: "Спамер"}, 10))
+	require.NoError(t, s.Snapshot())
+
+	assert.FileExists(t, filepath.Join(dir, "snapshot.json"))
+	files := walFiles(t, dir)
+	require.Len(t, files, 1)
+	assert.Equal(t, "wal-00000001.log", filepath.Base(files[0]))
+
+	require.NoError(t, s.DeleteComment(ctx, "c1"))
+	require.NoError(t, s.Close())
+
+	restored := openDurable(t, dir)
+	comments, err := restored.GetCommentsByPostID(ctx, "p1", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+	assert.Equal(t, "c2", comments[0].ID)
+	bans, err := restored.ListBans(ctx)
+	require.NoError(t, err)
+	assert.Len(t, bans, 1)
+	records, err := restored.ListModerationRecords(ctx, 0, 0)
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+}
+
+// Тест блокировки каталога: второй процесс не может открыть каталог,
+// пока его держит первый, и не трогает его журналы
+func TestDurableMemoryDirLocked(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	s, err := storage.NewDurableMemoryStorage(dir, storage.MemoryLogOptions{Fsync: storage.FsyncAlways})
+	require.NoError(t, err)
+	require.NoError(t, s.CreatePost(ctx, &model.Post{ID: "p1"}))
+
+	_, err = storage.NewDurableMemoryStorage(dir, storage.MemoryLogOptions{})
+	assert.ErrorIs(t, err, storage.ErrDirLocked)
+	require.NoError(t, s.CreatePost(ctx, &model.Post{ID: "p2"}))
+	require.NoError(t, s.Close())
+
+	restored := openDurable(t, dir)
+	posts, err := restored.GetAllPosts(ctx)
+	require.NoError(t, err)
+	assert.Len(t, posts, 2)
+}
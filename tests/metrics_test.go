@@ -0,0 +1,50 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"ozon_test/graph"
+	"ozon_test/metrics"
+	"ozon_test/service"
+	"ozon_test/storage"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест эндпоинта /metrics без сервера Prometheus
+func TestMetricsEndpoint(t *testing.T) {
+	m := metrics.New()
+	store := m.InstrumentStorage(storage.NewMemoryStorage())
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: newResolver(service.Deps{Store: store})}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(m.Tracer())
+
+	query := func(body string) {
+		req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		srv.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	query(`{"query":"mutation CreatePost { createPost(title: \"t\", content: \"c\", author: \"a\", commentsAllowed: true) { id } }"}`)
+	query(`{"query":"{ posts { id } }"}`)
+	query(`{"query":"{ post(id: \"missing\") { id } }"}`)
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	body, _ := io.ReadAll(rec.Body)
+	text := string(body)
+
+	assert.Contains(t, text, `ozon_graphql_operations_total{code="OK",operation="CreatePost",type="mutation"} 1`)
+	assert.Contains(t, text, `ozon_graphql_operations_total{code="OK",operation="posts",type="query"} 1`)
+	assert.Contains(t, text, `ozon_graphql_operations_total{code="ERROR",operation="post",type="query"} 1`)
+	assert.Contains(t, text, `ozon_storage_call_duration_seconds_count{method="CreatePost"} 1`)
+	assert.Contains(t, text, `ozon_storage_errors_total{method="GetPostByID"} 1`)
+	assert.Contains(t, text, `ozon_posts_created_total 1`)
+}
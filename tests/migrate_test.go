@@ -0,0 +1,208 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"ozon_test/config"
+	"ozon_test/graph/model"
+	"ozon_test/migrate"
+	"ozon_test/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// duringCopy имитирует запись работающего сервиса: при первом чтении
+// комментариев во время копирования выполняет write на исходном хранилище.
+type duringCopy struct {
+	storage.Storage
+	once  sync.Once
+	write func()
+}
+
+func (d *duringCopy) GetCommentsByPostID(ctx context.Context, postID string, limit, offset int) ([]*model.Comment, error) {
+	d.once.Do(d.write)
+	return d.Storage.GetCommentsByPostID(ctx, postID, limit, offset)
+}
+
+func (d *duringCopy) Unwrap() storage.Storage { return d.Storage }
+
+// Тест переноса SQLite → память: изменения во время копирования
+// догоняются по ленте, проверка сравнивает хранилища
+func TestMigrateData(t *testing.T) {
+	ctx := context.Background()
+	src := openSQLite(t, filepath.Join(t.TempDir(), "ozon.db"))
+	for _, id := range []string{"p1", "p2"} {
+		require.NoError(t, src.CreatePost(ctx, &model.Post{ID: id, Title: id, CommentsAllowed: true, CreatedAt: timeAt("2024-01-01T00:00:00Z")}))
+	}
+	root := "c1"
+	require.NoError(t, src.CreateComment(ctx, &model.Comment{ID: "c1", PostID: "p1", Content: "корень", CreatedAt: timeAt("2024-01-01T00:01:00Z")}))
+	require.NoError(t, src.CreateComment(ctx, &model.Comment{ID: "c2", PostID: "p1", ParentID: &root, Content: "ответ", CreatedAt: timeAt("2024-01-01T00:02:00Z")}))
+	require.NoError(t, src.CreateComment(ctx, &model.Comment{ID: "c3", PostID: "p2", Content: "текст", CreatedAt: timeAt("2024-01-01T00:03:00Z")}))
+
+	from := &duringCopy{Storage: src, write: func() {
+		// Комментарии p1 уже могли быть прочитаны: удаление с каскадом,
+		// правка, новый пост с комментарием и закрытие комментариев
+		require.NoError(t, src.DeleteComment(ctx, "c1"))
+		require.NoError(t, src.UpdateComment(ctx, &model.Comment{ID: "c3", Content: "исправлено"}))
+		require.NoError(t, src.CreatePost(ctx, &model.Post{ID: "p3", Title: "p3", CreatedAt: timeAt("2024-01-02T00:00:00Z")}))
+		require.NoError(t, src.CreateComment(ctx, &model.Comment{ID: "c4", PostID: "p3", Content: "новый", CreatedAt: timeAt("2024-01-02T00:01:00Z")}))
+		require.NoError(t, src.UpdatePost(ctx, &model.Post{ID: "p2", Title: "p2", CommentsAllowed: false}))
+	}}
+	dst := storage.NewMemoryStorage()
+
+	report, err := migrate.Run(ctx, from, dst, migrate.Options{BatchSize: 2})
+	require.NoError(t, err)
+	assert.True(t, report.Verify.OK())
+	assert.Equal(t, 3, report.Verify.TargetPosts)
+	assert.Equal(t, 2, report.Verify.TargetComments)
+	assert.Positive(t, report.Changes)
+
+	_, err = dst.GetCommentByID(ctx, "c2")
+	assert.Error(t, err)
+	c3, err := dst.GetCommentByID(ctx, "c3")
+	require.NoError(t, err)
+	assert.Equal(t, "исправлено", c3.Content)
+	p2, err := dst.GetPostByID(ctx, "p2")
+	require.NoError(t, err)
+	assert.False(t, p2.CommentsAllowed)
+
+	// Применённая часть ленты очищена, после переноса лента не ведется
+	require.NoError(t, src.CreatePost(ctx, &model.Post{ID: "p4"}))
+	changes, err := src.Changes(ctx, 0, 100)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+// Тест проверки: расхождение по комментариям находится и исправляется
+func TestMigrateVerifyRepairs(t *testing.T) {
+	ctx := context.Background()
+	src := storage.NewMemoryStorage()
+	dst := storage.NewMemoryStorage()
+	require.NoError(t, src.CreatePost(ctx, &model.Post{ID: "p1"}))
+	require.NoError(t, src.CreateComment(ctx, &model.Comment{ID: "c1", PostID: "p1"}))
+
+	report, err := migrate.Run(ctx, src, dst, migrate.Options{})
+	require.NoError(t, err)
+	assert.Empty(t, report.Repaired)
+
+	require.NoError(t, dst.CreateComment(ctx, &model.Comment{ID: "extra", PostID: "p1"}))
+	v, err := migrate.Verify(ctx, src, dst)
+	require.NoError(t, err)
+	assert.False(t, v.OK())
+	assert.Equal(t, []string{"p1"}, v.Mismatched)
+
+	report, err = migrate.Run(ctx, src, dst, migrate.Options{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"p1"}, report.Repaired)
+	assert.True(t, report.Verify.OK())
+
+	// Пропущенные изменения содержимого и статуса находятся так же
+	require.NoError(t, dst.UpdateComment(ctx, &model.Comment{ID: "c1", PostID: "p1", Content: "старый"}))
+	publishAt := timeAt("2024-01-01T00:00:00Z")
+	require.NoError(t, dst.UpdatePost(ctx, &model.Post{ID: "p1", Status: model.PostStatusScheduled, PublishAt: &publishAt}))
+	v, err = migrate.Verify(ctx, src, dst)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"p1"}, v.Mismatched)
+	assert.Equal(t, v.SourceComments, v.TargetComments)
+
+	// Повторный перенос обновляет пост и комментарий при копировании
+	report, err = migrate.Run(ctx, src, dst, migrate.Options{})
+	require.NoError(t, err)
+	assert.True(t, report.Verify.OK())
+	post, err := dst.GetPostByID(ctx, "p1")
+	require.NoError(t, err)
+	assert.Equal(t, model.PostStatusPublished, post.Status)
+}
+
+// Тест потребителей ленты изменений: без них лента не ведется, изменения
+// удаляются, когда их применили все потребители
+func TestChangeFeedConsumers(t *testing.T) {
+	ctx := context.Background()
+	src := openSQLite(t, filepath.Join(t.TempDir(), "ozon.db"))
+
+	require.NoError(t, src.CreatePost(ctx, &model.Post{ID: "p1"}))
+	changes, err := src.Changes(ctx, 0, 100)
+	require.NoError(t, err)
+	assert.Empty(t, changes, "без потребителей запись не попадает в ленту")
+
+	a, err := src.TrackChanges(ctx, "a")
+	require.NoError(t, err)
+	require.NoError(t, src.CreatePost(ctx, &model.Post{ID: "p2"}))
+	b, err := src.TrackChanges(ctx, "b")
+	require.NoError(t, err)
+	require.NoError(t, src.CreatePost(ctx, &model.Post{ID: "p3"}))
+
+	changes, err = src.Changes(ctx, a, 100)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, changes[0].Seq, b)
+
+	// a применил все, b — ничего: лента остается для b
+	require.NoError(t, src.AckChanges(ctx, "a", changes[1].Seq))
+	changes, err = src.Changes(ctx, 0, 100)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "p3", changes[0].ID)
+
+	require.NoError(t, src.UntrackChanges(ctx, "a"))
+	require.NoError(t, src.UntrackChanges(ctx, "b"))
+	require.NoError(t, src.CreatePost(ctx, &model.Post{ID: "p4"}))
+	changes, err = src.Changes(ctx, 0, 100)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+// Тест порядка ленты PostgreSQL, если задан POSTGRES_TEST_DSN: изменение
+// транзакции, получившей меньший seq, но зафиксированной позже, не
+// теряется — более позднее изменение не выдается, пока она не завершится
+func TestPostgresChangeFeedCommitOrder(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN не задан")
+	}
+	ctx := context.Background()
+
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	schema := fmt.Sprintf("change_feed_test_%d", time.Now().UnixNano())
+	_, err = db.ExecContext(ctx, `CREATE SCHEMA `+schema)
+	require.NoError(t, err)
+	t.Cleanup(func() { _, _ = db.ExecContext(ctx, `DROP SCHEMA `+schema+` CASCADE`) })
+
+	cfg := config.Default().Storage
+	cfg.DSN = withSearchPath(dsn, schema)
+	pg, err := storage.NewPostgresStorage(ctx, cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { pg.Close() })
+
+	from, err := pg.TrackChanges(ctx, "test")
+	require.NoError(t, err)
+
+	slow, err := pg.DB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer slow.Rollback()
+	_, err = slow.ExecContext(ctx, `INSERT INTO posts (id, title, content, author) VALUES ('00000000-0000-0000-0000-000000000001', 'Медленный', '', 'Автор')`)
+	require.NoError(t, err)
+	require.NoError(t, pg.CreatePost(ctx, &model.Post{ID: "00000000-0000-0000-0000-000000000002", Title: "Быстрый", CreatedAt: time.Now()}))
+
+	changes, err := pg.Changes(ctx, from, 100)
+	require.NoError(t, err)
+	assert.Empty(t, changes, "изменение после незавершённой транзакции не выдается")
+
+	require.NoError(t, slow.Commit())
+	changes, err = pg.Changes(ctx, from, 100)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.ElementsMatch(t,
+		[]string{"00000000-0000-0000-0000-000000000001", "00000000-0000-0000-0000-000000000002"},
+		[]string{changes[0].ID, changes[1].ID})
+}
@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"ozon_test/config"
+	"ozon_test/graph/model"
+	"ozon_test/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var schemaVersionsRe = regexp.MustCompile(`(?is)INSERT INTO schema_migrations \(version\) VALUES ([^;]*)`)
+
+// pgMigrations возвращает миграции PostgreSQL в порядке, в котором их
+// применяет docker-entrypoint-initdb.d, — по алфавиту.
+func pgMigrations(t *testing.T) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join("..", "migrations", "*.sql"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+	return files
+}
+
+// Тест порядка миграций PostgreSQL: номера идут подряд, schema_migrations
+// создается раньше первой записи в нее, каждый файл отмечает свою версию.
+// Миграции не удаляют таблиц: runner применяет их и к базам, созданным до
+// появления schema_migrations
+func TestPostgresMigrationsOrder(t *testing.T) {
+	created := false
+	for i, path := range pgMigrations(t) {
+		name := filepath.Base(path)
+		version, err := strconv.Atoi(strings.SplitN(name, "_", 2)[0])
+		require.NoError(t, err, name)
+		assert.Equal(t, i+1, version, "%s: номер не по порядку", name)
+
+		script, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.NotContains(t, strings.ToUpper(string(script)), "DROP TABLE", "%s: миграция удаляет данные", name)
+		if strings.Contains(string(script), "CREATE TABLE IF NOT EXISTS schema_migrations") {
+			created = true
+		}
+		if m := schemaVersionsRe.FindStringSubmatch(string(script)); m != nil {
+			assert.True(t, created, "%s: schema_migrations еще не создана", name)
+			assert.Contains(t, m[1], fmt.Sprintf("(%d)", version), "%s: файл отмечает чужую версию", name)
+		}
+	}
+}
+
+// Тест применения миграций PostgreSQL по алфавиту на пустой базе (в
+// отдельной схеме), если задан POSTGRES_TEST_DSN
+func TestPostgresMigrationsFreshDatabase(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN не задан")
+	}
+	ctx := context.Background()
+
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	schema := fmt.Sprintf("migrations_test_%d", time.Now().UnixNano())
+	_, err = conn.ExecContext(ctx, `CREATE SCHEMA `+schema+`; SET search_path TO `+schema)
+	require.NoError(t, err)
+	t.Cleanup(func() { _, _ = db.ExecContext(ctx, `DROP SCHEMA `+schema+` CASCADE`) })
+
+	files := pgMigrations(t)
+	for _, path := range files {
+		script, err := os.ReadFile(path)
+		require.NoError(t, err)
+		_, err = conn.ExecContext(ctx, string(script))
+		require.NoError(t, err, filepath.Base(path))
+	}
+
+	var version int
+	require.NoError(t, conn.QueryRowContext(ctx, `SELECT MAX(version) FROM schema_migrations`).Scan(&version))
+	assert.Equal(t, len(files), version)
+}
+
+// Тест runner'а миграций PostgreSQL на базе, созданной до появления
+// schema_migrations (в отдельной схеме), если задан POSTGRES_TEST_DSN:
+// данные сохраняются, повторный запуск ничего не меняет
+func TestPostgresMigrationRunner(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN не задан")
+	}
+	ctx := context.Background()
+
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	schema := fmt.Sprintf("migrations_test_%d", time.Now().UnixNano())
+	_, err = db.ExecContext(ctx, `CREATE SCHEMA `+schema)
+	require.NoError(t, err)
+	t.Cleanup(func() { _, _ = db.ExecContext(ctx, `DROP SCHEMA `+schema+` CASCADE`) })
+
+	cfg := config.Default().Storage
+	cfg.DSN = withSearchPath(dsn, schema)
+
+	legacy, err := os.ReadFile(pgMigrations(t)[0])
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, `SET search_path TO `+schema+`; `+string(legacy)+`
+		INSERT INTO posts (id, title, content, author) VALUES ('00000000-0000-0000-0000-000000000001', 'Старый', '', 'Автор');
+		RESET search_path`)
+	require.NoError(t, err)
+
+	for range 2 {
+		pg, err := storage.NewPostgresStorage(ctx, cfg)
+		require.NoError(t, err)
+		version, err := pg.MigrationVersion(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, len(pgMigrations(t)), version)
+		post, err := pg.GetPostByID(ctx, "00000000-0000-0000-0000-000000000001")
+		require.NoError(t, err)
+		assert.Equal(t, model.PostStatusPublished, post.Status)
+		require.NoError(t, pg.Close())
+	}
+}
+
+// withSearchPath добавляет к DSN параметр search_path: lib/pq передает
+// неизвестные параметры серверу.
+func withSearchPath(dsn, schema string) string {
+	switch {
+	case !strings.Contains(dsn, "://"):
+		return dsn + " search_path=" + schema
+	case strings.Contains(dsn, "?"):
+		return dsn + "&search_path=" + schema
+	default:
+		return dsn + "?search_path=" + schema
+	}
+}
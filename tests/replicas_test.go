@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"ozon_test/config"
+	"ozon_test/graph/model"
+	"ozon_test/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест маршрутизации чтений по репликам, исключения и возврата реплик
+func TestPostgresReplicas(t *testing.T) {
+	primaryDB, primary := openFake(t, "primary")
+	replicaDB0, replica0 := openFake(t, "replica0")
+	replicaDB1, replica1 := openFake(t, "replica1")
+
+	cfg := config.Default().Storage
+	cfg.ReplicaCheckInterval = 10 * time.Millisecond
+	pg := storage.NewPostgresStorageFromDB(primaryDB, []*sql.DB{replicaDB0, replicaDB1}, cfg)
+	defer pg.Close()
+	ctx := context.Background()
+
+	// Чтения распределяются по кругу
+	for i := 0; i < 4; i++ {
+		_, err := pg.GetPostByID(ctx, "1")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int64(0), primary.reads.Load())
+	assert.Equal(t, int64(2), replica0.reads.Load())
+	assert.Equal(t, int64(2), replica1.reads.Load())
+
+	// Упавшая реплика исключается, чтения не получают ошибку
+	replica1.down.Store(true)
+	for i := 0; i < 4; i++ {
+		_, err := pg.GetAllPosts(ctx)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int64(6), replica0.reads.Load())
+	healthy, total := pg.Replicas()
+	assert.Equal(t, 1, healthy)
+	assert.Equal(t, 2, total)
+
+	// После восстановления реплика возвращается в балансировку
+	replica1.down.Store(false)
+	assert.Eventually(t, func() bool {
+		healthy, _ := pg.Replicas()
+		return healthy == 2
+	}, time.Second, 5*time.Millisecond)
+
+	// Без здоровых реплик чтения идут на primary
+	replica0.down.Store(true)
+	replica1.down.Store(true)
+	_, err := pg.GetCommentsByPostID(ctx, "1", 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), primary.reads.Load())
+}
+
+// Тест чтения своих записей: после записи в контексте чтения идут на primary
+func TestPostgresReadYourWrites(t *testing.T) {
+	primaryDB, primary := openFake(t, "primary")
+	replicaDB, replica := openFake(t, "replica")
+	pg := storage.NewPostgresStorageFromDB(primaryDB, []*sql.DB{replicaDB}, config.Default().Storage)
+	defer pg.Close()
+
+	ctx := storage.WithReadYourWrites(context.Background())
+	_, err := pg.GetPostByID(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), replica.reads.Load())
+
+	require.NoError(t, pg.CreateComment(ctx, &model.Comment{ID: "c1", PostID: "1"}))
+	assert.Equal(t, int64(1), primary.writes.Load())
+
+	_, err = pg.GetPostByID(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), primary.reads.Load())
+	assert.Equal(t, int64(1), replica.reads.Load())
+
+	// Другие запросы по-прежнему читают с реплики
+	_, err = pg.GetPostByID(context.Background(), "1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), replica.reads.Load())
+}
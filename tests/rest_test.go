@@ -0,0 +1,199 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ozon_test/graph"
+	"ozon_test/graph/model"
+	"ozon_test/rest"
+	"ozon_test/service"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// restCall отправляет запрос к шлюзу и разбирает JSON-ответ в out.
+func restCall(t *testing.T, ts *httptest.Server, method, path, body string, out any) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	if out != nil {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+	}
+	return resp
+}
+
+type restError struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Тест REST-шлюза: создание и чтение постов и комментариев, проверки и
+// коды ошибок те же, что у GraphQL
+func TestRESTGateway(t *testing.T) {
+	resolver := newResolver(service.Deps{CommentMaxLength: 20})
+	ts := httptest.NewServer(rest.New(resolver.PostService, resolver.CommentService, 0))
+	defer ts.Close()
+
+	var posts []*model.Post
+	resp := restCall(t, ts, http.MethodGet, "/api/posts", "", &posts)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotNil(t, posts)
+	assert.Empty(t, posts)
+
+	var post model.Post
+	resp = restCall(t, ts, http.MethodPost, "/api/posts", `{"title":"REST","content":"Текст","author":"Автор","commentsAllowed":true}`, &post)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "/api/posts/"+post.ID, resp.Header.Get("Location"))
+	assert.Equal(t, "REST", post.Title)
+
+	var comment model.Comment
+	resp = restCall(t, ts, http.MethodPost, "/api/posts/"+post.ID+"/comments", `{"author":"Читатель","content":"Первый"}`, &comment)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp = restCall(t, ts, http.MethodPost, "/api/posts/"+post.ID+"/comments", `{"parentId":"`+comment.ID+`","author":"Автор","content":"Ответ"}`, nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var comments []*model.Comment
+	resp = restCall(t, ts, http.MethodGet, "/api/posts/"+post.ID+"/comments?limit=1&offset=1", "", &comments)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Len(t, comments, 1)
+
+	var got model.Post
+	resp = restCall(t, ts, http.MethodGet, "/api/posts/"+post.ID, "", &got)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Len(t, got.Comments, 2)
+
+	cases := []struct {
+		name, method, path, body string
+		status                   int
+		code                     string
+	}{
+		{"нет поста", http.MethodGet, "/api/posts/missing", "", http.StatusNotFound, service.CodeNotFound},
+		{"комментарии к несуществующему посту", http.MethodGet, "/api/posts/missing/comments", "", http.StatusNotFound, service.CodeNotFound},
+		{"нет обязательного поля", http.MethodPost, "/api/posts", `{"title":"t","content":"c","author":"a"}`, http.StatusBadRequest, service.CodeBadUserInput},
+		{"неизвестное поле", http.MethodPost, "/api/posts", `{"title":"t","content":"c","author":"a","commentsAllowed":true,"tags":[]}`, http.StatusBadRequest, service.CodeBadUserInput},
+		{"некорректный limit", http.MethodGet, "/api/posts/" + post.ID + "/comments?limit=x", "", http.StatusBadRequest, service.CodeBadUserInput},
+		{"отрицательный offset", http.MethodGet, "/api/posts/" + post.ID + "/comments?offset=-1", "", http.StatusBadRequest, service.CodeBadUserInput},
+		{"длинный комментарий", http.MethodPost, "/api/posts/" + post.ID + "/comments", `{"author":"a","content":"` + strings.Repeat("x", 21) + `"}`, http.StatusBadRequest, service.CodeBadUserInput},
+		{"неизвестный маршрут", http.MethodGet, "/api/users", "", http.StatusNotFound, service.CodeNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var body restError
+			resp := restCall(t, ts, tc.method, tc.path, tc.body, &body)
+			assert.Equal(t, tc.status, resp.StatusCode)
+			assert.Equal(t, tc.code, body.Error.Code)
+			assert.NotEmpty(t, body.Error.Message)
+		})
+	}
+
+	var locked model.Post
+	restCall(t, ts, http.MethodPost, "/api/posts", `{"title":"Закрыт","content":"c","author":"a","commentsAllowed":false}`, &locked)
+	var body restError
+	resp = restCall(t, ts, http.MethodPost, "/api/posts/"+locked.ID+"/comments", `{"author":"a","content":"c"}`, &body)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, service.CodeCommentsDisabled, body.Error.Code)
+	assert.Equal(t, "комментарии к этому посту запрещены", body.Error.Message)
+
+	var spec struct {
+		OpenAPI string         `json:"openapi"`
+		Paths   map[string]any `json:"paths"`
+	}
+	resp = restCall(t, ts, http.MethodGet, "/api/openapi.json", "", &spec)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, strings.HasPrefix(spec.OpenAPI, "3."))
+	assert.Contains(t, spec.Paths, "/api/posts/{id}/comments/stream")
+}
+
+// Тест потока комментариев REST: новые комментарии приходят событиями с
+// курсором, переподключение с Last-Event-ID досылает пропущенные
+func TestRESTCommentStream(t *testing.T) {
+	resolver := newResolver(service.Deps{})
+	ts := httptest.NewServer(rest.New(resolver.PostService, resolver.CommentService, 10*time.Millisecond))
+	// Cleanup, а не defer: потоки закрываются раньше сервера
+	t.Cleanup(ts.Close)
+
+	ctx := context.Background()
+	post, err := resolver.Mutation().CreatePost(ctx, "Поток", "Текст", "Автор", true)
+	require.NoError(t, err)
+
+	stream := func(lastEventID string) *bufio.Scanner {
+		reqCtx, cancel := context.WithCancel(ctx)
+		t.Cleanup(cancel)
+		req, _ := http.NewRequestWithContext(reqCtx, http.MethodGet, ts.URL+"/api/posts/"+post.ID+"/comments/stream", nil)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+		return bufio.NewScanner(resp.Body)
+	}
+
+	sc := stream("")
+	time.Sleep(50 * time.Millisecond)
+	_, err = resolver.Mutation().AddComment(ctx, post.ID, nil, "Читатель", "Первый")
+	require.NoError(t, err)
+
+	first := readSSE(t, sc)
+	assert.Equal(t, "comment", first.event)
+	assert.NotEmpty(t, first.id)
+	var comment model.Comment
+	require.NoError(t, json.Unmarshal([]byte(first.data), &comment))
+	assert.Equal(t, "Первый", comment.Content)
+
+	_, err = resolver.Mutation().AddComment(ctx, post.ID, nil, "Читатель", "Второй")
+	require.NoError(t, err)
+
+	resumed := readSSE(t, stream(first.id))
+	require.NoError(t, json.Unmarshal([]byte(resumed.data), &comment))
+	assert.Equal(t, "Второй", comment.Content)
+
+	var body restError
+	resp := restCall(t, ts, http.MethodGet, "/api/posts/missing/comments/stream", "", &body)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp = restCall(t, ts, http.MethodGet, "/api/posts/"+post.ID+"/comments/stream?since=bad", "", &body)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, service.CodeBadUserInput, body.Error.Code)
+}
+
+// Тест кодов ошибок GraphQL: те же коды, что у REST, в extensions.code
+func TestGraphQLErrorCodes(t *testing.T) {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: newResolver(service.Deps{})}))
+	srv.AddTransport(transport.POST{})
+	srv.SetErrorPresenter(graph.ErrorPresenter)
+
+	req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"{ post(id: \"missing\") { id } }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+
+	var resp struct {
+		Errors []struct {
+			Message    string         `json:"message"`
+			Extensions map[string]any `json:"extensions"`
+		} `json:"errors"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "пост не найден", resp.Errors[0].Message)
+	assert.Equal(t, service.CodeNotFound, resp.Errors[0].Extensions["code"])
+}
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	"ozon_test/config"
+	"ozon_test/storage"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест классификации временных ошибок PostgreSQL и сети
+func TestIsTransient(t *testing.T) {
+	transient := []error{
+		&pq.Error{Code: "40001"},
+		&pq.Error{Code: "57P03"},
+		&pq.Error{Code: "08006"},
+		driver.ErrBadConn,
+		fmt.Errorf("read: %w", syscall.ECONNRESET),
+	}
+	for _, err := range transient {
+		assert.True(t, storage.IsTransient(err), err.Error())
+	}
+
+	permanent := []error{
+		&pq.Error{Code: "23505"},
+		sql.ErrNoRows,
+		context.Canceled,
+		errors.New("syntax error"),
+	}
+	for _, err := range permanent {
+		assert.False(t, storage.IsTransient(err), err.Error())
+	}
+}
+
+// Тест повторов: временные ошибки повторяются, постоянные — нет
+func TestBackoffRetry(t *testing.T) {
+	b := storage.Backoff{Initial: time.Millisecond, Max: 5 * time.Millisecond}
+
+	calls := 0
+	err := b.Retry(t.Context(), 3, func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return driver.ErrBadConn
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	err = b.Retry(t.Context(), 3, func(context.Context) error {
+		calls++
+		return sql.ErrNoRows
+	})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.Equal(t, 1, calls)
+
+	calls = 0
+	err = b.Retry(t.Context(), 2, func(context.Context) error {
+		calls++
+		return driver.ErrBadConn
+	})
+	assert.ErrorIs(t, err, driver.ErrBadConn)
+	assert.Equal(t, 2, calls)
+}
+
+// Тест ожидания PostgreSQL при запуске: повторы до истечения connectTimeout
+func TestPostgresConnectDeadline(t *testing.T) {
+	cfg := config.Default().Storage
+	cfg.DSN = "postgres://postgres@127.0.0.1:1/ozon_test?sslmode=disable&connect_timeout=1"
+	cfg.ConnectTimeout = 700 * time.Millisecond
+
+	start := time.Now()
+	_, err := storage.NewPostgresStorage(t.Context(), cfg)
+	require.Error(t, err)
+	assert.True(t, storage.IsTransient(err), err.Error())
+	assert.GreaterOrEqual(t, time.Since(start), 500*time.Millisecond)
+	assert.Less(t, time.Since(start), 3*time.Second)
+}
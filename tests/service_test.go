@@ -0,0 +1,184 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ozon_test/graph/model"
+	"ozon_test/service"
+	"ozon_test/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sequentialIDs выдает идентификаторы prefix-1, prefix-2, ...
+func sequentialIDs(prefix string) service.IDFunc {
+	var n atomic.Int64
+	return func() string { return fmt.Sprintf("%s-%d", prefix, n.Add(1)) }
+}
+
+// failingReads возвращает err при чтении поста postID (пустой — любого),
+// в том числе внутри транзакции.
+type failingReads struct {
+	storage.Storage
+	err    error
+	postID string
+}
+
+func (f *failingReads) GetPostByID(ctx context.Context, id string) (*model.Post, error) {
+	if f.postID == "" || f.postID == id {
+		return nil, f.err
+	}
+	return f.Storage.GetPostByID(ctx, id)
+}
+
+func (f *failingReads) GetPostForUpdate(ctx context.Context, id string) (*model.Post, error) {
+	if f.postID == "" || f.postID == id {
+		return nil, f.err
+	}
+	return f.Storage.GetPostForUpdate(ctx, id)
+}
+
+func (f *failingReads) WithTx(ctx context.Context, fn func(tx storage.Storage) error) error {
+	return f.Storage.WithTx(ctx, func(tx storage.Storage) error {
+		return fn(&failingReads{Storage: tx, err: f.err, postID: f.postID})
+	})
+}
+
+// Тест ошибок хранилища при изменении поста: они возвращаются как есть,
+// а не выдаются за отсутствие поста
+func TestPostChangeStorageError(t *testing.T) {
+	ctx := context.Background()
+	mem := storage.NewMemoryStorage()
+	require.NoError(t, mem.CreatePost(ctx, &model.Post{ID: "p1", Author: "Автор", Status: model.PostStatusDraft}))
+
+	errDown := errors.New("соединение потеряно")
+	posts, _ := service.New(service.Deps{Store: &failingReads{Storage: mem, err: errDown}})
+
+	_, err := posts.Publish(ctx, "p1", "Автор")
+	assert.ErrorIs(t, err, errDown)
+	assert.NotErrorIs(t, err, service.ErrPostNotFound)
+	_, err = posts.SetCommentsAllowed(ctx, "p1", false)
+	assert.ErrorIs(t, err, errDown)
+	_, err = posts.Get(ctx, "p1", nil)
+	assert.ErrorIs(t, err, errDown)
+	assert.Equal(t, service.CodeInternal, service.ErrorCode(err))
+
+	// Отсутствующий пост по-прежнему ErrPostNotFound
+	posts, _ = service.New(service.Deps{Store: mem})
+	_, err = posts.Publish(ctx, "missing", "Автор")
+	assert.ErrorIs(t, err, service.ErrPostNotFound)
+	_, err = posts.SetCommentsAllowed(ctx, "missing", false)
+	assert.ErrorIs(t, err, service.ErrPostNotFound)
+}
+
+// Тест ответа на комментарий: отсутствующий родитель — NOT_FOUND, родитель
+// из другого поста — BAD_USER_INPUT, а не внутренняя ошибка хранилища
+func TestCommentParentChecks(t *testing.T) {
+	ctx := context.Background()
+	posts, comments := service.New(service.Deps{Store: storage.NewMemoryStorage()})
+
+	first, err := posts.Create(ctx, "Первый", "Текст", "Автор", true)
+	require.NoError(t, err)
+	second, err := posts.Create(ctx, "Второй", "Текст", "Автор", true)
+	require.NoError(t, err)
+	parent, err := comments.Add(ctx, first.ID, nil, "Читатель", "Привет")
+	require.NoError(t, err)
+
+	missing := "missing"
+	_, err = comments.Add(ctx, first.ID, &missing, "Читатель", "Ответ")
+	assert.Equal(t, service.CodeNotFound, service.ErrorCode(err))
+	_, err = comments.Add(ctx, second.ID, &parent.ID, "Читатель", "Ответ")
+	assert.Equal(t, service.CodeBadUserInput, service.ErrorCode(err))
+
+	reply, err := comments.Add(ctx, first.ID, &parent.ID, "Читатель", "Ответ")
+	require.NoError(t, err)
+	assert.Equal(t, parent.ID, *reply.ParentID)
+}
+
+// Тест Get: комментарии прикрепляются к копии, экземпляр поста в
+// in-memory хранилище не меняется
+func TestPostGetDoesNotModifyStoredPost(t *testing.T) {
+	ctx := context.Background()
+	mem := storage.NewMemoryStorage()
+	posts, comments := service.New(service.Deps{Store: mem})
+
+	post, err := posts.Create(ctx, "Пост", "Текст", "Автор", true)
+	require.NoError(t, err)
+	_, err = comments.Add(ctx, post.ID, nil, "Читатель", "Привет")
+	require.NoError(t, err)
+
+	got, err := posts.Get(ctx, post.ID, nil)
+	require.NoError(t, err)
+	assert.Len(t, got.Comments, 1)
+	stored, err := mem.GetPostByID(ctx, post.ID)
+	require.NoError(t, err)
+	assert.Empty(t, stored.Comments)
+}
+
+// Тест сервисов с подменёнными часами и генератором ID: у каждого теста своё
+// хранилище, поэтому они выполняются параллельно
+func TestServicesDeps(t *testing.T) {
+	t.Parallel()
+	// Часы в поясе +03:00 с наносекундами: сервис хранит время в UTC
+	// с точностью до микросекунды
+	now := time.Date(2024, 3, 1, 15, 0, 0, 123456789, time.FixedZone("MSK", 3*60*60))
+	created := time.Date(2024, 3, 1, 12, 0, 0, 123456000, time.UTC)
+
+	newServices := func(prefix string) (*service.PostService, *service.CommentService) {
+		return service.New(service.Deps{
+			Store:            storage.NewMemoryStorage(),
+			Clock:            service.ClockFunc(func() time.Time { return now }),
+			IDs:              sequentialIDs(prefix),
+			CommentMaxLength: 20,
+		})
+	}
+
+	t.Run("детерминированные ID и время", func(t *testing.T) {
+		t.Parallel()
+		posts, comments := newServices("a")
+		ctx := context.Background()
+
+		post, err := posts.Create(ctx, "Пост", "Текст", "Автор", true)
+		require.NoError(t, err)
+		assert.Equal(t, "a-1", post.ID)
+		assert.Equal(t, created, post.CreatedAt)
+
+		comment, err := comments.Add(ctx, post.ID, nil, "Читатель", "Привет")
+		require.NoError(t, err)
+		assert.Equal(t, "a-2", comment.ID)
+		assert.Equal(t, post.CreatedAt, comment.CreatedAt)
+
+		got, err := posts.Get(ctx, "a-1", nil)
+		require.NoError(t, err)
+		require.Len(t, got.Comments, 1)
+		assert.Equal(t, "a-2", got.Comments[0].ID)
+	})
+
+	t.Run("правила комментариев", func(t *testing.T) {
+		t.Parallel()
+		posts, comments := newServices("b")
+		ctx := context.Background()
+
+		post, err := posts.Create(ctx, "Пост", "Текст", "Автор", false)
+		require.NoError(t, err)
+		_, err = comments.Add(ctx, post.ID, nil, "Читатель", "Привет")
+		assert.ErrorIs(t, err, service.ErrCommentsDisabled)
+		assert.Equal(t, service.CodeCommentsDisabled, service.ErrorCode(err))
+
+		_, err = posts.SetCommentsAllowed(ctx, post.ID, true)
+		require.NoError(t, err)
+		_, err = comments.Add(ctx, post.ID, nil, "Читатель", "Слишком длинный")
+		assert.Equal(t, service.CodeBadUserInput, service.ErrorCode(err))
+
+		_, err = comments.Add(ctx, "missing", nil, "Читатель", "Привет")
+		assert.ErrorIs(t, err, service.ErrPostNotFound)
+		_, err = comments.List(ctx, post.ID, -1, 0)
+		assert.Equal(t, service.CodeBadUserInput, service.ErrorCode(err))
+	})
+}
@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"ozon_test/auth"
+	"ozon_test/graph/model"
+	"ozon_test/service"
+	"ozon_test/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openSQLite(t *testing.T, path string) *storage.SQLiteStorage {
+	s, err := storage.NewSQLiteStorage(context.Background(), path)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// Тест SQLite: миграции, WAL, иерархия комментариев, каскадное удаление и транзакции
+func TestSQLiteStorage(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "ozon.db")
+	s := openSQLite(t, path)
+
+	var mode string
+	require.NoError(t, s.DB.QueryRowContext(ctx, "PRAGMA journal_mode").Scan(&mode))
+	assert.Equal(t, "wal", mode)
+	version, err := s.MigrationVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 8, version)
+
+	require.NoError(t, s.CreatePost(ctx, &model.Post{ID: "p1", Title: "Пост", CommentsAllowed: true, CreatedAt: timeAt("2024-01-01T00:00:00Z")}))
+	require.NoError(t, s.CreateComment(ctx, &model.Comment{ID: "c1", PostID: "p1", Content: "корень", CreatedAt: timeAt("2024-01-01T00:00:01Z")}))
+	parent := "c1"
+	require.NoError(t, s.CreateComment(ctx, &model.Comment{ID: "c2", PostID: "p1", ParentID: &parent, Content: "ответ", CreatedAt: timeAt("2024-01-01T00:00:02Z")}))
+
+	reply, err := s.GetCommentByID(ctx, "c2")
+	require.NoError(t, err)
+	require.NotNil(t, reply.ParentID)
+	assert.Equal(t, "c1", *reply.ParentID)
+
+	// Ответ на несуществующий комментарий отклоняется внешним ключом
+	missing := "nope"
+	assert.Error(t, s.CreateComment(ctx, &model.Comment{ID: "c3", PostID: "p1", ParentID: &missing}))
+
+	// Откат транзакции
+	err = s.WithTx(ctx, func(tx storage.Storage) error {
+		require.NoError(t, tx.UpdateComment(ctx, &model.Comment{ID: "c1", Content: "изменён"}))
+		return errors.New("abort")
+	})
+	assert.Error(t, err)
+	root, err := s.GetCommentByID(ctx, "c1")
+	require.NoError(t, err)
+	assert.Equal(t, "корень", root.Content)
+
+	// Удаление корня удаляет ответы
+	require.NoError(t, s.DeleteComment(ctx, "c1"))
+	_, err = s.GetCommentByID(ctx, "c2")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.ErrorIs(t, s.DeleteComment(ctx, "c1"), sql.ErrNoRows)
+
+	// Данные и версия схемы сохраняются после переоткрытия
+	require.NoError(t, s.Close())
+	s = openSQLite(t, path)
+	post, err := s.GetPostByID(ctx, "p1")
+	require.NoError(t, err)
+	assert.True(t, post.CommentsAllowed)
+}
+
+// Тест миграции времени: значения прежнего формата (RFC 3339 с точностью до
+// секунды в поясе сервера) переводятся в UTC с фиксированной дробной частью
+func TestSQLiteLegacyTimes(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "ozon.db")
+	s := openSQLite(t, path)
+
+	// Схема версии 3 со строками в прежнем формате: миграции 4–8 применятся
+	// заново (триггеры ленты удаляются вместе с таблицей потребителей)
+	_, err := s.DB.ExecContext(ctx, `DROP TABLE moderation_log;
+		DROP TABLE banned_users;
+		DROP TRIGGER posts_insert_change;
+		DROP TRIGGER posts_update_change;
+		DROP TRIGGER posts_delete_change;
+		DROP TRIGGER comments_insert_change;
+		DROP TRIGGER comments_update_change;
+		DROP TRIGGER comments_delete_change;
+		DROP TABLE change_log_consumers;
+		DROP INDEX idx_posts_scheduled;
+		ALTER TABLE posts DROP COLUMN publish_at;
+		ALTER TABLE posts DROP COLUMN status;
+		DELETE FROM schema_migrations WHERE version >= 4`)
+	require.NoError(t, err)
+	_, err = s.DB.ExecContext(ctx, `INSERT INTO posts (id, title, content, author, comments_allowed, created_at)
+		VALUES ('p1', 'Пост', '', '', 1, '2024-01-01T03:00:00+03:00')`)
+	require.NoError(t, err)
+	_, err = s.DB.ExecContext(ctx, `INSERT INTO comments (id, post_id, content, author, created_at) VALUES
+		('c1', 'p1', 'старый', '', '2024-01-01T00:00:05Z'),
+		('c2', 'p1', 'новый', '', '2024-01-01T00:00:05.5Z')`)
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	s = openSQLite(t, path)
+	var raw string
+	require.NoError(t, s.DB.QueryRowContext(ctx, `SELECT created_at FROM posts WHERE id = 'p1'`).Scan(&raw))
+	assert.Equal(t, "2024-01-01T00:00:00.000000000Z", raw)
+
+	post, err := s.GetPostByID(ctx, "p1")
+	require.NoError(t, err)
+	assert.Equal(t, timeAt("2024-01-01T00:00:00Z"), post.CreatedAt)
+	// Прежние посты считаются опубликованными в момент создания
+	assert.Equal(t, model.PostStatusPublished, post.Status)
+	require.NotNil(t, post.PublishAt)
+	assert.Equal(t, post.CreatedAt, *post.PublishAt)
+	comments, err := s.GetCommentsByPostID(ctx, "p1", 0, 0)
+	require.NoError(t, err)
+	require.Len(t, comments, 2)
+	assert.Equal(t, "c2", comments[0].ID)
+	assert.Equal(t, timeAt("2024-01-01T00:00:05.5Z"), comments[0].CreatedAt)
+}
+
+// Тест резолверов поверх SQLite
+func TestSQLiteResolvers(t *testing.T) {
+	resolver := newResolver(service.Deps{Store: openSQLite(t, filepath.Join(t.TempDir(), "ozon.db"))})
+	ctx := context.Background()
+
+	post, err := resolver.Mutation().CreatePost(ctx, "Тест", "Контент", "Автор", true)
+	require.NoError(t, err)
+	comment, err := resolver.Mutation().AddComment(ctx, post.ID, nil, "Читатель", "Комментарий")
+	require.NoError(t, err)
+	_, err = resolver.Mutation().AddComment(ctx, post.ID, &comment.ID, "Автор", "Ответ")
+	require.NoError(t, err)
+
+	_, err = resolver.Mutation().SetCommentsAllowed(auth.WithAdmin(ctx), post.ID, false)
+	require.NoError(t, err)
+	_, err = resolver.Mutation().AddComment(ctx, post.ID, nil, "Читатель", "Ещё")
+	assert.Error(t, err)
+
+	comments, err := resolver.Query().Comments(ctx, post.ID, 10, 0)
+	require.NoError(t, err)
+	assert.Len(t, comments, 2)
+}
@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"ozon_test/graph"
+	"ozon_test/service"
+	"ozon_test/sse"
+	"ozon_test/storage"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sseEvent struct {
+	id, event, data string
+}
+
+// readSSE читает события из потока, пропуская комментарии-пинги.
+func readSSE(t *testing.T, sc *bufio.Scanner) sseEvent {
+	t.Helper()
+	var e sseEvent
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case line == "":
+			if e.event != "" {
+				return e
+			}
+		case strings.HasPrefix(line, "id: "):
+			e.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			e.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			e.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	t.Fatal("Поток SSE закрылся раньше времени")
+	return e
+}
+
+// Тест подписки по SSE с возобновлением по Last-Event-ID
+func TestSSESubscriptionResume(t *testing.T) {
+	resolver := newResolver(service.Deps{})
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: resolver}))
+	srv.AddTransport(sse.Transport{Heartbeat: 10 * time.Millisecond})
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	ctx := context.Background()
+	post, _ := resolver.Mutation().CreatePost(ctx, "SSE", "Контент", "Автор", true)
+
+	query := url.Values{
+		"query":     {`subscription($postId: ID!) { commentAdded(postId: $postId) { id content } }`},
+		"variables": {`{"postId":"` + post.ID + `"}`},
+	}
+	subscribe := func(lastEventID string) (*http.Response, *bufio.Scanner) {
+		reqCtx, cancel := context.WithCancel(ctx)
+		t.Cleanup(cancel)
+		req, _ := http.NewRequestWithContext(reqCtx, http.MethodGet, ts.URL+"?"+query.Encode(), nil)
+		req.Header.Set("Accept", "text/event-stream")
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+		return resp, bufio.NewScanner(resp.Body)
+	}
+
+	resp, stream := subscribe("")
+	time.Sleep(50 * time.Millisecond)
+	_, _ = resolver.Mutation().AddComment(ctx, post.ID, nil, "User", "Первый")
+
+	first := readSSE(t, stream)
+	assert.Equal(t, "next", first.event)
+	assert.Contains(t, first.data, "Первый")
+	assert.NotEmpty(t, first.id)
+	resp.Body.Close()
+
+	_, _ = resolver.Mutation().AddComment(ctx, post.ID, nil, "User", "Пропущенный")
+
+	resp, stream = subscribe(first.id)
+	defer resp.Body.Close()
+	missed := readSSE(t, stream)
+	assert.Contains(t, missed.data, "Пропущенный")
+	assert.NotEqual(t, first.id, missed.id)
+}
+
+// Тест GET по SSE: мутация отклоняется до выполнения, чтобы ее нельзя было
+// вызвать с чужой страницы через EventSource
+func TestSSERejectsMutationOverGET(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: newResolver(service.Deps{Store: store})}))
+	srv.AddTransport(sse.Transport{})
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	query := url.Values{"query": {`mutation { createPost(title: "CSRF", content: "", author: "Автор", commentsAllowed: true) { id } }`}}
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"?"+query.Encode(), nil)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	assert.Equal(t, http.MethodPost, resp.Header.Get("Allow"))
+
+	posts, err := store.GetAllPosts(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, posts)
+}
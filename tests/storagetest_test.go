@@ -0,0 +1,53 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ozon_test/config"
+	"ozon_test/storage"
+	"ozon_test/storage/storagetest"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Контракт хранилища: in-memory проверяется всегда
+func TestStorageContractMemory(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		return storage.NewMemoryStorage()
+	})
+}
+
+func TestStorageContractDurableMemory(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		return openDurable(t, t.TempDir())
+	})
+}
+
+func TestStorageContractSQLite(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		return openSQLite(t, filepath.Join(t.TempDir(), "ozon.db"))
+	})
+}
+
+// PostgreSQL проверяется, если задан POSTGRES_TEST_DSN; миграции
+// применяются при подключении, таблицы очищаются перед каждым сценарием.
+func TestStorageContractPostgres(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN не задан")
+	}
+
+	cfg := config.Default().Storage
+	cfg.DSN = dsn
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		pg, err := storage.NewPostgresStorage(context.Background(), cfg)
+		require.NoError(t, err)
+		t.Cleanup(func() { pg.Close() })
+		_, err = pg.DB.Exec(`TRUNCATE posts, comments, banned_users, moderation_log CASCADE`)
+		require.NoError(t, err)
+		return pg
+	})
+}
@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ozon_test/config"
+	"ozon_test/graph"
+	"ozon_test/service"
+	"ozon_test/tracing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// Тест трассировки HTTP-запроса, операции и резолверов с W3C-контекстом
+func TestTracingSpans(t *testing.T) {
+	_, err := tracing.Setup(t.Context(), &config.Config{Tracing: config.TracingConfig{Exporter: "none"}})
+	require.NoError(t, err)
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(sdktrace.NewTracerProvider())
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: newResolver(service.Deps{})}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(tracing.Extension{})
+	h := tracing.Middleware(srv)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"query Feed { posts { id } }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	names := map[string]bool{}
+	for _, span := range recorder.Ended() {
+		names[span.Name()] = true
+		assert.Equal(t, traceID, span.SpanContext().TraceID().String(), span.Name())
+	}
+	assert.True(t, names["POST /query"])
+	assert.True(t, names["graphql.query Feed"])
+	assert.True(t, names["graphql.resolve Query.posts"])
+}
+
+// Тест трассировки миграций и ленты изменений SQLite
+func TestTracingStorageFeedSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(sdktrace.NewTracerProvider())
+
+	ctx := t.Context()
+	s := openSQLite(t, filepath.Join(t.TempDir(), "ozon.db"))
+	_, err := s.TrackChanges(ctx, "replica")
+	require.NoError(t, err)
+	_, err = s.Changes(ctx, 0, 10)
+	require.NoError(t, err)
+	require.NoError(t, s.AckChanges(ctx, "replica", 0))
+
+	names := map[string]int{}
+	for _, span := range recorder.Ended() {
+		names[span.Name()]++
+	}
+	assert.Equal(t, 1, names["sqlite.Migrate"])
+	assert.Positive(t, names["sqlite.ApplyMigration"])
+	assert.Equal(t, 1, names["sqlite.TrackChanges"])
+	assert.Equal(t, 1, names["sqlite.Changes"])
+	assert.Equal(t, 1, names["sqlite.AckChanges"])
+}
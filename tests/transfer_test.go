@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ozon_test/graph/model"
+	"ozon_test/storage"
+	"ozon_test/transfer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест выгрузки и загрузки: перенос из памяти в SQLite сохраняет ID
+// и ссылки на родителей, повторный импорт ничего не меняет
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	src := storage.NewMemoryStorage()
+	require.NoError(t, src.CreatePost(ctx, &model.Post{ID: "p1", Title: "Пост", Author: "a", CommentsAllowed: true, CreatedAt: timeAt("2024-01-01T00:00:00Z")}))
+	require.NoError(t, src.CreatePost(ctx, &model.Post{ID: "p2", Title: "Второй", Author: "b", CreatedAt: timeAt("2024-01-02T00:00:00Z")}))
+	root := "c1"
+	reply := "c2"
+	require.NoError(t, src.CreateComment(ctx, &model.Comment{ID: "c1", PostID: "p1", Content: "корень", CreatedAt: timeAt("2024-01-01T00:01:00Z")}))
+	// Ответ с тем же временем: порядок по ID поставил бы его раньше родителя
+	require.NoError(t, src.CreateComment(ctx, &model.Comment{ID: "c2", PostID: "p1", ParentID: &root, Content: "ответ", CreatedAt: timeAt("2024-01-01T00:01:00Z")}))
+	require.NoError(t, src.CreateComment(ctx, &model.Comment{ID: "c0", PostID: "p1", ParentID: &reply, Content: "вложенный", CreatedAt: timeAt("2024-01-01T00:01:00Z")}))
+
+	var dump bytes.Buffer
+	exported, err := transfer.Export(ctx, src, &dump, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, exported.Posts)
+	assert.Equal(t, 3, exported.Comments)
+
+	dst := openSQLite(t, filepath.Join(t.TempDir(), "ozon.db"))
+	var reports []transfer.Progress
+	imported, err := transfer.Import(ctx, dst, bytes.NewReader(dump.Bytes()), transfer.Options{
+		BatchSize: 2,
+		Progress:  func(p transfer.Progress) { reports = append(reports, p) },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 5, imported.Created)
+	assert.Len(t, reports, 3)
+
+	nested, err := dst.GetCommentByID(ctx, "c0")
+	require.NoError(t, err)
+	require.NotNil(t, nested.ParentID)
+	assert.Equal(t, "c2", *nested.ParentID)
+
+	// Повторный импорт идемпотентен, изменённые записи обновляются
+	changed := strings.Replace(dump.String(), `"content":"ответ"`, `"content":"исправлено"`, 1)
+	again, err := transfer.Import(ctx, dst, strings.NewReader(changed), transfer.Options{})
+	require.NoError(t, err)
+	assert.Equal(t, 0, again.Created)
+	assert.Equal(t, 1, again.Updated)
+	assert.Equal(t, 4, again.Unchanged)
+
+	var roundTrip bytes.Buffer
+	_, err = transfer.Export(ctx, dst, &roundTrip, nil)
+	require.NoError(t, err)
+	assert.Equal(t, changed, roundTrip.String())
+}
+
+// Тест проверки ссылок: ошибка указывает строку, dry-run ничего не пишет
+func TestImportValidation(t *testing.T) {
+	ctx := context.Background()
+	s := storage.NewMemoryStorage()
+
+	input := `{"post":{"id":"p1","title":"t","content":"","author":"a","commentsAllowed":true,"createdAt":"2024-01-01T00:00:00Z"}}
+
+{"comment":{"id":"c1","postId":"p1","author":"a","content":"x","createdAt":"2024-01-01T00:00:00Z"}}
+{"comment":{"id":"c2","postId":"p1","parentId":"missing","author":"a","content":"x","createdAt":"2024-01-01T00:00:00Z"}}
+`
+	_, err := transfer.Import(ctx, s, strings.NewReader(input), transfer.Options{})
+	var lineErr *transfer.LineError
+	require.ErrorAs(t, err, &lineErr)
+	assert.Equal(t, 4, lineErr.Line)
+	assert.Contains(t, err.Error(), "missing")
+
+	p, err := transfer.Import(ctx, s, strings.NewReader(input[:strings.LastIndex(input, `{"comment":{"id":"c2"`)]), transfer.Options{DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, p.Posts)
+	assert.Equal(t, 1, p.Comments)
+	posts, err := s.GetAllPosts(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, posts)
+
+	_, err = transfer.Import(ctx, s, strings.NewReader(`{"comment":{"id":"c1","postId":"nope"}}`), transfer.Options{})
+	assert.ErrorContains(t, err, "несуществующий пост")
+	_, err = transfer.Import(ctx, s, strings.NewReader(`{"post":{"id":"p1"},"comment":{"id":"c1","postId":"p1"}}`), transfer.Options{})
+	assert.ErrorContains(t, err, "ровно одно")
+}
+
+// Тест Upsert поста: смена статуса и времени публикации — обновление,
+// запись из выгрузки без статуса совпадает с опубликованным постом
+func TestUpsertPostStatus(t *testing.T) {
+	ctx := context.Background()
+	dst := openSQLite(t, filepath.Join(t.TempDir(), "ozon.db"))
+	created := timeAt("2024-01-01T00:00:00Z")
+	publishAt := timeAt("2024-01-02T00:00:00Z")
+	scheduled := &model.Post{ID: "p1", Title: "Анонс", Author: "a", CreatedAt: created, Status: model.PostStatusScheduled, PublishAt: &publishAt}
+	require.NoError(t, dst.CreatePost(ctx, scheduled))
+
+	upsert := func(post *model.Post) transfer.Progress {
+		t.Helper()
+		var p transfer.Progress
+		require.NoError(t, transfer.Upsert(ctx, dst, transfer.Record{Post: post}, &p))
+		return p
+	}
+
+	published := *scheduled
+	published.Status = model.PostStatusPublished
+	assert.Equal(t, 1, upsert(&published).Updated)
+	got, err := dst.GetPostByID(ctx, "p1")
+	require.NoError(t, err)
+	assert.Equal(t, model.PostStatusPublished, got.Status)
+
+	moved := published
+	movedAt := publishAt.Add(time.Hour)
+	moved.PublishAt = &movedAt
+	assert.Equal(t, 1, upsert(&moved).Updated)
+	got, err = dst.GetPostByID(ctx, "p1")
+	require.NoError(t, err)
+	require.NotNil(t, got.PublishAt)
+	assert.True(t, movedAt.Equal(*got.PublishAt))
+	assert.Equal(t, 1, upsert(&moved).Unchanged)
+
+	// Выгрузка до появления статусов: опубликован в момент создания
+	legacy := &model.Post{ID: "p2", Title: "Старый", Author: "a", CreatedAt: created}
+	require.NoError(t, dst.CreatePost(ctx, &model.Post{ID: "p2", Title: "Старый", Author: "a", CreatedAt: created, Status: model.PostStatusPublished, PublishAt: &created}))
+	assert.Equal(t, 1, upsert(legacy).Unchanged)
+}
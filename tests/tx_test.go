@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"ozon_test/config"
+	"ozon_test/graph/model"
+	"ozon_test/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест транзакций in-memory хранилища: фиксация и откат всех изменений
+func TestMemoryWithTx(t *testing.T) {
+	ctx := context.Background()
+	s := storage.NewMemoryStorage()
+	require.NoError(t, s.CreatePost(ctx, &model.Post{ID: "p1", CommentsAllowed: true}))
+	require.NoError(t, s.CreateComment(ctx, &model.Comment{ID: "c1", PostID: "p1", Content: "первый"}))
+
+	errAbort := errors.New("abort")
+	err := s.WithTx(ctx, func(tx storage.Storage) error {
+		require.NoError(t, tx.CreatePost(ctx, &model.Post{ID: "p2"}))
+		require.NoError(t, tx.CreateComment(ctx, &model.Comment{ID: "c2", PostID: "p1"}))
+		require.NoError(t, tx.UpdateComment(ctx, &model.Comment{ID: "c1", PostID: "p1", Content: "изменён"}))
+		require.NoError(t, tx.UpdatePost(ctx, &model.Post{ID: "p1", CommentsAllowed: false}))
+		require.NoError(t, tx.DeleteComment(ctx, "c1"))
+
+		// Внутри транзакции изменения видны
+		_, err := tx.GetCommentByID(ctx, "c1")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+
+	_, err = s.GetPostByID(ctx, "p2")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	post, err := s.GetPostByID(ctx, "p1")
+	require.NoError(t, err)
+	assert.True(t, post.CommentsAllowed)
+	comments, err := s.GetCommentsByPostID(ctx, "p1", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+	assert.Equal(t, "первый", comments[0].Content)
+	_, err = s.GetCommentByID(ctx, "c2")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	err = s.WithTx(ctx, func(tx storage.Storage) error {
+		return tx.CreateComment(ctx, &model.Comment{ID: "c3", PostID: "p1"})
+	})
+	require.NoError(t, err)
+	_, err = s.GetCommentByID(ctx, "c3")
+	assert.NoError(t, err)
+}
+
+// Тест транзакции PostgreSQL: пост блокируется FOR SHARE, запись идет в той же транзакции
+func TestPostgresWithTx(t *testing.T) {
+	primaryDB, primary := openFake(t, "primary")
+	pg := storage.NewPostgresStorageFromDB(primaryDB, nil, config.Default().Storage)
+	defer pg.Close()
+	ctx := context.Background()
+
+	err := pg.WithTx(ctx, func(tx storage.Storage) error {
+		if _, err := tx.GetPostByID(ctx, "1"); err != nil {
+			return err
+		}
+		return tx.CreateComment(ctx, &model.Comment{ID: "c1", PostID: "1"})
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), primary.commits.Load())
+	require.Len(t, primary.queries, 2)
+	assert.Contains(t, primary.queries[0], "FOR SHARE")
+
+	err = pg.WithTx(ctx, func(tx storage.Storage) error {
+		return errors.New("abort")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, int64(1), primary.rollbacks.Load())
+
+	// Вне транзакции пост читается без блокировки
+	_, err = pg.GetPostByID(ctx, "1")
+	require.NoError(t, err)
+	assert.NotContains(t, primary.queries[2], "FOR SHARE")
+}
+
+// Тест чтения для изменения: в транзакции пост блокируется FOR UPDATE,
+// вне транзакции — обычное чтение
+func TestPostgresGetPostForUpdate(t *testing.T) {
+	primaryDB, primary := openFake(t, "primary")
+	pg := storage.NewPostgresStorageFromDB(primaryDB, nil, config.Default().Storage)
+	defer pg.Close()
+	ctx := context.Background()
+
+	err := pg.WithTx(ctx, func(tx storage.Storage) error {
+		post, err := tx.GetPostForUpdate(ctx, "1")
+		if err != nil {
+			return err
+		}
+		return tx.UpdatePost(ctx, post)
+	})
+	require.NoError(t, err)
+	require.Len(t, primary.queries, 2)
+	assert.Contains(t, primary.queries[0], "FOR UPDATE")
+	assert.NotContains(t, primary.queries[0], "FOR SHARE")
+
+	_, err = pg.GetPostForUpdate(ctx, "1")
+	require.NoError(t, err)
+	assert.NotContains(t, primary.queries[2], "FOR UPDATE")
+}
@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Extension — расширение gqlgen: span на каждую операцию (кроме подписок)
+// и на каждое поле с резолвером, выполнявшееся дольше FieldThreshold.
+type Extension struct {
+	// FieldThreshold — минимальная длительность резолвера, при которой
+	// для поля создается span; 0 — все поля с резолверами.
+	FieldThreshold time.Duration
+}
+
+var (
+	_ graphql.HandlerExtension     = Extension{}
+	_ graphql.OperationInterceptor = Extension{}
+	_ graphql.FieldInterceptor     = Extension{}
+)
+
+func (Extension) ExtensionName() string { return "OpenTelemetry" }
+
+func (Extension) Validate(graphql.ExecutableSchema) error { return nil }
+
+func (e Extension) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	opCtx := graphql.GetOperationContext(ctx)
+	if opCtx.Operation == nil || opCtx.Operation.Operation == ast.Subscription {
+		return next(ctx)
+	}
+
+	name := opCtx.Operation.Name
+	if name == "" {
+		name = "anonymous"
+	}
+	ctx, span := Tracer().Start(ctx, "graphql."+string(opCtx.Operation.Operation)+" "+name,
+		trace.WithAttributes(
+			attribute.String("graphql.operation.type", string(opCtx.Operation.Operation)),
+			attribute.String("graphql.operation.name", name),
+		))
+
+	handler := next(ctx)
+	return func(ctx context.Context) *graphql.Response {
+		defer span.End()
+		resp := handler(ctx)
+		if resp != nil && len(resp.Errors) > 0 {
+			span.SetStatus(codes.Error, resp.Errors.Error())
+		}
+		return resp
+	}
+}
+
+func (e Extension) InterceptField(ctx context.Context, next graphql.Resolver) (any, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsResolver {
+		return next(ctx)
+	}
+
+	start := time.Now()
+	res, err := next(ctx)
+	elapsed := time.Since(start)
+	if elapsed < e.FieldThreshold {
+		return res, err
+	}
+
+	// Span создается задним числом, чтобы быстрые поля не попадали в трассу
+	_, span := Tracer().Start(ctx, "graphql.resolve "+fc.Object+"."+fc.Field.Name,
+		trace.WithTimestamp(start),
+		trace.WithAttributes(
+			attribute.String("graphql.field.path", fc.Path().String()),
+			attribute.String("graphql.field.type", fc.Field.Definition.Type.String()),
+		))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End(trace.WithTimestamp(start.Add(elapsed)))
+	return res, err
+}
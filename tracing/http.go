@@ -0,0 +1,25 @@
+package tracing
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// untraced — служебные эндпоинты, которые опрашиваются слишком часто,
+// чтобы трассировать их.
+var untraced = map[string]bool{"/healthz": true, "/readyz": true, "/metrics": true}
+
+// Middleware открывает span на каждый HTTP-запрос, продолжая трассу из
+// заголовков traceparent/tracestate. Websocket-соединения не трассируются:
+// span жил бы всё время соединения.
+func Middleware(h http.Handler) http.Handler {
+	return otelhttp.NewHandler(h, "http.server",
+		otelhttp.WithFilter(func(r *http.Request) bool {
+			return r.Header.Get("Upgrade") == "" && !untraced[r.URL.Path]
+		}),
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + r.URL.Path
+		}),
+	)
+}
@@ -0,0 +1,417 @@
+// Package transfer выгружает и загружает посты и комментарии в формате
+// NDJSON: одна запись Record на строку. Работает с любым storage.Storage.
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"ozon_test/graph/model"
+	"ozon_test/storage"
+)
+
+// Record — строка NDJSON; заполнено ровно одно поле.
+type Record struct {
+	Post    *model.Post    `json:"post,omitempty"`
+	Comment *model.Comment `json:"comment,omitempty"`
+}
+
+// Progress — счетчики выгрузки или загрузки.
+type Progress struct {
+	Posts    int `json:"posts"`
+	Comments int `json:"comments"`
+	// Только для импорта: результат upsert.
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+}
+
+// Records возвращает общее число обработанных записей.
+func (p Progress) Records() int { return p.Posts + p.Comments }
+
+const (
+	// DefaultBatchSize — записей импорта в одной транзакции.
+	DefaultBatchSize = 500
+	// exportProgressEvery — как часто экспорт сообщает о прогрессе.
+	exportProgressEvery = 1000
+	maxLineSize         = 16 << 20
+)
+
+// Export выгружает все посты, за каждым — его комментарии. Родительский
+// комментарий всегда идет раньше ответов, поэтому вывод можно загрузить
+// Import в один проход. progress (может быть nil) вызывается периодически
+// и в конце.
+func Export(ctx context.Context, s storage.Storage, w io.Writer, progress func(Progress)) (Progress, error) {
+	var p Progress
+	report := func() {
+		if progress != nil {
+			progress(p)
+		}
+	}
+
+	posts, err := s.GetAllPosts(ctx)
+	if err != nil {
+		return p, err
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		if !posts[i].CreatedAt.Equal(posts[j].CreatedAt) {
+			return posts[i].CreatedAt.Before(posts[j].CreatedAt)
+		}
+		return posts[i].ID < posts[j].ID
+	})
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	for _, post := range posts {
+		if err := ctx.Err(); err != nil {
+			return p, err
+		}
+		out := *post
+		out.Comments = nil
+		if err := enc.Encode(Record{Post: &out}); err != nil {
+			return p, err
+		}
+		p.Posts++
+
+		comments, err := s.GetCommentsByPostID(ctx, post.ID, 0, 0)
+		if err != nil {
+			return p, fmt.Errorf("комментарии поста %s: %w", post.ID, err)
+		}
+		for _, comment := range ParentsFirst(comments) {
+			out := *comment
+			out.Cursor = nil
+			if err := enc.Encode(Record{Comment: &out}); err != nil {
+				return p, err
+			}
+			p.Comments++
+			if p.Records()%exportProgressEvery == 0 {
+				report()
+			}
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return p, err
+	}
+	report()
+	return p, nil
+}
+
+// ParentsFirst упорядочивает комментарии поста (в порядке хранилища — от
+// новых к старым) от старых к новым так, чтобы родитель шел раньше ответов.
+func ParentsFirst(newestFirst []*model.Comment) []*model.Comment {
+	pending := make([]*model.Comment, 0, len(newestFirst))
+	for i := len(newestFirst) - 1; i >= 0; i-- {
+		pending = append(pending, newestFirst[i])
+	}
+
+	ordered := make([]*model.Comment, 0, len(pending))
+	emitted := make(map[string]bool, len(pending))
+	for len(pending) > 0 {
+		var deferred []*model.Comment
+		for _, c := range pending {
+			if c.ParentID == nil || emitted[*c.ParentID] {
+				ordered = append(ordered, c)
+				emitted[c.ID] = true
+			} else {
+				deferred = append(deferred, c)
+			}
+		}
+		if len(deferred) == len(pending) {
+			// Родителя нет среди комментариев поста: порядок не важен
+			return append(ordered, deferred...)
+		}
+		pending = deferred
+	}
+	return ordered
+}
+
+// Options — параметры импорта.
+type Options struct {
+	// BatchSize — записей в одной транзакции; 0 — DefaultBatchSize.
+	BatchSize int
+	// DryRun только проверяет ввод и ссылки, ничего не записывая.
+	DryRun bool
+	// Progress вызывается после каждой пачки и в конце; может быть nil.
+	Progress func(Progress)
+}
+
+// LineError — ошибка в строке ввода импорта.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string { return fmt.Sprintf("строка %d: %v", e.Line, e.Err) }
+
+func (e *LineError) Unwrap() error { return e.Err }
+
+// ErrConflict — запись с тем же ID уже есть, но ссылается на другой пост
+// или другого родителя.
+var ErrConflict = errors.New("конфликт с существующей записью")
+
+// Import загружает записи NDJSON с сохранением ID и ссылок на родителей.
+// Запись upsert-ится: отсутствующая создается, отличающаяся обновляется
+// (у поста — заголовок, текст и режим комментирования, у комментария —
+// текст), совпадающая пропускается, поэтому повторный импорт безопасен.
+// Пост комментария и родитель должны быть в хранилище или выше во вводе.
+// Записи пишутся пачками в транзакциях; при ошибке пачка откатывается,
+// а предыдущие остаются записанными.
+func Import(ctx context.Context, s storage.Storage, r io.Reader, opts Options) (Progress, error) {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	imp := &importer{
+		s:        s,
+		posts:    make(map[string]bool),
+		comments: make(map[string]string),
+	}
+	var (
+		p     Progress
+		batch []numbered
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if !opts.DryRun {
+			err := s.WithTx(ctx, func(tx storage.Storage) error {
+				for _, rec := range batch {
+					if err := Upsert(ctx, tx, rec.Record, &p); err != nil {
+						return &LineError{Line: rec.line, Err: err}
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		for _, rec := range batch {
+			if rec.Post != nil {
+				p.Posts++
+			} else {
+				p.Comments++
+			}
+		}
+		batch = batch[:0]
+		if opts.Progress != nil {
+			opts.Progress(p)
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), maxLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		if err := ctx.Err(); err != nil {
+			return p, err
+		}
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		rec, err := decode(data)
+		if err == nil {
+			err = imp.validate(ctx, rec)
+		}
+		if err != nil {
+			return p, &LineError{Line: line, Err: err}
+		}
+
+		batch = append(batch, numbered{Record: rec, line: line})
+		if len(batch) >= batchSize {
+			if err := flush(); err != nil {
+				return p, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return p, err
+	}
+	if err := flush(); err != nil {
+		return p, err
+	}
+	if opts.Progress != nil && p.Records() == 0 {
+		opts.Progress(p)
+	}
+	return p, nil
+}
+
+// Copy переносит все посты и комментарии из from в to: выгрузка Export
+// передается в Import без промежуточного файла.
+func Copy(ctx context.Context, from, to storage.Storage, opts Options) (Progress, error) {
+	pr, pw := io.Pipe()
+	exported := make(chan error, 1)
+	go func() {
+		_, err := Export(ctx, from, pw, nil)
+		pw.CloseWithError(err)
+		exported <- err
+	}()
+
+	p, err := Import(ctx, to, pr, opts)
+	// Если импорт остановился раньше, экспорт не должен ждать читателя
+	pr.CloseWithError(errors.New("импорт остановлен"))
+	if exportErr := <-exported; err == nil && exportErr != nil {
+		err = fmt.Errorf("выгрузка: %w", exportErr)
+	}
+	return p, err
+}
+
+type numbered struct {
+	Record
+	line int
+}
+
+func decode(data []byte) (Record, error) {
+	var rec Record
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&rec); err != nil {
+		return rec, fmt.Errorf("некорректный JSON: %w", err)
+	}
+
+	switch {
+	case (rec.Post == nil) == (rec.Comment == nil):
+		return rec, errors.New("запись должна содержать ровно одно из полей post или comment")
+	case rec.Post != nil && rec.Post.ID == "":
+		return rec, errors.New("у поста нет id")
+	case rec.Comment != nil && rec.Comment.ID == "":
+		return rec, errors.New("у комментария нет id")
+	case rec.Comment != nil && rec.Comment.PostID == "":
+		return rec, fmt.Errorf("у комментария %s нет postId", rec.Comment.ID)
+	}
+	if rec.Post != nil {
+		rec.Post.Comments = nil
+	} else {
+		rec.Comment.Cursor = nil
+	}
+	return rec, nil
+}
+
+// importer проверяет ссылки: помнит посты и комментарии, уже встреченные
+// во вводе или найденные в хранилище.
+type importer struct {
+	s     storage.Storage
+	posts map[string]bool
+	// comments — ID комментария → ID его поста.
+	comments map[string]string
+}
+
+func (imp *importer) validate(ctx context.Context, rec Record) error {
+	if rec.Post != nil {
+		imp.posts[rec.Post.ID] = true
+		return nil
+	}
+
+	c := rec.Comment
+	if !imp.posts[c.PostID] {
+		if _, err := imp.s.GetPostByID(ctx, c.PostID); errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("комментарий %s ссылается на несуществующий пост %s", c.ID, c.PostID)
+		} else if err != nil {
+			return err
+		}
+		imp.posts[c.PostID] = true
+	}
+
+	if c.ParentID != nil {
+		parentPost, ok := imp.comments[*c.ParentID]
+		if !ok {
+			parent, err := imp.s.GetCommentByID(ctx, *c.ParentID)
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("комментарий %s ссылается на несуществующий родитель %s", c.ID, *c.ParentID)
+			} else if err != nil {
+				return err
+			}
+			parentPost = parent.PostID
+			imp.comments[parent.ID] = parentPost
+		}
+		if parentPost != c.PostID {
+			return fmt.Errorf("родитель %s комментария %s относится к другому посту", *c.ParentID, c.ID)
+		}
+	}
+	imp.comments[c.ID] = c.PostID
+	return nil
+}
+
+// Upsert создает запись, если ее нет, обновляет, если она отличается,
+// и учитывает результат в p. Пост сравнивается вместе со статусом и
+// временем публикации. Комментарий с тем же ID, но другим постом или
+// родителем — ErrConflict.
+func Upsert(ctx context.Context, tx storage.Storage, rec Record, p *Progress) error {
+	if rec.Post != nil {
+		post := withStatus(rec.Post)
+		existing, err := tx.GetPostByID(ctx, post.ID)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			p.Created++
+			return tx.CreatePost(ctx, post)
+		case err != nil:
+			return err
+		case existing.Title == post.Title && existing.Content == post.Content && existing.CommentsAllowed == post.CommentsAllowed &&
+			existing.Status == post.Status && sameTime(existing.PublishAt, post.PublishAt):
+			p.Unchanged++
+			return nil
+		default:
+			p.Updated++
+			return tx.UpdatePost(ctx, post)
+		}
+	}
+
+	comment := rec.Comment
+	existing, err := tx.GetCommentByID(ctx, comment.ID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		p.Created++
+		return tx.CreateComment(ctx, comment)
+	case err != nil:
+		return err
+	case existing.PostID != comment.PostID || !sameParent(existing.ParentID, comment.ParentID):
+		return fmt.Errorf("комментарий %s: %w", comment.ID, ErrConflict)
+	case existing.Content == comment.Content:
+		p.Unchanged++
+		return nil
+	default:
+		p.Updated++
+		return tx.UpdateComment(ctx, comment)
+	}
+}
+
+// withStatus возвращает пост из выгрузки, сделанной до появления статусов,
+// опубликованным в момент создания — как после миграции хранилищ.
+func withStatus(post *model.Post) *model.Post {
+	if post.Status != "" {
+		return post
+	}
+	published := *post
+	published.Status = model.PostStatusPublished
+	if published.PublishAt == nil {
+		published.PublishAt = &published.CreatedAt
+	}
+	return &published
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func sameParent(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}